@@ -2,3 +2,10 @@ module kiki-ai-shell
 
 go 1.22
 
+require (
+	github.com/creack/pty v1.1.24
+	golang.org/x/term v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/sys v0.20.0 // indirect
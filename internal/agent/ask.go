@@ -22,7 +22,7 @@ type AskOpts struct {
 // then asks the final question using the condensed summary.
 //
 // This is a local agent (no extra server). It reduces ctx-size explosions for CPU-only llama.cpp.
-func AskWithAutoChunk(ctx context.Context, endpoint string, systemPrompt string, userQuestion string, userContent string, opts AskOpts) (string, error) {
+func AskWithAutoChunk(ctx context.Context, endpoint llm.Endpoint, systemPrompt string, userQuestion string, userContent string, opts AskOpts) (string, error) {
 	maxCtx := opts.MaxCtx
 	if maxCtx <= 0 {
 		// No ctx info: just do normal request.
@@ -76,7 +76,7 @@ func buildChunkMessage(idx, total int, chunk, running string) string {
 	return fmt.Sprintf("[PART %d/%d]\n%s\n\n[CURRENT SUMMARY]\n%s\n\n당신의 임무: 기존 요약을 유지하되, 새 내용이 추가되면 덧붙이고, 중복은 제거해서 12줄 이내로 업데이트하세요. (설명 금지, 요약만)\n", idx, total, chunk, running)
 }
 
-func single(ctx context.Context, endpoint, systemPrompt, userContent string, opts AskOpts) (string, error) {
+func single(ctx context.Context, endpoint llm.Endpoint, systemPrompt, userContent string, opts AskOpts) (string, error) {
 	req := llm.ChatRequest{
 		Model:       opts.Model,
 		Temperature: opts.Temp,
@@ -0,0 +1,239 @@
+// Package agentd is the client for the KIKI agentd REST API (:8082
+// /api/v1/*): account registration, login with token persistence and the
+// authenticated endpoints that build on it.
+package agentd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is where agentd listens in the KIKI pod.
+const DefaultBaseURL = "http://127.0.0.1:8082"
+
+// ErrUnauthorized is returned when agentd rejects the stored token.
+var ErrUnauthorized = errors.New("agentd rejected the token (log in again: kiki-ai-shell login)")
+
+// Client talks to one agentd server. The stored token is attached only
+// when it was issued by that same server.
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+	Session *Session // nil when not logged in to BaseURL
+}
+
+// NewClient returns a client for baseURL (DefaultBaseURL when empty) with
+// the stored session, if it belongs to that server. An expired or unsafe
+// token file is reported in err; the client still works for anonymous
+// calls (register, login).
+func NewClient(baseURL string) (*Client, error) {
+	c := &Client{BaseURL: NormalizeURL(baseURL), HTTP: &http.Client{Timeout: 30 * time.Second}}
+	s, err := LoadSession()
+	if err != nil {
+		return c, err
+	}
+	if s.BaseURL == c.BaseURL {
+		c.Session = s
+	}
+	return c, nil
+}
+
+// NormalizeURL adds http:// and drops a trailing slash.
+func NormalizeURL(u string) string {
+	u = strings.TrimSpace(u)
+	if u == "" {
+		return DefaultBaseURL
+	}
+	if !strings.Contains(u, "://") {
+		u = "http://" + u
+	}
+	return strings.TrimRight(u, "/")
+}
+
+// Register creates an account.
+func (c *Client) Register(ctx context.Context, username, password string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/register", credentials(username, password), nil, false)
+}
+
+// Login exchanges credentials for a token and stores it in
+// ~/.kiki/config.json.
+func (c *Client) Login(ctx context.Context, username, password string) (*Session, error) {
+	var resp struct {
+		AccessToken string          `json:"access_token"`
+		Token       string          `json:"token"`
+		TokenType   string          `json:"token_type"`
+		ExpiresIn   json.RawMessage `json:"expires_in"`
+		ExpiresAt   json.RawMessage `json:"expires_at"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/v1/login", credentials(username, password), &resp, false)
+	var he *HTTPError
+	if errors.As(err, &he) && he.Status == http.StatusUnprocessableEntity {
+		// FastAPI OAuth2PasswordRequestForm wants a form body, not JSON.
+		err = c.doForm(ctx, "/api/v1/login", url.Values{"username": {username}, "password": {password}}, &resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := resp.AccessToken
+	if token == "" {
+		token = resp.Token
+	}
+	if token == "" {
+		return nil, fmt.Errorf("agentd login: response has no access_token")
+	}
+	s := &Session{
+		BaseURL:   c.BaseURL,
+		Username:  username,
+		Token:     token,
+		TokenType: resp.TokenType,
+		ExpiresAt: tokenExpiry(time.Now(), resp.ExpiresIn, resp.ExpiresAt, token).UTC(),
+	}
+	if err := s.Save(); err != nil {
+		return nil, err
+	}
+	c.Session = s
+	return s, nil
+}
+
+// Logout forgets the stored token. The server is told when it has a
+// logout route; a missing route is not an error.
+func (c *Client) Logout(ctx context.Context) error {
+	if c.Session != nil {
+		err := c.do(ctx, http.MethodPost, "/api/v1/logout", nil, nil, true)
+		var he *HTTPError
+		if err != nil && !(errors.As(err, &he) && (he.Status == http.StatusNotFound || he.Status == http.StatusMethodNotAllowed)) && !errors.Is(err, ErrUnauthorized) {
+			return fmt.Errorf("server logout failed (local token kept): %w", err)
+		}
+	}
+	return ClearSession()
+}
+
+// Get fetches path (with query) into out using the stored token.
+func (c *Client) Get(ctx context.Context, path string, query url.Values, out any) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, out, true)
+}
+
+// Post sends body as JSON to path using the stored token.
+func (c *Client) Post(ctx context.Context, path string, body, out any) error {
+	return c.do(ctx, http.MethodPost, path, body, out, true)
+}
+
+// HTTPError is a non-2xx agentd response.
+type HTTPError struct {
+	Status int
+	Detail string
+}
+
+func (e *HTTPError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("agentd: HTTP %d: %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("agentd: HTTP %d", e.Status)
+}
+
+func credentials(username, password string) map[string]string {
+	return map[string]string{"username": username, "password": password}
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any, auth bool) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, rd)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth {
+		if c.Session == nil {
+			return ErrNotLoggedIn
+		}
+		typ := c.Session.TokenType
+		if typ == "" || strings.EqualFold(typ, "bearer") {
+			typ = "Bearer"
+		}
+		req.Header.Set("Authorization", typ+" "+c.Session.Token)
+	}
+	return c.send(req, out, auth)
+}
+
+func (c *Client) doForm(ctx context.Context, path string, form url.Values, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.send(req, out, false)
+}
+
+func (c *Client) send(req *http.Request, out any, auth bool) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return err
+	}
+	if auth && resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode >= 400 {
+		return &HTTPError{Status: resp.StatusCode, Detail: errorDetail(raw)}
+	}
+	if out == nil || len(bytes.TrimSpace(raw)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("agentd: bad response from %s: %w", req.URL.Path, err)
+	}
+	return nil
+}
+
+// errorDetail pulls the message out of FastAPI ({"detail": ...}) and
+// OpenAI-style ({"error": {"message": ...}}) error bodies.
+func errorDetail(raw []byte) string {
+	var v struct {
+		Detail json.RawMessage `json:"detail"`
+		Error  struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(raw, &v) == nil {
+		var s string
+		if json.Unmarshal(v.Detail, &s) == nil && s != "" {
+			return s
+		}
+		if len(v.Detail) > 0 {
+			return string(v.Detail)
+		}
+		if v.Error.Message != "" {
+			return v.Error.Message
+		}
+	}
+	s := strings.TrimSpace(string(raw))
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
@@ -0,0 +1,51 @@
+package agentd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"kiki-ai-shell/internal/history"
+)
+
+const historyPath = "/api/v1/history"
+
+// PushHistory uploads records to agentd. Each carries its id (content
+// hash), so a batch sent twice after a lost response is harmless to a
+// server that dedups on it.
+func (c *Client) PushHistory(ctx context.Context, recs []history.Record) error {
+	return c.Post(ctx, historyPath, map[string]any{"records": recs}, nil)
+}
+
+// PullHistory fetches up to limit of the newest records (all users'
+// machines, as the server returns them). Both a bare list and a
+// {"records"|"items"|"history": [...]} wrapper are accepted.
+func (c *Client) PullHistory(ctx context.Context, limit int) ([]history.Record, error) {
+	q := url.Values{}
+	if limit > 0 {
+		q.Set("limit", strconv.Itoa(limit))
+	}
+	var raw json.RawMessage
+	if err := c.Get(ctx, historyPath, q, &raw); err != nil {
+		return nil, err
+	}
+	var recs []history.Record
+	if json.Unmarshal(raw, &recs) == nil {
+		return recs, nil
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &wrapped); err != nil {
+		return nil, fmt.Errorf("agentd: unexpected %s response: %w", historyPath, err)
+	}
+	for _, k := range []string{"records", "items", "history"} {
+		if v, ok := wrapped[k]; ok {
+			if err := json.Unmarshal(v, &recs); err != nil {
+				return nil, fmt.Errorf("agentd: %s.%s: %w", historyPath, k, err)
+			}
+			return recs, nil
+		}
+	}
+	return nil, fmt.Errorf("agentd: %s response has no records list", historyPath)
+}
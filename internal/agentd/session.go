@@ -0,0 +1,185 @@
+package agentd
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+// Session is the login state kept in ~/.kiki/config.json (the file the
+// Python kiki CLI uses too; keys this package does not know are preserved).
+type Session struct {
+	BaseURL   string    `json:"base_url"`
+	Username  string    `json:"username,omitempty"`
+	Token     string    `json:"token"`
+	TokenType string    `json:"token_type,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"` // zero (dropped from the file): the server did not say
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+var (
+	// ErrNotLoggedIn means there is no stored token.
+	ErrNotLoggedIn = errors.New("not logged in to agentd (kiki-ai-shell login --base-url http://127.0.0.1:8082)")
+	// ErrExpired means the stored token is past its expiry.
+	ErrExpired = errors.New("agentd token expired (log in again: kiki-ai-shell login)")
+)
+
+// expirySkew treats tokens about to expire as expired, so a request does
+// not start with a token that dies in flight.
+const expirySkew = 30 * time.Second
+
+// Expired reports whether the token is past its (known) expiry.
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && now.Add(expirySkew).After(s.ExpiresAt)
+}
+
+// SessionPath returns ~/.kiki/config.json.
+func SessionPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kiki", "config.json")
+}
+
+// LoadSession reads the stored session. It refuses a file other users can
+// read or that belongs to someone else, and reports ErrNotLoggedIn or
+// ErrExpired (with the session) when there is no usable token.
+func LoadSession() (*Session, error) {
+	path := SessionPath()
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.CheckPrivate(path); err != nil {
+		return nil, err
+	}
+	var s Session
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Token == "" {
+		return nil, ErrNotLoggedIn
+	}
+	if s.Expired(time.Now()) {
+		return &s, ErrExpired
+	}
+	return &s, nil
+}
+
+// Save writes the session into ~/.kiki/config.json (mode 0600), keeping
+// unrelated keys already in the file.
+func (s *Session) Save() error {
+	s.SavedAt = time.Now().UTC().Truncate(time.Second)
+	return editSessionFile(func(m map[string]json.RawMessage) error {
+		b, err := json.Marshal(s)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return err
+		}
+		for k, v := range fields {
+			m[k] = v
+		}
+		if s.ExpiresAt.IsZero() {
+			delete(m, "expires_at")
+		}
+		return nil
+	})
+}
+
+// ClearSession removes the token (and its metadata) from config.json.
+func ClearSession() error {
+	return editSessionFile(func(m map[string]json.RawMessage) error {
+		for _, k := range []string{"token", "token_type", "expires_at", "saved_at", "username"} {
+			delete(m, k)
+		}
+		return nil
+	})
+}
+
+func editSessionFile(edit func(map[string]json.RawMessage) error) error {
+	path := SessionPath()
+	m := map[string]json.RawMessage{}
+	if b, err := os.ReadFile(path); err == nil && len(strings.TrimSpace(string(b))) > 0 {
+		if err := json.Unmarshal(b, &m); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := edit(m); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return fsutil.WritePrivate(path, append(b, '\n'))
+}
+
+// tokenExpiry works out when a login response's token expires: expires_in
+// (seconds), expires_at (RFC 3339 or unix seconds), or the exp claim of a
+// JWT. The zero time means unknown.
+func tokenExpiry(now time.Time, expiresIn, expiresAt json.RawMessage, token string) time.Time {
+	if n, ok := jsonNumber(expiresIn); ok && n > 0 {
+		return now.Add(time.Duration(n) * time.Second)
+	}
+	if n, ok := jsonNumber(expiresAt); ok && n > 0 {
+		return time.Unix(int64(n), 0)
+	}
+	var s string
+	if json.Unmarshal(expiresAt, &s) == nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t
+		}
+	}
+	return jwtExpiry(token)
+}
+
+func jsonNumber(raw json.RawMessage) (float64, bool) {
+	if len(raw) == 0 {
+		return 0, false
+	}
+	var f float64
+	if json.Unmarshal(raw, &f) == nil {
+		return f, true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// jwtExpiry reads the exp claim without verifying the signature (the
+// server does that); it is only used to stop sending a dead token.
+func jwtExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+	var claims struct {
+		Exp float64 `json:"exp"`
+	}
+	if json.Unmarshal(payload, &claims) != nil || claims.Exp <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(int64(claims.Exp), 0)
+}
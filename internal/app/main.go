@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"kiki-ai-shell/internal/config"
 	"kiki-ai-shell/internal/shell"
@@ -23,12 +24,12 @@ func (m *multiFlag) Set(v string) error {
 }
 
 func Main() {
-	cfg := config.Default()
+	cfg, cerrs := config.Load()
 	uicfg := ui.DefaultConfig()
 
 	var files multiFlag
 	fHelp := flag.Bool("help", false, "show help")
-	fProfile := flag.String("profile", "", "profile none|fast|deep")
+	fProfile := flag.String("profile", "", "profile name (kiki-ai-shell profile list)")
 	fStream := flag.Bool("stream", false, "stream output")
 	fUIHeader := flag.Bool("ui-header", uicfg.ShowHeader, "show header in interactive shell")
 	fUIClear := flag.Bool("ui-clear", uicfg.ClearOnDraw, "clear screen before drawing header")
@@ -42,20 +43,33 @@ func Main() {
 	}
 	if strings.TrimSpace(*fProfile) != "" {
 		cfg.Profile = *fProfile
+		cfg.SetOrigin("profile", "flag -profile")
 	}
 	if *fStream {
 		cfg.Stream = true
+		cfg.SetOrigin("stream", "flag -stream")
+	}
+	// `config check` reports these itself.
+	if flag.Arg(0) != "config" {
+		for _, err := range cerrs {
+			fmt.Fprintln(os.Stderr, "config:", err)
+		}
 	}
 	uicfg.ShowHeader = *fUIHeader
 	uicfg.ClearOnDraw = *fUIClear
 	uicfg.MaxFilesLine = *fUIMaxFiles
 
-	config.ApplyProfile(cfg)
+	if err := config.ApplyProfile(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, "profile error:", err, "(using none)")
+		cfg.Profile = "none"
+	}
 
 	args := flag.Args()
 	if len(args) > 0 {
 		st := shell.NewState(cfg, uicfg)
-		st.Files = append(st.Files, []string(files)...)
+		for _, f := range files {
+			st.Files = append(st.Files, shell.Attachment{Path: f})
+		}
 		if args[0] == "gen" {
 			if len(args) < 3 {
 				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell gen <path> <prompt...>")
@@ -73,6 +87,147 @@ func Main() {
 			}
 			return
 		}
+		if args[0] == "ansible-ai" {
+			out, p, opts, err := shell.ParseAnsibleAIArgs(cfg, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell ansible-ai \"<prompt>\" [--target ansible] [--inventory hosts] [--verify syntax|none] [--repair N] [--out path] [--confirm] [--base-url URL]")
+				os.Exit(1)
+			}
+			err = shell.GenTarget(cfg, st, out, p, opts)
+			shell.WaitHistoryPush(5 * time.Second)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ansible-ai error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "ansible-k8s" {
+			out, p, opts, err := shell.ParseK8sAIArgs(cfg, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell ansible-k8s \"<prompt>\" [--namespace ns] [--repair N] [--out path] [--confirm] [--base-url URL]")
+				os.Exit(1)
+			}
+			err = shell.GenTarget(cfg, st, out, p, opts)
+			shell.WaitHistoryPush(5 * time.Second)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "ansible-k8s error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "scaffold" {
+			dir, p, opts, err := shell.ParseScaffoldArgs(cfg, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell scaffold \"<prompt>\" --kind role|k8s|files --out <dir> [--namespace ns] [--inventory hosts] [--verify syntax|none] [--repair N] [--confirm] [--base-url URL]")
+				os.Exit(1)
+			}
+			if err := shell.RunScaffold(cfg, st, dir, p, opts); err != nil {
+				fmt.Fprintln(os.Stderr, "scaffold error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "config" {
+			if err := shell.RunConfig(cfg, args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "config:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "profile" {
+			if err := shell.RunProfile(cfg, st, args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "profile error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "llm" {
+			if err := shell.RunLLM(cfg, st, args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "llm error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "login" || args[0] == "register" || args[0] == "logout" || args[0] == "whoami" {
+			if err := shell.RunAgentd(cfg, args[0], args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "%s error: %v\n", args[0], err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "history" {
+			if len(args) < 2 || args[1] != "sync" {
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell history sync [status]")
+				os.Exit(1)
+			}
+			if err := shell.RunHistorySync(cfg, args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "history sync error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "secret" {
+			if err := shell.RunSecret(args[1:]); err != nil {
+				fmt.Fprintln(os.Stderr, "secret error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "tpl" {
+			err := shell.RunTpl(cfg, st, args[1:])
+			shell.WaitHistoryPush(5 * time.Second)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "tpl error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "health-collect" {
+			opts, err := shell.ParseHealthCollectArgs(args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell health-collect [--inventory hosts|file] [--out /tmp/health.tgz] [--ssh-user U] [--ssh-opts \"...\"] [--timeout 30s] [--parallel 8] [--journal-lines 500] [--confirm]")
+				os.Exit(1)
+			}
+			if err := shell.RunHealthCollect(opts); err != nil {
+				fmt.Fprintln(os.Stderr, "health-collect error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "health-ai" {
+			opts, err := shell.ParseHealthAIArgs(cfg, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell health-ai --file /tmp/health.tgz [--format both|md|json] [--out report.md] [--domains disk,memory,services,kernel] [--base-url URL]")
+				os.Exit(1)
+			}
+			err = shell.RunHealthAI(cfg, st, opts)
+			shell.WaitHistoryPush(5 * time.Second)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "health-ai error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if args[0] == "log-ai" {
+			opts, err := shell.ParseLogAIArgs(cfg, args[1:])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, "usage: kiki-ai-shell log-ai --file <path> [--format auto|syslog|journal|klog] [--level warning] [--since T] [--until T] [--grep RE] [--unit U] [--base-url URL] [question...]")
+				os.Exit(1)
+			}
+			err = shell.RunLogAI(cfg, st, opts)
+			shell.WaitHistoryPush(5 * time.Second)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "log-ai error:", err)
+				os.Exit(1)
+			}
+			return
+		}
 		if args[0] == "ask" {
 			p := strings.TrimSpace(strings.Join(args[1:], " "))
 			if p == "" {
@@ -80,6 +235,7 @@ func Main() {
 				os.Exit(1)
 			}
 			shell.Ask(cfg, st, p, "")
+			shell.WaitHistoryPush(5 * time.Second)
 			return
 		}
 		p := strings.TrimSpace(strings.Join(args, " "))
@@ -88,6 +244,7 @@ func Main() {
 			os.Exit(1)
 		}
 		shell.Ask(cfg, st, p, "")
+		shell.WaitHistoryPush(5 * time.Second)
 		return
 	}
 
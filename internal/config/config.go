@@ -1,89 +1,77 @@
 package config
 
 import (
+	"maps"
 	"os"
 	"path/filepath"
-	"strconv"
-	"strings"
 )
 
+// Config is the runtime configuration. Each field is tagged with its key in
+// the config files (yaml) and, when it can be set from the environment, its
+// variable (env); see Load for the layering and fields.go for the schema.
 type Config struct {
-	Host       string
-	Port       int
-	BaseURL    string
-	Model      string
-	Temp       float64
-	MaxTokens  int
-	TimeoutSec int
-
-	SystemPrompt    string
-	GenSystemPrompt string
-	Profile         string
-	Stream          bool
-
-	AuthPAM       bool
-	UsageEnabled  bool
-	UsageBaseDir  string
-	UsageLoadDays int
-	UsageLoadMax  int
-
-	CtxSizeTarget   int
-	CtxSizeObserved int
-
-	HistoryEnabled bool
-	HistoryPath    string
-	HistoryPreview int
-
-	FileMaxBytes int
-	FileMaxChars int
-
-	CaptureFull bool
-	CaptureMax  int
-
-	RAGEnabled  bool
-	RAGTopK     int
-	RAGMaxChars int
+	Host       string  `yaml:"host"`
+	Port       int     `yaml:"port"`
+	BaseURL    string  `yaml:"base_url" env:"LLM_BASE_URL"`
+	Model      string  `yaml:"model" env:"LLM_MODEL"`
+	Temp       float64 `yaml:"temperature" env:"LLM_TEMP"`
+	MaxTokens  int     `yaml:"max_tokens" env:"LLM_MAX_TOKENS"`
+	TimeoutSec int     `yaml:"timeout" env:"LLM_TIMEOUT"`
+
+	// Named server from the endpoints: registry (:llm use); see endpoint.go
+	LLMEndpoint string `yaml:"llm_endpoint" env:"KIKI_LLM_ENDPOINT"`
+
+	// KIKI agentd API (login/history); empty = the server of the last login
+	AgentdURL string `yaml:"agentd_url" env:"KIKI_AGENTD_URL"`
+
+	SystemPrompt    string `yaml:"system_prompt" env:"LLM_SYSTEM_PROMPT"`
+	GenSystemPrompt string `yaml:"gen_system_prompt" env:"LLM_GEN_SYSTEM_PROMPT"`
+	Profile         string `yaml:"profile" env:"LLM_PROFILE"`
+	Stream          bool   `yaml:"stream" env:"LLM_STREAM"`
+
+	AuthPAM       bool   `yaml:"auth_pam" env:"KIKI_AUTH_PAM"`
+	UsageEnabled  bool   `yaml:"usage" env:"KIKI_USAGE"`
+	UsageBaseDir  string `yaml:"usage_base" env:"KIKI_USAGE_BASE" kind:"path"`
+	UsageLoadDays int    `yaml:"usage_load_days" env:"KIKI_USAGE_LOAD_DAYS"`
+	UsageLoadMax  int    `yaml:"usage_load_max" env:"KIKI_USAGE_LOAD_MAX"`
+
+	CtxSizeTarget   int `yaml:"ctx_target" env:"LLM_CTX_TARGET"`
+	CtxSizeObserved int `yaml:"ctx_observed" env:"LLM_CTX_OBSERVED"`
+
+	HistoryEnabled bool   `yaml:"history" env:"LLM_HISTORY"`
+	HistoryPath    string `yaml:"history_path" env:"LLM_HISTORY_PATH" kind:"path"`
+	HistoryPreview int    `yaml:"history_preview" env:"LLM_HISTORY_PREVIEW"`
+	HistorySync    bool   `yaml:"history_sync" env:"KIKI_HISTORY_SYNC"` // share history via agentd /api/v1/history (needs login)
+
+	FileMaxBytes int `yaml:"file_max_bytes" env:"LLM_FILE_MAX_BYTES"`
+	FileMaxChars int `yaml:"file_max_chars" env:"LLM_FILE_MAX_CHARS"`
+
+	CaptureFull bool `yaml:"capture_full" env:"LLM_CAPTURE_FULL"`
+	CaptureMax  int  `yaml:"capture_max" env:"LLM_CAPTURE_MAX"`
+
+	RAGEnabled  bool `yaml:"rag" env:"LLM_RAG"`
+	RAGTopK     int  `yaml:"rag_topk" env:"LLM_RAG_TOPK"`
+	RAGMaxChars int  `yaml:"rag_max_chars" env:"LLM_RAG_MAX_CHARS"`
 
 	// PCP (Performance Co-Pilot)
-	PCPHost string // "local" or remote host (requires pmcd on target)
+	PCPHost       string `yaml:"pcp_host" env:"KIKI_PCP_HOST"`       // "local" or remote host (requires pmcd on target)
+	PCPGroups     string `yaml:"pcp_groups" env:"KIKI_PCP_GROUPS"`   // host groups: "name=spec;name2=spec2" (spec: "node1,node2" or "kube-worker[1:5]")
+	PCPTimeoutSec int    `yaml:"pcp_timeout" env:"KIKI_PCP_TIMEOUT"` // per-host timeout for multi-host queries
+
+	// Background watchers (:watch)
+	WatchIntervalSec int  `yaml:"watch_interval" env:"KIKI_WATCH_INTERVAL"` // rule evaluation interval
+	WatchExplain     bool `yaml:"watch_explain" env:"KIKI_WATCH_EXPLAIN"`   // ask the LLM to explain alerts when they fire
 
 	// Output formatting
-	NoFence bool // strip markdown code fences like ```yaml ... ```
-}
+	NoFence bool `yaml:"nofence" env:"KIKI_NOFENCE"` // strip markdown code fences like ```yaml ... ```
 
-func envString(k, d string) string {
-	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
-		return v
-	}
-	return d
-}
-func envInt(k string, d int) int {
-	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
-		if n, err := strconv.Atoi(v); err == nil {
-			return n
-		}
-	}
-	return d
-}
-func envFloat(k string, d float64) float64 {
-	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil {
-			return f
-		}
-	}
-	return d
-}
-func envBool(k string, d bool) bool {
-	if v := strings.TrimSpace(os.Getenv(k)); v != "" {
-		v = strings.ToLower(v)
-		if v == "1" || v == "true" || v == "yes" || v == "on" {
-			return true
-		}
-		if v == "0" || v == "false" || v == "no" || v == "off" {
-			return false
-		}
-	}
-	return d
+	// Code generation
+	GenRepairMax int `yaml:"gen_repair" env:"KIKI_GEN_REPAIR"` // validation-failure repair attempts for :gen targets (0 = off)
+
+	// profile is what the last ApplyProfile changed (see profile.go).
+	profile *appliedProfile
+	// origin records where each field's value came from, by yaml key.
+	origin map[string]string
 }
 
 func defaultHistoryPath() string {
@@ -100,51 +88,64 @@ func defaultUsageBaseDir() string {
 	return dir
 }
 
-func Default() *Config {
+// builtin returns the compiled-in defaults (no env, no files).
+func builtin() *Config {
 	return &Config{
-		// NOTE: 주소 설정은 env에서 LLM_BASE_URL만 사용합니다.
-		// LLM_HOST/LLM_PORT는 더 이상 env로 읽지 않습니다. (쉘 내 :llm 로 변경)
+		// NOTE: Host/Port는 env로 읽지 않습니다. 주소는 LLM_BASE_URL,
+		// 설정 파일의 base_url(또는 host/port), 쉘의 :llm 으로 바꿉니다.
 		Host:       "10.0.2.253",
 		Port:       8080,
-		BaseURL:    envString("LLM_BASE_URL", ""),
-		Model:      envString("LLM_MODEL", "llama"),
-		Temp:       envFloat("LLM_TEMP", 0.2),
-		MaxTokens:  envInt("LLM_MAX_TOKENS", 512),
-		TimeoutSec: envInt("LLM_TIMEOUT", 60),
-
-		SystemPrompt:    envString("LLM_SYSTEM_PROMPT", "당신은 간결하고 정확하게 답변하는 도우미입니다."),
-		GenSystemPrompt: envString("LLM_GEN_SYSTEM_PROMPT", "\ub2f9\uc2e0\uc740 \ucf54\ub4dc \uc0dd\uc131\uae30\uc785\ub2c8\ub2e4. \uc124\uba85\uc740 \uc4f0\uc9c0 \ub9d0\uace0, \uc694\uccad\ud55c \uacb0\uacfc\ub97c \uadf8\ub300\ub85c \uc6d0\ubcf8 \ucf54\ub4dc\ub9cc \ucd9c\ub825\ud558\uc138\uc694. \ub9c8\ud06c\ub2e4\uc6b4 \ucf54\ub4dc\ud39c\uc2a4(``` ... ```)\ub098 \ubc31\ud2f1(`)\uc744 \uc808\ub300 \ud3ec\ud568\ud558\uc9c0 \ub9c8\uc138\uc694. \ud14d\uc2a4\ud2b8 \uc124\uba85, \uc8fc\uc11d, \ucd94\uac00 \ubb38\uc7a5\ub3c4 \uc808\ub300 \ud3ec\ud568\ud558\uc9c0 \ub9c8\uc138\uc694."),
-		Profile:         envString("LLM_PROFILE", "fast"),
-		Stream:          envBool("LLM_STREAM", false),
+		Model:      "llama",
+		Temp:       0.2,
+		MaxTokens:  512,
+		TimeoutSec: 60,
 
-		AuthPAM:       envBool("KIKI_AUTH_PAM", true),
-		UsageEnabled:  envBool("KIKI_USAGE", true),
-		UsageBaseDir:  envString("KIKI_USAGE_BASE", defaultUsageBaseDir()),
-		UsageLoadDays: envInt("KIKI_USAGE_LOAD_DAYS", 30),
-		UsageLoadMax:  envInt("KIKI_USAGE_LOAD_MAX", 5000),
+		SystemPrompt:    "당신은 간결하고 정확하게 답변하는 도우미입니다.",
+		GenSystemPrompt: "\ub2f9\uc2e0\uc740 \ucf54\ub4dc \uc0dd\uc131\uae30\uc785\ub2c8\ub2e4. \uc124\uba85\uc740 \uc4f0\uc9c0 \ub9d0\uace0, \uc694\uccad\ud55c \uacb0\uacfc\ub97c \uadf8\ub300\ub85c \uc6d0\ubcf8 \ucf54\ub4dc\ub9cc \ucd9c\ub825\ud558\uc138\uc694. \ub9c8\ud06c\ub2e4\uc6b4 \ucf54\ub4dc\ud39c\uc2a4(``` ... ```)\ub098 \ubc31\ud2f1(`)\uc744 \uc808\ub300 \ud3ec\ud568\ud558\uc9c0 \ub9c8\uc138\uc694. \ud14d\uc2a4\ud2b8 \uc124\uba85, \uc8fc\uc11d, \ucd94\uac00 \ubb38\uc7a5\ub3c4 \uc808\ub300 \ud3ec\ud568\ud558\uc9c0 \ub9c8\uc138\uc694.",
+		Profile:         "fast",
 
-		CtxSizeTarget:   envInt("LLM_CTX_TARGET", 0),
-		CtxSizeObserved: envInt("LLM_CTX_OBSERVED", 0),
+		AuthPAM:       true,
+		UsageEnabled:  true,
+		UsageBaseDir:  defaultUsageBaseDir(),
+		UsageLoadDays: 30,
+		UsageLoadMax:  5000,
 
-		HistoryEnabled: envBool("LLM_HISTORY", true),
-		HistoryPath:    envString("LLM_HISTORY_PATH", defaultHistoryPath()),
-		HistoryPreview: envInt("LLM_HISTORY_PREVIEW", 800),
+		HistoryEnabled: true,
+		HistoryPath:    defaultHistoryPath(),
+		HistoryPreview: 800,
 
-		FileMaxBytes: envInt("LLM_FILE_MAX_BYTES", 256*1024),
-		FileMaxChars: envInt("LLM_FILE_MAX_CHARS", 20000),
+		FileMaxBytes: 256 * 1024,
+		FileMaxChars: 20000,
 
-		CaptureFull: envBool("LLM_CAPTURE_FULL", false),
-		CaptureMax:  envInt("LLM_CAPTURE_MAX", 2_000_000),
+		CaptureMax: 2_000_000,
 
-		RAGEnabled:  envBool("LLM_RAG", false),
-		RAGTopK:     envInt("LLM_RAG_TOPK", 3),
-		RAGMaxChars: envInt("LLM_RAG_MAX_CHARS", 2500),
+		RAGTopK:     3,
+		RAGMaxChars: 2500,
 
-		PCPHost: envString("KIKI_PCP_HOST", "local"),
+		PCPHost:       "local",
+		PCPTimeoutSec: 10,
 
-		PCPHost: envString("KIKI_PCP_HOST", "local"),
+		WatchIntervalSec: 15,
 
 		// If true, the shell will remove markdown fences like ```yaml / ``` from model outputs.
-		NoFence: envBool("KIKI_NOFENCE", true),
+		NoFence: true,
+
+		GenRepairMax: 3,
 	}
 }
+
+// Default returns the built-in defaults overlaid with the environment. It
+// does not read config files; see Load.
+func Default() *Config {
+	cfg := builtin()
+	_ = cfg.applyEnv()
+	return cfg
+}
+
+// Clone returns a copy of cfg, origins included, for settings that apply to
+// one command only (e.g. --base-url); changing it leaves cfg as it was.
+func (cfg *Config) Clone() *Config {
+	c := *cfg
+	c.origin = maps.Clone(cfg.origin)
+	return &c
+}
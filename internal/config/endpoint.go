@@ -0,0 +1,368 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kiki-ai-shell/internal/fsutil"
+	"kiki-ai-shell/internal/secret"
+)
+
+// Endpoint is a named LLM server from the "endpoints:" section of a config
+// file:
+//
+//	endpoints:
+//	  gpu1:
+//	    url: http://10.0.2.253:8080
+//	    provider: llamacpp
+//	    model: qwen2.5-32b
+//	    ctx_size: 32768
+//	    tags: [gpu, a100]
+//	  openai:
+//	    url: https://api.openai.com/v1
+//	    model: gpt-4o-mini
+//	    api_key: env:OPENAI_API_KEY
+//	  gateway:
+//	    url: https://llm-gw.internal
+//	    basic_user: svc-kiki
+//	    basic_password: keyring:gw-pass
+//	    headers:
+//	      X-Tenant: sre
+//	      X-Gateway-Token: file:~/.config/gw.token
+//	  ingress:
+//	    url: https://infer.k8s.internal
+//	    tls:
+//	      ca_file: ~/.kiki/certs/ca.pem
+//	      cert_file: ~/.kiki/certs/client.pem
+//	      key_file: ~/.kiki/certs/client-key.pem
+//	      min_version: "1.3"
+//
+// Credentials are only ever references (see package secret); they are
+// resolved when a request is made.
+type Endpoint struct {
+	Name          string            `yaml:"-"`
+	Source        string            `yaml:"-"` // defining file
+	URL           string            `yaml:"url"`
+	Provider      string            `yaml:"provider,omitempty"` // openai (default) | llamacpp | vllm | ollama
+	Model         string            `yaml:"model,omitempty"`
+	APIKey        string            `yaml:"api_key,omitempty"` // Authorization: Bearer; env:VAR | file:PATH | keyring:NAME
+	BasicUser     string            `yaml:"basic_user,omitempty"`
+	BasicPassword string            `yaml:"basic_password,omitempty"` // reference, like api_key
+	Headers       map[string]string `yaml:"headers,omitempty"`        // literal values or references
+	TLS           *EndpointTLS      `yaml:"tls,omitempty"`
+	CtxSize       int               `yaml:"ctx_size,omitempty"`
+	Tags          []string          `yaml:"tags,omitempty,flow"`
+	Description   string            `yaml:"description,omitempty"`
+}
+
+// EndpointTLS configures the TLS client of an https:// endpoint.
+type EndpointTLS struct {
+	CAFile             string `yaml:"ca_file,omitempty"`     // PEM bundle trusted in addition to the system roots
+	CertFile           string `yaml:"cert_file,omitempty"`   // client certificate (mTLS)
+	KeyFile            string `yaml:"key_file,omitempty"`    // its private key
+	ServerName         string `yaml:"server_name,omitempty"` // SNI and name to verify, when it differs from the URL host
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+	MinVersion         string `yaml:"min_version,omitempty"` // 1.0 | 1.1 | 1.2 | 1.3
+}
+
+// TLSVersions are the accepted tls.min_version values.
+var TLSVersions = []string{"1.0", "1.1", "1.2", "1.3"}
+
+// Insecure reports whether certificate verification is turned off.
+func (ep *Endpoint) Insecure() bool {
+	return ep.TLS != nil && ep.TLS.InsecureSkipVerify
+}
+
+// Providers are the accepted endpoint provider types.
+var Providers = []string{"openai", "llamacpp", "vllm", "ollama"}
+
+// HasTag reports whether the endpoint carries tag.
+func (ep *Endpoint) HasTag(tag string) bool {
+	for _, t := range ep.Tags {
+		if t == strings.ToLower(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize fills defaults and canonicalizes the URL, provider and tags.
+func (ep *Endpoint) normalize() {
+	ep.URL = strings.TrimSpace(ep.URL)
+	if ep.URL != "" && !strings.Contains(ep.URL, "://") {
+		ep.URL = "http://" + ep.URL
+	}
+	ep.URL = strings.TrimRight(ep.URL, "/")
+	ep.Provider = strings.ToLower(strings.TrimSpace(ep.Provider))
+	if ep.Provider == "" {
+		ep.Provider = "openai"
+	}
+	var tags []string
+	for _, t := range ep.Tags {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	ep.Tags = tags
+	if t := ep.TLS; t != nil {
+		t.CAFile, t.CertFile, t.KeyFile = fsutil.ExpandHome(t.CAFile), fsutil.ExpandHome(t.CertFile), fsutil.ExpandHome(t.KeyFile)
+		t.MinVersion = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(t.MinVersion)), "tls")
+		if *t == (EndpointTLS{}) {
+			ep.TLS = nil
+		}
+	}
+}
+
+func (ep *Endpoint) validate() error {
+	if ep.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	if err := validateField("base_url", ep.URL); err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	if u, err := url.Parse(ep.URL); err == nil && u.User != nil {
+		return fmt.Errorf("url: no credentials in the URL; use basic_user/basic_password")
+	}
+	if !contains(Providers, ep.Provider) {
+		return fmt.Errorf("provider %q: must be one of %s", ep.Provider, strings.Join(Providers, ", "))
+	}
+	refs := strings.Join(secret.Schemes, "..., ") + "..."
+	if ep.APIKey != "" && !secret.IsRef(ep.APIKey) {
+		return fmt.Errorf("api_key must be a reference (%s), not the key itself", refs)
+	}
+	if ep.BasicPassword != "" && !secret.IsRef(ep.BasicPassword) {
+		return fmt.Errorf("basic_password must be a reference (%s), not the password itself", refs)
+	}
+	if (ep.BasicUser == "") != (ep.BasicPassword == "") {
+		return fmt.Errorf("basic_user and basic_password go together")
+	}
+	if ep.APIKey != "" && ep.BasicUser != "" {
+		return fmt.Errorf("use either api_key (bearer) or basic_user/basic_password, not both")
+	}
+	for k := range ep.Headers {
+		if strings.TrimSpace(k) == "" || strings.ContainsAny(k, " :\r\n") {
+			return fmt.Errorf("headers: %q is not a valid header name", k)
+		}
+		if strings.EqualFold(k, "Authorization") && (ep.APIKey != "" || ep.BasicUser != "") {
+			return fmt.Errorf("headers: Authorization conflicts with api_key/basic_user")
+		}
+	}
+	if ep.CtxSize < 0 {
+		return fmt.Errorf("ctx_size must be >= 0")
+	}
+	if t := ep.TLS; t != nil {
+		if !strings.HasPrefix(ep.URL, "https://") {
+			return fmt.Errorf("tls: settings need an https:// url")
+		}
+		if (t.CertFile == "") != (t.KeyFile == "") {
+			return fmt.Errorf("tls: cert_file and key_file go together")
+		}
+		if t.MinVersion != "" && !contains(TLSVersions, t.MinVersion) {
+			return fmt.Errorf("tls: min_version %q: must be one of %s", t.MinVersion, strings.Join(TLSVersions, ", "))
+		}
+	}
+	return nil
+}
+
+// ParseEndpoint checks a name and an endpoint built outside a config file
+// (:llm add) the same way the loader would.
+func ParseEndpoint(name string, ep Endpoint) (*Endpoint, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !validEndpointName(name) {
+		return nil, fmt.Errorf("endpoint name %q: use letters, digits, - and _", name)
+	}
+	ep.Name = name
+	ep.normalize()
+	if err := ep.validate(); err != nil {
+		return nil, fmt.Errorf("endpoint %s: %w", name, err)
+	}
+	return &ep, nil
+}
+
+func validEndpointName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseEndpoints reads the "endpoints:" section of a config file. Like
+// profiles, a broken entry is reported and skipped.
+func parseEndpoints(data []byte, source string) (map[string]*Endpoint, []error) {
+	var doc struct {
+		Endpoints map[string]yaml.Node `yaml:"endpoints"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, []error{err}
+	}
+	out := map[string]*Endpoint{}
+	var errs []error
+	for name, node := range doc.Endpoints {
+		ep, err := decodeEndpoint(name, &node)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		ep.Source = source
+		out[ep.Name] = ep
+	}
+	return out, errs
+}
+
+func decodeEndpoint(name string, node *yaml.Node) (*Endpoint, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("endpoint %s: line %d: expected a mapping", name, node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		if !endpointKeySet[k.Value] {
+			return nil, fmt.Errorf("endpoint %s: line %d: unknown key %q", name, k.Line, k.Value)
+		}
+		if k.Value != "tls" || v.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(v.Content); j += 2 {
+			if tk := v.Content[j]; !tlsKeySet[tk.Value] {
+				return nil, fmt.Errorf("endpoint %s: line %d: unknown tls key %q", name, tk.Line, tk.Value)
+			}
+		}
+	}
+	var ep Endpoint
+	if err := node.Decode(&ep); err != nil {
+		return nil, fmt.Errorf("endpoint %s: %w", name, err)
+	}
+	p, err := ParseEndpoint(name, ep)
+	if err != nil {
+		return nil, fmt.Errorf("%w (line %d)", err, node.Line)
+	}
+	return p, nil
+}
+
+// endpointKeySet and tlsKeySet hold the yaml keys of Endpoint and
+// EndpointTLS.
+var (
+	endpointKeySet = yamlKeys(reflect.TypeOf(Endpoint{}))
+	tlsKeySet      = yamlKeys(reflect.TypeOf(EndpointTLS{}))
+)
+
+func yamlKeys(t reflect.Type) map[string]bool {
+	m := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		k, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if k != "" && k != "-" {
+			m[k] = true
+		}
+	}
+	return m
+}
+
+// Endpoints returns the endpoint registry merged from the config files
+// (ConfigPaths order, later wins by name).
+func Endpoints() (map[string]*Endpoint, []error) {
+	m := map[string]*Endpoint{}
+	var errs []error
+	for _, path := range ConfigPaths() {
+		b, err := readConfigFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if b == nil {
+			continue
+		}
+		file, ferrs := parseEndpoints(b, path)
+		for _, err := range ferrs {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+		for name, ep := range file {
+			m[name] = ep
+		}
+	}
+	return m, errs
+}
+
+// EndpointNames returns the sorted registry names.
+func EndpointNames() []string {
+	m, _ := Endpoints()
+	out := make([]string, 0, len(m))
+	for name := range m {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LookupEndpoint finds a registry entry by name.
+func LookupEndpoint(name string) (*Endpoint, error) {
+	m, _ := Endpoints()
+	name = strings.ToLower(strings.TrimSpace(name))
+	if ep := m[name]; ep != nil {
+		return ep, nil
+	}
+	if len(m) == 0 {
+		return nil, fmt.Errorf("unknown endpoint %q (registry is empty; add one with :llm add)", name)
+	}
+	return nil, fmt.Errorf("unknown endpoint %q (have: %s)", name, strings.Join(EndpointNames(), ", "))
+}
+
+// UseEndpoint points the LLM settings at ep: base_url, model (when the entry
+// names one) and the known server ctx size, which replaces whatever was
+// observed on the previous server. Keys for which keep returns true (e.g.
+// values set from the environment) are left alone.
+func (cfg *Config) UseEndpoint(ep *Endpoint, origin string, keep func(key string) bool) {
+	if keep == nil {
+		keep = func(string) bool { return false }
+	}
+	set := func(key string, apply func()) {
+		if !keep(key) {
+			apply()
+			cfg.SetOrigin(key, origin)
+		}
+	}
+	set("base_url", func() { cfg.BaseURL = ep.URL })
+	if ep.Model != "" {
+		set("model", func() { cfg.Model = ep.Model })
+	}
+	set("ctx_observed", func() { cfg.CtxSizeObserved = ep.CtxSize })
+	cfg.LLMEndpoint = ep.Name
+	cfg.SetOrigin("llm_endpoint", origin)
+}
+
+// applyEndpoint resolves llm_endpoint after the files and env are loaded.
+// Values given explicitly through the environment win over the entry.
+func (cfg *Config) applyEndpoint() error {
+	name := strings.TrimSpace(cfg.LLMEndpoint)
+	if name == "" {
+		return nil
+	}
+	ep, err := LookupEndpoint(name)
+	if err != nil {
+		cfg.LLMEndpoint = ""
+		return fmt.Errorf("llm_endpoint (from %s): %w", cfg.Origin("llm_endpoint"), err)
+	}
+	origin := cfg.Origin("llm_endpoint")
+	cfg.UseEndpoint(ep, "endpoint "+ep.Name, func(key string) bool {
+		return strings.HasPrefix(cfg.Origin(key), "env ")
+	})
+	cfg.SetOrigin("llm_endpoint", origin)
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
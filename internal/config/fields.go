@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+// Field describes one Config field that can be set from files, env or the
+// shell. It is derived from the struct tags on Config.
+type Field struct {
+	Key  string // config-file key, e.g. "max_tokens"
+	Env  string // environment variable, "" if none
+	Type string // "string" | "int" | "float" | "bool"
+	Path bool   // "~/" is expanded
+
+	index int
+}
+
+var fields, fieldByKey = buildFields()
+
+func buildFields() ([]Field, map[string]Field) {
+	var list []Field
+	byKey := map[string]Field{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("yaml")
+		if key == "" || !sf.IsExported() {
+			continue
+		}
+		f := Field{Key: key, Env: sf.Tag.Get("env"), Path: sf.Tag.Get("kind") == "path", index: i}
+		switch sf.Type.Kind() {
+		case reflect.String:
+			f.Type = "string"
+		case reflect.Int:
+			f.Type = "int"
+		case reflect.Float64:
+			f.Type = "float"
+		case reflect.Bool:
+			f.Type = "bool"
+		default:
+			panic("config: unsupported field type for " + sf.Name)
+		}
+		list = append(list, f)
+		byKey[key] = f
+	}
+	return list, byKey
+}
+
+// Fields returns the settable fields in declaration order.
+func Fields() []Field {
+	return append([]Field(nil), fields...)
+}
+
+// FieldKeys returns the sorted field keys.
+func FieldKeys() []string {
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, f.Key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LookupField finds a field by key; env names (LLM_MODEL) are accepted too.
+func LookupField(key string) (Field, bool) {
+	key = strings.TrimSpace(key)
+	if f, ok := fieldByKey[strings.ToLower(key)]; ok {
+		return f, true
+	}
+	for _, f := range fields {
+		if f.Env != "" && strings.EqualFold(f.Env, key) {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func (cfg *Config) value(f Field) reflect.Value {
+	return reflect.ValueOf(cfg).Elem().Field(f.index)
+}
+
+// Get returns the current value of key formatted as text.
+func (cfg *Config) Get(key string) (string, error) {
+	f, ok := LookupField(key)
+	if !ok {
+		return "", unknownKey(key)
+	}
+	return formatValue(cfg.value(f)), nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// parseValue converts text to the field type.
+func parseValue(f Field, raw string) (any, error) {
+	raw = strings.TrimSpace(raw)
+	switch f.Type {
+	case "int":
+		n, err := strconv.Atoi(strings.ReplaceAll(raw, "_", ""))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", raw)
+		}
+		return n, nil
+	case "float":
+		x, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return x, nil
+	case "bool":
+		switch strings.ToLower(raw) {
+		case "1", "true", "yes", "on":
+			return true, nil
+		case "0", "false", "no", "off":
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a boolean (true|false|on|off|1|0)", raw)
+	}
+	if f.Path {
+		raw = fsutil.ExpandHome(raw)
+	}
+	return raw, nil
+}
+
+// Set parses raw for key, validates it and records origin. The old value is
+// kept when parsing or validation fails.
+func (cfg *Config) Set(key, raw, origin string) error {
+	f, ok := LookupField(key)
+	if !ok {
+		return unknownKey(key)
+	}
+	return cfg.setField(f, raw, origin)
+}
+
+func (cfg *Config) setField(f Field, raw, origin string) error {
+	v, err := parseValue(f, raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Key, err)
+	}
+	v = normalizeField(f.Key, v)
+	if err := validateField(f.Key, v); err != nil {
+		return fmt.Errorf("%s: %w", f.Key, err)
+	}
+	cfg.value(f).Set(reflect.ValueOf(v))
+	cfg.SetOrigin(f.Key, origin)
+	return nil
+}
+
+// Origin returns where the value of key came from ("default", a file path,
+// "env LLM_MODEL", "flag -profile", ...).
+func (cfg *Config) Origin(key string) string {
+	if o := cfg.origin[key]; o != "" {
+		return o
+	}
+	return "default"
+}
+
+// SetOrigin records where the value of key came from.
+func (cfg *Config) SetOrigin(key, origin string) {
+	if cfg.origin == nil {
+		cfg.origin = map[string]string{}
+	}
+	cfg.origin[key] = origin
+}
+
+// applyEnv overlays the environment. Empty variables are ignored, as before;
+// values that do not parse are reported and skipped.
+func (cfg *Config) applyEnv() []error {
+	var errs []error
+	for _, f := range fields {
+		if f.Env == "" {
+			continue
+		}
+		raw := strings.TrimSpace(os.Getenv(f.Env))
+		if raw == "" {
+			continue
+		}
+		if err := cfg.setField(f, raw, "env "+f.Env); err != nil {
+			errs = append(errs, fmt.Errorf("env %s: %w", f.Env, err))
+		}
+	}
+	return errs
+}
+
+func unknownKey(key string) error {
+	if s := suggestKey(key); s != "" {
+		return fmt.Errorf("unknown key %q (did you mean %s?)", key, s)
+	}
+	return fmt.Errorf("unknown key %q", key)
+}
+
+// suggestKey returns the closest field key within a small edit distance.
+func suggestKey(key string) string {
+	key = strings.ToLower(key)
+	best, bestD := "", 3
+	for _, f := range fields {
+		if d := editDistance(key, f.Key); d < bestD {
+			best, bestD = f.Key, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
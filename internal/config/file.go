@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SystemConfigPath is the fleet-wide config file.
+const SystemConfigPath = "/etc/kiki/config.yaml"
+
+// ProjectConfigName is the per-directory config file.
+const ProjectConfigName = ".kiki.yaml"
+
+// UserConfigPath returns ~/.kiki/config.yaml.
+func UserConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kiki", "config.yaml")
+}
+
+// ConfigPaths lists the config files in merge order (later wins): system,
+// user, then project (./.kiki.yaml).
+func ConfigPaths() []string {
+	return []string{SystemConfigPath, UserConfigPath(), "./" + ProjectConfigName}
+}
+
+// readConfigFile returns the file content, or nil when it does not exist.
+func readConfigFile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sections are top-level config-file keys that are not Config fields; they
+// are read by their own loaders (profile.go, endpoint.go).
+var sections = map[string]bool{"profiles": true, "endpoints": true}
+
+// Load builds the configuration in layers, later wins:
+//
+//	built-in defaults
+//	/etc/kiki/config.yaml   (fleet)
+//	~/.kiki/config.yaml     (user)
+//	./.kiki.yaml            (project)
+//	environment (LLM_*, KIKI_*)
+//	llm_endpoint            (registry entry; env values still win)
+//
+// Command-line flags are applied by the caller on top. Bad values are
+// reported in errs and the previous layer's value is kept, so a typo in a
+// shared file never stops the shell from starting.
+func Load() (*Config, []error) {
+	cfg := builtin()
+	var errs []error
+	for _, path := range ConfigPaths() {
+		errs = append(errs, cfg.applyFile(path)...)
+	}
+	errs = append(errs, cfg.applyEnv()...)
+	if err := cfg.applyEndpoint(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errs
+}
+
+// applyFile overlays one YAML config file (a flat mapping of field keys).
+func (cfg *Config) applyFile(path string) []error {
+	b, err := readConfigFile(path)
+	if err != nil {
+		return []error{err}
+	}
+	if b == nil {
+		return nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return []error{fmt.Errorf("%s: %w", path, err)}
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return []error{fmt.Errorf("%s:%d: top level must be a mapping of key: value", path, root.Line)}
+	}
+	var errs []error
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		k, v := root.Content[i], root.Content[i+1]
+		if sections[k.Value] {
+			continue
+		}
+		f, ok := fieldByKey[k.Value]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", path, k.Line, unknownKey(k.Value)))
+			continue
+		}
+		if v.Kind != yaml.ScalarNode {
+			errs = append(errs, fmt.Errorf("%s:%d: %s: expected a %s value", path, v.Line, k.Value, f.Type))
+			continue
+		}
+		if v.Tag == "!!null" {
+			continue
+		}
+		if err := cfg.setField(f, v.Value, path); err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", path, v.Line, err))
+		}
+	}
+	return errs
+}
+
+// ConfigFile is one layer as reported by `:config paths`.
+type ConfigFile struct {
+	Path   string
+	Exists bool
+}
+
+// ConfigFiles reports which layers exist.
+func ConfigFiles() []ConfigFile {
+	var out []ConfigFile
+	for _, p := range ConfigPaths() {
+		_, err := os.Stat(p)
+		out = append(out, ConfigFile{Path: p, Exists: err == nil})
+	}
+	return out
+}
+
+// Entry is one row of `:config show`.
+type Entry struct {
+	Key    string
+	Value  string
+	Origin string
+	Env    string
+}
+
+// Entries lists every field with its value and origin, sorted by key.
+func (cfg *Config) Entries() []Entry {
+	out := make([]Entry, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, Entry{Key: f.Key, Value: formatValue(cfg.value(f)), Origin: cfg.Origin(f.Key), Env: f.Env})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Check re-reads all layers and returns every problem found, including
+// broken profiles and endpoints; it is what `kiki-ai-shell config check`
+// reports.
+func Check() []error {
+	cfg, errs := Load()
+	errs = append(errs, cfg.Validate()...)
+	_, perrs := Profiles()
+	errs = append(errs, perrs...)
+	_, eerrs := Endpoints()
+	errs = append(errs, eerrs...)
+	if _, err := ProfileChain(cfg.Profile); err != nil {
+		errs = append(errs, fmt.Errorf("profile (from %s): %w", cfg.Origin("profile"), err))
+	}
+	return dedupeErrors(errs)
+}
+
+func dedupeErrors(errs []error) []error {
+	seen := map[string]bool{}
+	var out []error
+	for _, err := range errs {
+		s := strings.TrimSpace(err.Error())
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, err)
+		}
+	}
+	return out
+}
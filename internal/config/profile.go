@@ -1,30 +1,367 @@
 package config
 
-import "strings"
+import (
+	_ "embed"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
-func ApplyProfile(cfg *Config) {
-	switch strings.ToLower(strings.TrimSpace(cfg.Profile)) {
-	case "", "none":
-		return
-	case "fast":
-		if cfg.Temp > 0.2 {
-			cfg.Temp = 0.2
+	"gopkg.in/yaml.v3"
+)
+
+// Profile is a named set of overrides applied on top of the env/file config.
+// Nil fields leave the current value alone. Extends names a parent profile
+// that is applied first.
+type Profile struct {
+	Name        string   `yaml:"-"`
+	Source      string   `yaml:"-"` // "builtin" or the defining file
+	Description string   `yaml:"description"`
+	Extends     string   `yaml:"extends"`
+	Model       *string  `yaml:"model"`
+	Temp        *float64 `yaml:"temperature"`
+	MaxTokens   *int     `yaml:"max_tokens"`
+	System      *string  `yaml:"system_prompt"`
+	RAG         *bool    `yaml:"rag"`
+	RAGTopK     *int     `yaml:"rag_topk"`
+	RAGMaxChars *int     `yaml:"rag_max_chars"`
+	Endpoint    *string  `yaml:"endpoint"` // registry name, or base URL (host:port or http://...)
+	NoFence     *bool    `yaml:"nofence"`
+
+	// tune is the legacy relative tuning of the fast/deep built-ins.
+	tune func(*Config)
+}
+
+//go:embed profiles.yaml
+var builtinProfilesYAML []byte
+
+func builtinProfiles() map[string]*Profile {
+	m := map[string]*Profile{
+		"none": {Description: "env/설정 파일 값 그대로"},
+		"fast": {Description: "짧고 빠른 답변(temp<=0.2, max_tokens<=384)", tune: tuneFast},
+		"deep": {Description: "구조화된 긴 답변(temp>=0.3, max_tokens>=768)", tune: tuneDeep},
+	}
+	file, errs := parseProfiles(builtinProfilesYAML, "builtin")
+	if len(errs) > 0 {
+		panic(fmt.Sprintf("config: builtin profiles: %v", errs))
+	}
+	for name, p := range file {
+		m[name] = p
+	}
+	for name, p := range m {
+		p.Name, p.Source = name, "builtin"
+	}
+	return m
+}
+
+func tuneFast(cfg *Config) {
+	if cfg.Temp > 0.2 {
+		cfg.Temp = 0.2
+	}
+	if cfg.MaxTokens <= 0 || cfg.MaxTokens > 384 {
+		cfg.MaxTokens = 256
+	}
+	if strings.TrimSpace(cfg.SystemPrompt) == "" {
+		cfg.SystemPrompt = "당신은 간결하고 정확하게 답변하는 도우미입니다. 결론을 먼저 말하고, 필요하면 3개 이하 bullet로만 보충하세요."
+	}
+}
+
+func tuneDeep(cfg *Config) {
+	if cfg.Temp < 0.3 {
+		cfg.Temp = 0.3
+	}
+	if cfg.MaxTokens < 768 {
+		cfg.MaxTokens = 1024
+	}
+	if strings.TrimSpace(cfg.SystemPrompt) == "" {
+		cfg.SystemPrompt = "당신은 시니어 SRE/플랫폼 엔지니어입니다. 가정/원인/검증/조치 순으로 구조화해 답변하세요. 불확실한 부분은 불확실하다고 표시하세요."
+	}
+}
+
+// parseProfiles reads the "profiles:" section of a config file. Unknown keys
+// inside a profile are errors so typos do not silently do nothing; a broken
+// profile is skipped and reported, the others are still returned.
+func parseProfiles(data []byte, source string) (map[string]*Profile, []error) {
+	var doc struct {
+		Profiles map[string]yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, []error{err}
+	}
+	out := map[string]*Profile{}
+	var errs []error
+	for name, node := range doc.Profiles {
+		p, err := decodeProfile(&node)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", name, err))
+			continue
+		}
+		name = strings.ToLower(name)
+		p.Name, p.Source = name, source
+		out[name] = p
+	}
+	return out, errs
+}
+
+func decodeProfile(node *yaml.Node) (*Profile, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if k := node.Content[i]; !profileKeySet[k.Value] {
+			return nil, fmt.Errorf("line %d: unknown key %q", k.Line, k.Value)
+		}
+	}
+	p := &Profile{}
+	if err := node.Decode(p); err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	return p, nil
+}
+
+// profileKeySet holds the yaml keys of Profile.
+var profileKeySet = func() map[string]bool {
+	m := map[string]bool{}
+	t := reflect.TypeOf(Profile{})
+	for i := 0; i < t.NumField(); i++ {
+		if k := t.Field(i).Tag.Get("yaml"); k != "" && k != "-" {
+			m[k] = true
+		}
+	}
+	return m
+}()
+
+func (p *Profile) validate() error {
+	if p.Temp != nil && (*p.Temp < 0 || *p.Temp > 2) {
+		return fmt.Errorf("temperature %v out of range 0..2", *p.Temp)
+	}
+	if p.MaxTokens != nil && *p.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be > 0")
+	}
+	if p.RAGTopK != nil && *p.RAGTopK < 0 {
+		return fmt.Errorf("rag_topk must be >= 0")
+	}
+	if p.RAGMaxChars != nil && *p.RAGMaxChars < 0 {
+		return fmt.Errorf("rag_max_chars must be >= 0")
+	}
+	return nil
+}
+
+// Profiles returns the built-in profiles overlaid with those from the config
+// files (ConfigPaths order, later wins). Unreadable files and broken
+// profiles are reported in errs and skipped.
+func Profiles() (map[string]*Profile, []error) {
+	m := builtinProfiles()
+	var errs []error
+	for _, path := range ConfigPaths() {
+		b, err := readConfigFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if b == nil {
+			continue
+		}
+		file, ferrs := parseProfiles(b, path)
+		for _, err := range ferrs {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+		for name, p := range file {
+			m[name] = p
+		}
+	}
+	return m, errs
+}
+
+// ProfileNames returns the sorted profile names.
+func ProfileNames() []string {
+	m, _ := Profiles()
+	out := make([]string, 0, len(m))
+	for name := range m {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ProfileChain resolves name and its extends parents, root first.
+func ProfileChain(name string) ([]*Profile, error) {
+	m, _ := Profiles()
+	return profileChain(m, name)
+}
+
+func profileChain(m map[string]*Profile, name string) ([]*Profile, error) {
+	var chain []*Profile
+	seen := map[string]bool{}
+	for n := strings.ToLower(strings.TrimSpace(name)); n != ""; {
+		if seen[n] {
+			return nil, fmt.Errorf("profile %s: extends cycle", name)
 		}
-		if cfg.MaxTokens <= 0 || cfg.MaxTokens > 384 {
-			cfg.MaxTokens = 256
+		seen[n] = true
+		p := m[n]
+		if p == nil {
+			if len(chain) == 0 {
+				return nil, fmt.Errorf("unknown profile %q", n)
+			}
+			return nil, fmt.Errorf("profile %s: extends unknown profile %q", chain[0].Name, n)
 		}
-		if strings.TrimSpace(cfg.SystemPrompt) == "" {
-			cfg.SystemPrompt = "당신은 간결하고 정확하게 답변하는 도우미입니다. 결론을 먼저 말하고, 필요하면 3개 이하 bullet로만 보충하세요."
+		chain = append([]*Profile{p}, chain...)
+		n = strings.ToLower(strings.TrimSpace(p.Extends))
+	}
+	return chain, nil
+}
+
+// profileFields are the Config fields a profile may change.
+type profileFields struct {
+	Model        string
+	Temp         float64
+	MaxTokens    int
+	SystemPrompt string
+	RAGEnabled   bool
+	RAGTopK      int
+	RAGMaxChars  int
+	BaseURL      string
+	NoFence      bool
+}
+
+func (cfg *Config) profileFields() profileFields {
+	return profileFields{cfg.Model, cfg.Temp, cfg.MaxTokens, cfg.SystemPrompt, cfg.RAGEnabled, cfg.RAGTopK, cfg.RAGMaxChars, cfg.BaseURL, cfg.NoFence}
+}
+
+func (cfg *Config) setProfileFields(f profileFields) {
+	cfg.Model, cfg.Temp, cfg.MaxTokens, cfg.SystemPrompt = f.Model, f.Temp, f.MaxTokens, f.SystemPrompt
+	cfg.RAGEnabled, cfg.RAGTopK, cfg.RAGMaxChars = f.RAGEnabled, f.RAGTopK, f.RAGMaxChars
+	cfg.BaseURL, cfg.NoFence = f.BaseURL, f.NoFence
+}
+
+// undo restores base where cur still holds what the profile set, so values
+// changed by hand since then (:llm set, :nofence ...) survive a switch.
+func (cur profileFields) undo(base, set profileFields) profileFields {
+	if cur.Model == set.Model {
+		cur.Model = base.Model
+	}
+	if cur.Temp == set.Temp {
+		cur.Temp = base.Temp
+	}
+	if cur.MaxTokens == set.MaxTokens {
+		cur.MaxTokens = base.MaxTokens
+	}
+	if cur.SystemPrompt == set.SystemPrompt {
+		cur.SystemPrompt = base.SystemPrompt
+	}
+	if cur.RAGEnabled == set.RAGEnabled {
+		cur.RAGEnabled = base.RAGEnabled
+	}
+	if cur.RAGTopK == set.RAGTopK {
+		cur.RAGTopK = base.RAGTopK
+	}
+	if cur.RAGMaxChars == set.RAGMaxChars {
+		cur.RAGMaxChars = base.RAGMaxChars
+	}
+	if cur.BaseURL == set.BaseURL {
+		cur.BaseURL = base.BaseURL
+	}
+	if cur.NoFence == set.NoFence {
+		cur.NoFence = base.NoFence
+	}
+	return cur
+}
+
+func (p *Profile) apply(cfg *Config) {
+	if p.tune != nil {
+		p.tune(cfg)
+	}
+	if p.Model != nil {
+		cfg.Model = *p.Model
+	}
+	if p.Temp != nil {
+		cfg.Temp = *p.Temp
+	}
+	if p.MaxTokens != nil {
+		cfg.MaxTokens = *p.MaxTokens
+	}
+	if p.System != nil {
+		cfg.SystemPrompt = strings.TrimSpace(*p.System)
+	}
+	if p.RAG != nil {
+		cfg.RAGEnabled = *p.RAG
+	}
+	if p.RAGTopK != nil {
+		cfg.RAGTopK = *p.RAGTopK
+	}
+	if p.RAGMaxChars != nil {
+		cfg.RAGMaxChars = *p.RAGMaxChars
+	}
+	if p.Endpoint != nil {
+		url := strings.TrimSpace(*p.Endpoint)
+		if ep, err := LookupEndpoint(url); err == nil && url != "" {
+			url = ep.URL
 		}
-	case "deep":
-		if cfg.Temp < 0.3 {
-			cfg.Temp = 0.3
+		if url != "" && !strings.Contains(url, "://") {
+			url = "http://" + url
 		}
-		if cfg.MaxTokens < 768 {
-			cfg.MaxTokens = 1024
+		cfg.BaseURL = strings.TrimRight(url, "/")
+	}
+	if p.NoFence != nil {
+		cfg.NoFence = *p.NoFence
+	}
+}
+
+// ApplyProfile applies cfg.Profile (with its extends chain). Switching
+// profiles first undoes what the previous one set. An unknown profile leaves
+// cfg unchanged and returns an error.
+func ApplyProfile(cfg *Config) error {
+	name := strings.ToLower(strings.TrimSpace(cfg.Profile))
+	if name == "" {
+		name = "none"
+	}
+	chain, err := ProfileChain(name)
+	if err != nil {
+		return err
+	}
+	before := cfg.profileValues()
+	cur := cfg.profileFields()
+	if cfg.profile != nil {
+		cur = cur.undo(cfg.profile.base, cfg.profile.set)
+	}
+	cfg.setProfileFields(cur)
+	undone := cfg.profileValues()
+	origins := map[string]string{}
+	for _, k := range profileKeys {
+		if cfg.profile != nil && undone[k] != before[k] {
+			cfg.SetOrigin(k, cfg.profile.origins[k])
 		}
-		if strings.TrimSpace(cfg.SystemPrompt) == "" {
-			cfg.SystemPrompt = "당신은 시니어 SRE/플랫폼 엔지니어입니다. 가정/원인/검증/조치 순으로 구조화해 답변하세요. 불확실한 부분은 불확실하다고 표시하세요."
+		origins[k] = cfg.Origin(k)
+	}
+	for _, p := range chain {
+		p.apply(cfg)
+	}
+	for k, v := range cfg.profileValues() {
+		if v != undone[k] {
+			cfg.SetOrigin(k, "profile "+name)
 		}
 	}
+	cfg.profile = &appliedProfile{base: cur, set: cfg.profileFields(), origins: origins}
+	return nil
+}
+
+// appliedProfile remembers the values before and after the last ApplyProfile
+// and the origins it replaced.
+type appliedProfile struct {
+	base, set profileFields
+	origins   map[string]string
+}
+
+// profileKeys are the field keys of profileFields.
+var profileKeys = []string{"model", "temperature", "max_tokens", "system_prompt", "rag", "rag_topk", "rag_max_chars", "base_url", "nofence"}
+
+func (cfg *Config) profileValues() map[string]any {
+	m := make(map[string]any, len(profileKeys))
+	for _, k := range profileKeys {
+		m[k] = cfg.value(fieldByKey[k]).Interface()
+	}
+	return m
 }
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+// RuntimeKeys returns the keys changed from inside the shell (origin starts
+// with ":", e.g. ":set" or ":llm set"); these are what `:config save` writes
+// by default.
+func (cfg *Config) RuntimeKeys() []string {
+	var out []string
+	for _, f := range fields {
+		if strings.HasPrefix(cfg.Origin(f.Key), ":") {
+			out = append(out, f.Key)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Save writes the current values of keys into the YAML config file at path,
+// keeping everything else in it (other keys, profiles:, comments). The file
+// is replaced atomically and created with mode 0600.
+func (cfg *Config) Save(path string, keys []string) error {
+	err := editConfigFile(path, func(root *yaml.Node) error {
+		for _, key := range keys {
+			f, ok := LookupField(key)
+			if !ok {
+				return unknownKey(key)
+			}
+			setMappingValue(root, f.Key, cfg.scalarNode(f))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if f, ok := LookupField(key); ok {
+			cfg.SetOrigin(f.Key, path)
+		}
+	}
+	return nil
+}
+
+// SaveEndpoint adds or replaces ep under "endpoints:" in the config file at
+// path, leaving the rest of the file alone.
+func SaveEndpoint(path string, ep *Endpoint) error {
+	var v yaml.Node
+	if err := v.Encode(ep); err != nil {
+		return err
+	}
+	return editConfigFile(path, func(root *yaml.Node) error {
+		section, err := sectionNode(root, "endpoints", true)
+		if err != nil {
+			return err
+		}
+		setMappingValue(section, ep.Name, &v)
+		return nil
+	})
+}
+
+// RemoveEndpoint deletes name from "endpoints:" in the config file at path.
+func RemoveEndpoint(path, name string) error {
+	return editConfigFile(path, func(root *yaml.Node) error {
+		section, err := sectionNode(root, "endpoints", false)
+		if err != nil {
+			return err
+		}
+		for i := 0; section != nil && i+1 < len(section.Content); i += 2 {
+			if section.Content[i].Value == name {
+				section.Content = append(section.Content[:i], section.Content[i+2:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("endpoint %q is not defined in %s", name, path)
+	})
+}
+
+// editConfigFile loads the YAML document at path (an empty mapping when the
+// file does not exist), lets edit change the top-level mapping and writes
+// it back atomically.
+func editConfigFile(path string, edit func(root *yaml.Node) error) error {
+	b, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if len(bytes.TrimSpace(b)) > 0 {
+		if err := yaml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: top level is not a mapping; not touching it", path)
+	}
+	if err := edit(root); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out.Bytes())
+}
+
+// sectionNode returns the mapping under the top-level key name, creating it
+// when create is set (nil otherwise).
+func sectionNode(root *yaml.Node, name string, create bool) (*yaml.Node, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != name {
+			continue
+		}
+		v := root.Content[i+1]
+		if v.Kind == yaml.ScalarNode && v.Tag == "!!null" {
+			*v = yaml.Node{Kind: yaml.MappingNode}
+		}
+		if v.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: %s: is not a mapping", v.Line, name)
+		}
+		return v, nil
+	}
+	if !create {
+		return nil, nil
+	}
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, m)
+	return m, nil
+}
+
+func (cfg *Config) scalarNode(f Field) *yaml.Node {
+	v := formatValue(cfg.value(f))
+	n := &yaml.Node{Kind: yaml.ScalarNode, Value: v}
+	switch f.Type {
+	case "int":
+		n.Tag = "!!int"
+	case "float":
+		n.Tag = "!!float"
+	case "bool":
+		n.Tag = "!!bool"
+	default:
+		n.Tag = "!!str"
+		if strings.Contains(v, "\n") {
+			n.Style = yaml.LiteralStyle
+		}
+	}
+	return n
+}
+
+// setMappingValue replaces the value of key in m, or appends key: value.
+func setMappingValue(m *yaml.Node, key string, v *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			// Keep comments attached to the old value.
+			v.LineComment, v.HeadComment, v.FootComment = m.Content[i+1].LineComment, m.Content[i+1].HeadComment, m.Content[i+1].FootComment
+			m.Content[i+1] = v
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, v)
+}
+
+// writeFileAtomic replaces path atomically, keeping the mode of an
+// existing file (0600 for a new one).
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if fi, err := os.Stat(path); err == nil {
+		mode = fi.Mode().Perm()
+	}
+	return fsutil.WriteFileAtomic(path, data, mode)
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// normalizeField tidies values that have a canonical form.
+func normalizeField(key string, v any) any {
+	if key == "base_url" || key == "agentd_url" {
+		u := strings.TrimSpace(v.(string))
+		if u != "" && !strings.Contains(u, "://") {
+			u = "http://" + u
+		}
+		return strings.TrimRight(u, "/")
+	}
+	return v
+}
+
+// validateField checks one (normalized) value against the field's range.
+func validateField(key string, v any) error {
+	switch x := v.(type) {
+	case int:
+		lo, hi, ok := intRange(key)
+		if ok && (x < lo || (hi > 0 && x > hi)) {
+			if hi > 0 {
+				return fmt.Errorf("%d out of range %d..%d", x, lo, hi)
+			}
+			return fmt.Errorf("must be >= %d (got %d)", lo, x)
+		}
+		if key == "ctx_target" && x != 0 && x < 256 {
+			return fmt.Errorf("must be 0 (unset) or >= 256 (got %d)", x)
+		}
+	case float64:
+		if key == "temperature" && (x < 0 || x > 2) {
+			return fmt.Errorf("%v out of range 0..2", x)
+		}
+	case string:
+		switch key {
+		case "host":
+			if strings.TrimSpace(x) == "" {
+				return fmt.Errorf("must not be empty")
+			}
+		case "base_url", "agentd_url":
+			if x == "" {
+				return nil
+			}
+			u, err := url.Parse(x)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("%q is not an http(s)://host[:port] URL", x)
+			}
+		}
+	}
+	return nil
+}
+
+// intRange returns the allowed range for int keys (hi 0 = unbounded).
+func intRange(key string) (lo, hi int, ok bool) {
+	switch key {
+	case "port":
+		return 1, 65535, true
+	case "timeout", "file_max_bytes", "capture_max", "pcp_timeout", "watch_interval":
+		return 1, 0, true
+	case "max_tokens", "file_max_chars", "usage_load_days", "usage_load_max", "ctx_target", "ctx_observed", "history_preview", "rag_topk", "rag_max_chars", "gen_repair":
+		// max_tokens 0 = server default, file_max_chars 0 = no limit
+		return 0, 0, true
+	}
+	return 0, 0, false
+}
+
+// Validate checks every field and returns one error per bad value, naming
+// where the value came from.
+func (cfg *Config) Validate() []error {
+	var errs []error
+	for _, f := range fields {
+		if err := validateField(f.Key, cfg.value(f).Interface()); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", f.Key, cfg.Origin(f.Key), err))
+		}
+	}
+	return errs
+}
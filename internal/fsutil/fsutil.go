@@ -0,0 +1,70 @@
+// Package fsutil holds the small file helpers shared by config, secrets,
+// the agentd session and history: "~" expansion and atomic writes of
+// private files.
+package fsutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// ExpandHome resolves a leading "~" or "~/" to the home directory. Other
+// paths (including "~user") are returned unchanged.
+func ExpandHome(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, strings.TrimPrefix(p, "~"))
+	}
+	return p
+}
+
+// WriteFileAtomic writes data to a temp file next to path with mode perm
+// and renames it over path, so readers never see a partial file. Missing
+// parent directories are created 0700.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// WritePrivate is WriteFileAtomic with mode 0600.
+func WritePrivate(path string, data []byte) error {
+	return WriteFileAtomic(path, data, 0o600)
+}
+
+// CheckPrivate refuses files other users can read or write, or that belong
+// to someone else.
+func CheckPrivate(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if perm := fi.Mode().Perm(); perm&0o077 != 0 {
+		return fmt.Errorf("%s: permissions %04o are too open (chmod 600 %s)", path, perm, path)
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s: owned by uid %d, not you (uid %d); refusing to use it", path, st.Uid, os.Getuid())
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package gen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register(&Target{
+		Name:        "ansible",
+		Description: "Ansible playbook (YAML parse, play/task structure, module catalog, ansible-playbook --syntax-check)",
+		System:      ansibleSystem,
+		Validate:    ValidateAnsible,
+	})
+}
+
+func ansibleSystem(o Options) string {
+	var b strings.Builder
+	b.WriteString("당신은 Ansible 플레이북 생성기입니다. 유효한 YAML 플레이북 하나만 출력하세요.\n")
+	b.WriteString("규칙:\n")
+	b.WriteString("- 문서는 '---' 로 시작하고, 최상위는 플레이(play)의 리스트입니다.\n")
+	b.WriteString("- 각 플레이에는 name, hosts 가 있어야 하며 작업은 tasks(필요 시 handlers) 아래에 둡니다.\n")
+	b.WriteString("- 모든 task 에 name 을 붙이고, task 하나에는 모듈 하나만 사용하세요.\n")
+	b.WriteString("- 모듈은 FQCN(ansible.builtin.dnf, ansible.builtin.service, ansible.posix.firewalld 등)으로 쓰세요.\n")
+	b.WriteString("- shell/command 는 전용 모듈이 없을 때만 사용하고, 가능한 멱등(idempotent)하게 작성하세요.\n")
+	b.WriteString("- 설명/마크다운 코드펜스/주석 외 문장은 출력하지 마세요.\n")
+	if len(o.Inventory) > 0 {
+		b.WriteString("\n[Inventory]\n대상 호스트: ")
+		b.WriteString(strings.Join(o.Inventory, ", "))
+		b.WriteString("\n특별한 요청이 없으면 hosts: all 을 사용하세요.\n")
+	}
+	return b.String()
+}
+
+// playKeywords are keys allowed on a play besides the task lists.
+var playKeywords = setOf(
+	"name", "hosts", "gather_facts", "become", "become_user", "become_method", "become_flags",
+	"vars", "vars_files", "vars_prompt", "roles", "collections", "environment", "serial",
+	"strategy", "any_errors_fatal", "max_fail_percentage", "ignore_errors", "ignore_unreachable",
+	"connection", "port", "remote_user", "tags", "when", "order", "run_once", "check_mode",
+	"diff", "no_log", "module_defaults", "force_handlers", "gather_subset", "gather_timeout",
+	"fact_path", "throttle", "timeout", "debugger", "become_exe",
+)
+
+var playTaskLists = []string{"pre_tasks", "tasks", "post_tasks", "handlers"}
+
+// taskKeywords are task keys that are not modules.
+var taskKeywords = setOf(
+	"name", "when", "loop", "with_items", "with_dict", "with_fileglob", "with_together",
+	"with_subelements", "with_sequence", "with_nested", "with_first_found", "with_list",
+	"with_indexed_items", "with_random_choice", "with_lines", "with_inventory_hostnames",
+	"loop_control", "register", "become", "become_user", "become_method", "become_flags",
+	"notify", "listen", "tags", "vars", "environment", "ignore_errors", "ignore_unreachable",
+	"failed_when", "changed_when", "until", "retries", "delay", "delegate_to", "delegate_facts",
+	"run_once", "no_log", "check_mode", "diff", "async", "poll", "args", "any_errors_fatal",
+	"throttle", "timeout", "connection", "remote_user", "module_defaults", "collections",
+	"local_action", "action", "debugger", "port",
+)
+
+var blockKeys = []string{"block", "rescue", "always"}
+
+// ValidateAnsible parses content as a playbook and checks its structure.
+func ValidateAnsible(content string, o Options) Result {
+	res := Result{Content: content}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		res.Issues = append(res.Issues, yamlIssue(err))
+		return res
+	}
+	if len(doc.Content) == 0 {
+		res.Issues = append(res.Issues, Issue{Severity: "error", Msg: "empty document"})
+		return res
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.SequenceNode {
+		res.Issues = append(res.Issues, Issue{Severity: "error", Line: root.Line, Msg: "playbook must be a list of plays (got " + kindName(root) + ")"})
+		return res
+	}
+	v := &ansibleValidator{}
+	for i, play := range root.Content {
+		v.play(play, fmt.Sprintf("play[%d]", i))
+	}
+	res.Issues = append(res.Issues, v.issues...)
+
+	if o.SyntaxCheck && res.OK() {
+		res.Issues = append(res.Issues, ansibleSyntaxCheck(content, o.Inventory)...)
+	}
+	return res
+}
+
+type ansibleValidator struct {
+	issues []Issue
+}
+
+func (v *ansibleValidator) add(sev string, n *yaml.Node, path, format string, args ...any) {
+	line := 0
+	if n != nil {
+		line = n.Line
+	}
+	v.issues = append(v.issues, Issue{Severity: sev, Line: line, Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *ansibleValidator) play(n *yaml.Node, path string) {
+	if n.Kind != yaml.MappingNode {
+		v.add("error", n, path, "play must be a mapping (got %s)", kindName(n))
+		return
+	}
+	keys := mapKeys(n)
+	if _, ok := keys["import_playbook"]; ok {
+		return
+	}
+	if _, ok := keys["ansible.builtin.import_playbook"]; ok {
+		return
+	}
+	if _, ok := keys["hosts"]; !ok {
+		v.add("error", n, path, "play has no 'hosts'")
+	}
+	if _, ok := keys["name"]; !ok {
+		v.add("warning", n, path, "play has no 'name'")
+	}
+	hasWork := false
+	for _, kv := range pairs(n) {
+		k := kv.key.Value
+		switch {
+		case contains(playTaskLists, k):
+			hasWork = true
+			v.taskList(kv.value, path+"."+k)
+		case k == "roles":
+			hasWork = true
+			if kv.value.Kind != yaml.SequenceNode {
+				v.add("error", kv.value, path+".roles", "roles must be a list")
+			}
+		case playKeywords[k]:
+		default:
+			if isModule(k) {
+				v.add("error", kv.key, path, "module %q used directly on the play; put it under tasks:", k)
+			} else {
+				v.add("error", kv.key, path, "unknown play keyword %q", k)
+			}
+		}
+	}
+	if !hasWork {
+		v.add("warning", n, path, "play has no tasks or roles")
+	}
+}
+
+func (v *ansibleValidator) taskList(n *yaml.Node, path string) {
+	if n.Kind == yaml.ScalarNode && (n.Tag == "!!null" || n.Value == "") {
+		return
+	}
+	if n.Kind != yaml.SequenceNode {
+		v.add("error", n, path, "must be a list of tasks (got %s)", kindName(n))
+		return
+	}
+	for i, t := range n.Content {
+		v.task(t, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *ansibleValidator) task(n *yaml.Node, path string) {
+	if n.Kind != yaml.MappingNode {
+		v.add("error", n, path, "task must be a mapping (got %s)", kindName(n))
+		return
+	}
+	keys := mapKeys(n)
+	if _, ok := keys["name"]; !ok {
+		v.add("warning", n, path, "task has no 'name'")
+	}
+	isBlock := false
+	for _, b := range blockKeys {
+		if kv, ok := keys[b]; ok {
+			isBlock = true
+			v.taskList(kv.value, path+"."+b)
+		}
+	}
+	var modules []string
+	for _, kv := range pairs(n) {
+		k := kv.key.Value
+		if taskKeywords[k] || contains(blockKeys, k) {
+			continue
+		}
+		if strings.HasPrefix(k, "with_") {
+			continue
+		}
+		modules = append(modules, k)
+		if !isModule(k) {
+			if strings.Count(k, ".") >= 2 && !completeCollection(k) {
+				v.add("warning", kv.key, path, "module %q is not in the bundled catalog (collection not fully catalogued)", k)
+			} else {
+				v.add("error", kv.key, path, "unknown module or task keyword %q", k)
+			}
+		}
+	}
+	if _, ok := keys["action"]; ok {
+		modules = append(modules, "action")
+	}
+	if _, ok := keys["local_action"]; ok {
+		modules = append(modules, "local_action")
+	}
+	switch {
+	case isBlock && len(modules) > 0:
+		v.add("error", n, path, "block cannot also use a module (%s)", strings.Join(modules, ", "))
+	case !isBlock && len(modules) == 0:
+		v.add("error", n, path, "task has no module")
+	case len(modules) > 1:
+		v.add("error", n, path, "task uses more than one module: %s", strings.Join(modules, ", "))
+	}
+}
+
+// ansibleSyntaxCheck runs `ansible-playbook --syntax-check` when installed.
+func ansibleSyntaxCheck(content string, inventory []string) []Issue {
+	bin, err := exec.LookPath("ansible-playbook")
+	if err != nil {
+		return []Issue{{Severity: "warning", Msg: "ansible-playbook not found; --syntax-check skipped"}}
+	}
+	f, err := os.CreateTemp("", "kiki-playbook-*.yml")
+	if err != nil {
+		return []Issue{{Severity: "warning", Msg: "syntax-check skipped: " + err.Error()}}
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString(content)
+	f.Close()
+
+	inv := "localhost,"
+	if len(inventory) > 0 {
+		inv = strings.Join(inventory, ",") + ","
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, bin, "--syntax-check", "-i", inv, f.Name()).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	msg := strings.TrimSpace(string(out))
+	msg = strings.ReplaceAll(msg, f.Name(), "<playbook>")
+	if msg == "" {
+		msg = err.Error()
+	}
+	return []Issue{{Severity: "error", Msg: "ansible-playbook --syntax-check: " + msg}}
+}
+
+type mapEntry struct {
+	key, value *yaml.Node
+}
+
+// pairs returns the entries of a mapping node in document order.
+func pairs(n *yaml.Node) []mapEntry {
+	out := make([]mapEntry, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		out = append(out, mapEntry{n.Content[i], n.Content[i+1]})
+	}
+	return out
+}
+
+func mapKeys(n *yaml.Node) map[string]mapEntry {
+	out := map[string]mapEntry{}
+	for _, kv := range pairs(n) {
+		out[kv.key.Value] = kv
+	}
+	return out
+}
+
+func kindName(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	case yaml.ScalarNode:
+		return "scalar"
+	case yaml.AliasNode:
+		return "alias"
+	}
+	return "document"
+}
+
+// yamlIssue converts a yaml.v3 error ("yaml: line 3: ...") into an Issue.
+func yamlIssue(err error) Issue {
+	msg := strings.TrimPrefix(err.Error(), "yaml: ")
+	line := 0
+	if strings.HasPrefix(msg, "line ") {
+		rest := msg[len("line "):]
+		if i := strings.Index(rest, ":"); i > 0 {
+			fmt.Sscanf(rest[:i], "%d", &line)
+			msg = strings.TrimSpace(rest[i+1:])
+		}
+	}
+	return Issue{Severity: "error", Line: line, Msg: "invalid YAML: " + msg}
+}
+
+func setOf(items ...string) map[string]bool {
+	m := make(map[string]bool, len(items))
+	for _, s := range items {
+		m[s] = true
+	}
+	return m
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package gen
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+func init() {
+	register(&Target{
+		Name:        "bash",
+		Description: "Bash script (bash -n syntax check, shellcheck lint when installed)",
+		System:      bashSystem,
+		Validate:    ValidateBash,
+	})
+}
+
+func bashSystem(o Options) string {
+	var b strings.Builder
+	b.WriteString("당신은 Bash 스크립트 생성기입니다. 실행 가능한 스크립트 하나만 출력하세요.\n")
+	b.WriteString("규칙:\n")
+	b.WriteString("- 첫 줄은 #!/usr/bin/env bash 이고, 이어서 set -euo pipefail 을 사용하세요.\n")
+	b.WriteString("- 변수 확장은 항상 따옴표로 감싸고(\"$var\"), 명령 치환은 $(...) 를 쓰세요.\n")
+	b.WriteString("- shellcheck 경고가 없도록 작성하세요.\n")
+	b.WriteString("- 설명/마크다운 코드펜스/주석 외 문장은 출력하지 마세요.\n")
+	if len(o.Inventory) > 0 {
+		b.WriteString("\n[Inventory]\n대상 호스트: ")
+		b.WriteString(strings.Join(o.Inventory, ", "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ValidateBash runs `bash -n` and, with o.SyntaxCheck, shellcheck when installed.
+// Shellcheck errors fail validation; its warnings and notes are reported only.
+func ValidateBash(content string, o Options) Result {
+	res := Result{Content: content}
+	if strings.TrimSpace(content) == "" {
+		res.Issues = append(res.Issues, Issue{Severity: "error", Msg: "empty script"})
+		return res
+	}
+	f, err := os.CreateTemp("", "kiki-script-*.sh")
+	if err != nil {
+		res.Issues = append(res.Issues, Issue{Severity: "warning", Msg: "checks skipped: " + err.Error()})
+		return res
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString(content)
+	f.Close()
+
+	if !strings.HasPrefix(content, "#!") {
+		res.Issues = append(res.Issues, Issue{Severity: "warning", Line: 1, Msg: "missing shebang"})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if bash, err := exec.LookPath("bash"); err == nil {
+		out, err := exec.CommandContext(ctx, bash, "-n", f.Name()).CombinedOutput()
+		if err != nil {
+			res.Issues = append(res.Issues, parseBashN(string(out), f.Name())...)
+			if res.OK() {
+				res.Issues = append(res.Issues, Issue{Severity: "error", Msg: "bash -n: " + err.Error()})
+			}
+			return res
+		}
+	}
+	if !o.SyntaxCheck {
+		return res
+	}
+	sc, err := exec.LookPath("shellcheck")
+	if err != nil {
+		res.Issues = append(res.Issues, Issue{Severity: "warning", Msg: "shellcheck not found; lint skipped"})
+		return res
+	}
+	out, _ := exec.CommandContext(ctx, sc, "-f", "gcc", "-s", "bash", f.Name()).CombinedOutput()
+	res.Issues = append(res.Issues, parseShellcheck(string(out))...)
+	return res
+}
+
+// "<file>: line 3: syntax error near unexpected token `fi'"
+var bashNLine = regexp.MustCompile(`: line (\d+): (.*)$`)
+
+func parseBashN(out, name string) []Issue {
+	var issues []Issue
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" {
+			continue
+		}
+		i := Issue{Severity: "error", Msg: "bash -n: " + strings.ReplaceAll(l, name, "<script>")}
+		if m := bashNLine.FindStringSubmatch(l); m != nil {
+			fmt.Sscanf(m[1], "%d", &i.Line)
+			i.Msg = "bash -n: " + m[2]
+		}
+		issues = append(issues, i)
+	}
+	return issues
+}
+
+// "<file>:3:5: warning: Double quote to prevent globbing and word splitting. [SC2086]"
+var shellcheckLine = regexp.MustCompile(`^.*?:(\d+):(\d+): (error|warning|note|style): (.*)$`)
+
+func parseShellcheck(out string) []Issue {
+	var issues []Issue
+	sc := bufio.NewScanner(strings.NewReader(out))
+	for sc.Scan() {
+		m := shellcheckLine.FindStringSubmatch(sc.Text())
+		if m == nil {
+			continue
+		}
+		i := Issue{Severity: "warning", Msg: "shellcheck: " + m[4]}
+		if m[3] == "error" {
+			i.Severity = "error"
+		}
+		fmt.Sscanf(m[1], "%d", &i.Line)
+		issues = append(issues, i)
+	}
+	return issues
+}
@@ -0,0 +1,66 @@
+package gen
+
+import "strings"
+
+// completeCollections lists collections whose modules are all in the
+// catalog; a FQCN in one of them must match a catalog entry. The other
+// catalogued collections (community.general, kubernetes.core) only carry
+// their common modules, so a miss there is not an error.
+var completeCollections = []string{"ansible.builtin", "ansible.posix"}
+
+// ansibleModules is the bundled module catalog, by collection.
+var ansibleModules = map[string][]string{
+	"ansible.builtin": {
+		"add_host", "apt", "apt_key", "apt_repository", "assemble", "assert", "async_status",
+		"blockinfile", "command", "copy", "cron", "debconf", "debug", "deb822_repository", "dnf",
+		"dnf5", "dpkg_selections", "expect", "fail", "fetch", "file", "find", "gather_facts",
+		"get_url", "getent", "git", "group", "group_by", "hostname", "import_playbook",
+		"import_role", "import_tasks", "include_role", "include_tasks", "include_vars",
+		"iptables", "known_hosts", "lineinfile", "meta", "mount_facts", "package", "package_facts",
+		"pause", "ping", "pip", "raw", "reboot", "replace", "rpm_key", "script", "service",
+		"service_facts", "set_fact", "set_stats", "setup", "shell", "slurp", "stat", "subversion",
+		"systemd", "systemd_service", "sysvinit", "tempfile", "template", "unarchive", "uri",
+		"user", "validate_argument_spec", "wait_for", "wait_for_connection", "yum", "yum_repository",
+	},
+	"ansible.posix": {
+		"acl", "at", "authorized_key", "firewalld", "firewalld_info", "mount", "patch",
+		"rhel_facts", "rhel_rpm_ostree", "rpm_ostree_upgrade", "seboolean", "selinux", "synchronize", "sysctl",
+	},
+	"community.general": {
+		"alternatives", "archive", "filesystem", "ini_file", "lvg", "lvol", "make", "modprobe",
+		"nmcli", "npm", "open_iscsi", "pam_limits", "parted", "pids", "pipx", "redhat_subscription",
+		"rhsm_repository", "sefcontext", "snap", "timezone", "ufw", "xml", "zypper",
+	},
+	"kubernetes.core": {
+		"helm", "helm_info", "helm_repository", "k8s", "k8s_cp", "k8s_drain", "k8s_exec",
+		"k8s_info", "k8s_log", "k8s_rollback", "k8s_scale", "k8s_service",
+	},
+}
+
+var moduleIndex = func() map[string]bool {
+	idx := map[string]bool{}
+	for coll, mods := range ansibleModules {
+		for _, m := range mods {
+			idx[coll+"."+m] = true
+			// Short names are accepted too; older playbooks use them for
+			// modules that moved out of core into these collections.
+			idx[m] = true
+		}
+	}
+	return idx
+}()
+
+// isModule reports whether name is a module in the bundled catalog, by short
+// name or FQCN.
+func isModule(name string) bool { return moduleIndex[name] }
+
+// completeCollection reports whether a FQCN belongs to a collection the
+// catalog covers in full.
+func completeCollection(fqcn string) bool {
+	for _, c := range completeCollections {
+		if strings.HasPrefix(fqcn, c+".") {
+			return true
+		}
+	}
+	return false
+}
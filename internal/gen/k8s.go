@@ -0,0 +1,468 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	register(&Target{
+		Name:        "k8s",
+		Description: "Kubernetes manifests (multi-doc YAML, bundled OpenAPI schemas, namespace from :ctx ns; no cluster needed)",
+		System:      k8sSystem,
+		Validate:    ValidateK8s,
+	})
+}
+
+func k8sSystem(o Options) string {
+	var b strings.Builder
+	b.WriteString("당신은 쿠버네티스 매니페스트 생성기입니다. 유효한 YAML 매니페스트만 출력하세요.\n")
+	b.WriteString("규칙:\n")
+	b.WriteString("- 리소스가 여러 개면 '---' 로 구분된 멀티 문서 YAML 로 출력하세요.\n")
+	b.WriteString("- 모든 문서에 apiVersion, kind, metadata.name 을 넣고, 현재 GA API 버전(apps/v1, batch/v1, networking.k8s.io/v1 등)을 사용하세요.\n")
+	b.WriteString("- Deployment/StatefulSet/DaemonSet 의 spec.selector.matchLabels 는 template.metadata.labels 와 일치해야 합니다.\n")
+	b.WriteString("- 포트 번호/replicas 는 정수, 라벨/어노테이션/ConfigMap data 값은 문자열(필요 시 따옴표)로 쓰세요.\n")
+	b.WriteString("- 설명/마크다운 코드펜스/주석 외 문장은 출력하지 마세요.\n")
+	if ns := strings.TrimSpace(o.Namespace); ns != "" {
+		b.WriteString("\n[Namespace]\n네임스페이스 리소스의 metadata.namespace 는 " + ns + " 를 사용하세요.\n")
+	}
+	return b.String()
+}
+
+// k8sDoc is one document of a multi-doc manifest.
+type k8sDoc struct {
+	root       *yaml.Node // the mapping at the top of the document
+	apiVersion string
+	kind       string
+	name       string
+	label      string // Kind/name, used as the issue path prefix
+}
+
+// ValidateK8s splits content into documents and checks each one against the
+// bundled schemas. When o.Namespace is set, namespaced objects without
+// metadata.namespace get it injected; Result.Content carries the rewrite and
+// issue lines refer to it.
+func ValidateK8s(content string, o Options) Result {
+	res := Result{Content: content}
+	docs, err := splitK8sDocs(content)
+	if err != nil {
+		res.Issues = append(res.Issues, yamlIssue(err))
+		return res
+	}
+	if ns := strings.TrimSpace(o.Namespace); ns != "" {
+		if rewritten, ok := injectNamespace(content, docs, ns); ok {
+			res.Content = rewritten
+			if docs, err = splitK8sDocs(rewritten); err != nil {
+				res.Issues = append(res.Issues, yamlIssue(err))
+				return res
+			}
+		}
+	}
+	if len(docs) == 0 {
+		res.Issues = append(res.Issues, Issue{Severity: "error", Msg: "no Kubernetes objects in output"})
+		return res
+	}
+	v := &k8sValidator{}
+	for _, d := range docs {
+		v.doc(d, o)
+	}
+	res.Issues = append(res.Issues, v.issues...)
+	return res
+}
+
+// splitK8sDocs decodes every document in a multi-doc stream, skipping empty ones.
+func splitK8sDocs(content string) ([]k8sDoc, error) {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	var docs []k8sDoc
+	for i := 0; ; i++ {
+		var n yaml.Node
+		if err := dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return docs, err
+		}
+		if len(n.Content) == 0 {
+			continue
+		}
+		root := n.Content[0]
+		if root.Kind == yaml.ScalarNode && root.Tag == "!!null" {
+			continue
+		}
+		d := k8sDoc{root: root, label: fmt.Sprintf("doc[%d]", i)}
+		if root.Kind == yaml.MappingNode {
+			keys := mapKeys(root)
+			d.apiVersion = scalarAt(keys, "apiVersion")
+			d.kind = scalarAt(keys, "kind")
+			if md, ok := keys["metadata"]; ok && md.value.Kind == yaml.MappingNode {
+				d.name = scalarAt(mapKeys(md.value), "name")
+			}
+			if d.kind != "" {
+				d.label = d.kind + "/" + d.name
+				if d.name == "" {
+					d.label = fmt.Sprintf("%s#%d", d.kind, i)
+				}
+			}
+		}
+		docs = append(docs, d)
+	}
+}
+
+func scalarAt(keys map[string]mapEntry, key string) string {
+	if kv, ok := keys[key]; ok && kv.value.Kind == yaml.ScalarNode {
+		return kv.value.Value
+	}
+	return ""
+}
+
+// injectNamespace adds metadata.namespace to namespaced documents that lack
+// one. Block-style metadata gets a line inserted so the model's formatting and
+// comments survive; anything else falls back to re-encoding the stream.
+func injectNamespace(content string, docs []k8sDoc, ns string) (string, bool) {
+	type insert struct {
+		line   int // 1-based line to insert before
+		indent int
+	}
+	var inserts []insert
+	reencode := false
+	for _, d := range docs {
+		k := k8sKinds[d.apiVersion+"/"+d.kind]
+		if k == nil || !k.Namespaced || d.root.Kind != yaml.MappingNode {
+			continue
+		}
+		md, ok := mapKeys(d.root)["metadata"]
+		if !ok || md.value.Kind != yaml.MappingNode {
+			continue
+		}
+		if _, ok := mapKeys(md.value)["namespace"]; ok {
+			continue
+		}
+		if md.value.Style&yaml.FlowStyle != 0 || len(md.value.Content) == 0 {
+			reencode = true
+		} else {
+			first := md.value.Content[0]
+			inserts = append(inserts, insert{line: first.Line, indent: first.Column - 1})
+		}
+		md.value.Content = append(md.value.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "namespace"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ns})
+	}
+	if reencode {
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		for _, d := range docs {
+			if err := enc.Encode(d.root); err != nil {
+				return content, false
+			}
+		}
+		enc.Close()
+		return buf.String(), true
+	}
+	if len(inserts) == 0 {
+		return content, false
+	}
+	lines := strings.Split(content, "\n")
+	// Back to front so earlier line numbers stay valid.
+	for i := len(inserts) - 1; i >= 0; i-- {
+		in := inserts[i]
+		at := in.line - 1
+		if at < 0 || at > len(lines) {
+			continue
+		}
+		row := strings.Repeat(" ", in.indent) + "namespace: " + yamlScalar(ns)
+		lines = append(lines[:at], append([]string{row}, lines[at:]...)...)
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// yamlScalar renders s as a plain or quoted YAML scalar.
+func yamlScalar(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return s
+	}
+	return strings.TrimSpace(string(out))
+}
+
+var dns1123 = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+
+type k8sValidator struct {
+	issues []Issue
+}
+
+func (v *k8sValidator) add(sev string, n *yaml.Node, path, format string, args ...any) {
+	line := 0
+	if n != nil {
+		line = n.Line
+	}
+	v.issues = append(v.issues, Issue{Severity: sev, Line: line, Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (v *k8sValidator) doc(d k8sDoc, o Options) {
+	if d.root.Kind != yaml.MappingNode {
+		v.add("error", d.root, d.label, "object must be a mapping (got %s)", kindName(d.root))
+		return
+	}
+	if d.apiVersion == "" {
+		v.add("error", d.root, d.label, "missing apiVersion")
+	}
+	if d.kind == "" {
+		v.add("error", d.root, d.label, "missing kind")
+		return
+	}
+	k := k8sKinds[d.apiVersion+"/"+d.kind]
+	if k == nil {
+		if known := k8sByKind[d.kind]; known != nil && d.apiVersion != "" {
+			v.add("error", mapKeys(d.root)["apiVersion"].value, d.label, "%s is served as %s (got %s)", d.kind, known.APIVersion, d.apiVersion)
+		} else {
+			v.add("warning", d.root, d.label, "no bundled schema for %s/%s; field checks skipped", d.apiVersion, d.kind)
+		}
+		return
+	}
+	v.node(d.root, k.Schema, d.label)
+	v.metadata(d, k, o)
+	v.semantics(d)
+}
+
+func (v *k8sValidator) metadata(d k8sDoc, k *k8sKind, o Options) {
+	keys := mapKeys(d.root)
+	md, ok := keys["metadata"]
+	if !ok || md.value.Kind != yaml.MappingNode {
+		return
+	}
+	mk := mapKeys(md.value)
+	if d.name == "" {
+		if _, ok := mk["generateName"]; !ok {
+			v.add("error", md.value, d.label+".metadata", "missing metadata.name")
+		}
+	} else if len(d.name) > 253 || !dns1123.MatchString(d.name) {
+		v.add("error", mk["name"].value, d.label+".metadata.name", "%q is not a valid DNS-1123 name (lowercase alphanumerics, '-' and '.')", d.name)
+	}
+	nsKV, hasNS := mk["namespace"]
+	switch {
+	case hasNS && !k.Namespaced:
+		v.add("warning", nsKV.key, d.label+".metadata.namespace", "%s is cluster-scoped; namespace is ignored", d.kind)
+	case hasNS && o.Namespace != "" && nsKV.value.Value != o.Namespace:
+		v.add("warning", nsKV.value, d.label+".metadata.namespace", "namespace %q differs from :ctx ns %q", nsKV.value.Value, o.Namespace)
+	}
+}
+
+// node checks n against s: unknown fields, types, enums and required fields.
+func (v *k8sValidator) node(n *yaml.Node, s *schema, path string) {
+	if s == nil || s.PreserveUnknown {
+		return
+	}
+	if n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return // null means unset
+	}
+	if s.Format == "quantity" {
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float" && n.Tag != "!!str") {
+			v.add("error", n, path, "expected quantity (number or string), got %s", yamlTypeName(n))
+		}
+		return
+	}
+	if s.IntOrString {
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!str") {
+			v.add("error", n, path, "expected integer or string, got %s", yamlTypeName(n))
+		}
+		return
+	}
+	switch s.Type {
+	case "object":
+		if n.Kind != yaml.MappingNode {
+			v.add("error", n, path, "expected object, got %s", yamlTypeName(n))
+			return
+		}
+		keys := mapKeys(n)
+		for _, req := range s.Required {
+			if _, ok := keys[req]; !ok {
+				v.add("error", n, path, "missing required field %q", req)
+			}
+		}
+		for _, kv := range pairs(n) {
+			k := kv.key.Value
+			child := joinPath(path, k)
+			if p, ok := s.Properties[k]; ok {
+				v.node(kv.value, p, child)
+				continue
+			}
+			if s.AdditionalProperties != nil {
+				v.node(kv.value, s.AdditionalProperties, child)
+				continue
+			}
+			v.add("error", kv.key, child, "unknown field %q", k)
+		}
+	case "array":
+		if n.Kind != yaml.SequenceNode {
+			v.add("error", n, path, "expected list, got %s", yamlTypeName(n))
+			return
+		}
+		for i, item := range n.Content {
+			v.node(item, s.Items, fmt.Sprintf("%s[%d]", path, i))
+		}
+	case "string":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!str" {
+			v.add("error", n, path, "expected string, got %s (quote the value)", yamlTypeName(n))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, n.Value) {
+			v.add("error", n, path, "invalid value %q (one of %s)", n.Value, strings.Join(s.Enum, ", "))
+		}
+	case "integer":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!int" {
+			v.add("error", n, path, "expected integer, got %s", yamlTypeName(n))
+		}
+	case "number":
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float") {
+			v.add("error", n, path, "expected number, got %s", yamlTypeName(n))
+		}
+	case "boolean":
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!bool" {
+			v.add("error", n, path, "expected boolean, got %s", yamlTypeName(n))
+		}
+	}
+}
+
+// semantics covers cross-field rules the schema cannot express.
+func (v *k8sValidator) semantics(d k8sDoc) {
+	var podSpecPath []string
+	var claims []string
+	switch d.kind {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		v.selectorMatches(d)
+		if vcts := nodeAt(d.root, "spec", "volumeClaimTemplates"); vcts != nil && vcts.Kind == yaml.SequenceNode {
+			for _, t := range vcts.Content {
+				if n := nodeAt(t, "metadata", "name"); n != nil {
+					claims = append(claims, n.Value)
+				}
+			}
+		}
+		podSpecPath = []string{"spec", "template", "spec"}
+	case "Job":
+		podSpecPath = []string{"spec", "template", "spec"}
+	case "CronJob":
+		podSpecPath = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	case "Pod":
+		podSpecPath = []string{"spec"}
+	default:
+		return
+	}
+	if ps := nodeAt(d.root, podSpecPath...); ps != nil {
+		v.podSpec(ps, d.label+"."+strings.Join(podSpecPath, "."), claims)
+	}
+}
+
+// selectorMatches checks that spec.selector.matchLabels selects the pod template.
+func (v *k8sValidator) selectorMatches(d k8sDoc) {
+	sel := nodeAt(d.root, "spec", "selector", "matchLabels")
+	if sel == nil || sel.Kind != yaml.MappingNode {
+		return
+	}
+	labels := map[string]string{}
+	if l := nodeAt(d.root, "spec", "template", "metadata", "labels"); l != nil && l.Kind == yaml.MappingNode {
+		for _, kv := range pairs(l) {
+			labels[kv.key.Value] = kv.value.Value
+		}
+	}
+	for _, kv := range pairs(sel) {
+		if got, ok := labels[kv.key.Value]; !ok || got != kv.value.Value {
+			v.add("error", kv.key, d.label+".spec.selector.matchLabels", "selector %s=%s does not match spec.template.metadata.labels", kv.key.Value, kv.value.Value)
+		}
+	}
+}
+
+// podSpec checks container names and that volume mounts refer to declared
+// volumes; claims are StatefulSet volumeClaimTemplates, which also count.
+func (v *k8sValidator) podSpec(ps *yaml.Node, path string, claims []string) {
+	if ps.Kind != yaml.MappingNode {
+		return
+	}
+	volumes := setOf(claims...)
+	if vols := nodeAt(ps, "volumes"); vols != nil && vols.Kind == yaml.SequenceNode {
+		for _, vol := range vols.Content {
+			if n := nodeAt(vol, "name"); n != nil {
+				volumes[n.Value] = true
+			}
+		}
+	}
+	names := map[string]bool{}
+	for _, list := range []string{"initContainers", "containers"} {
+		cs := nodeAt(ps, list)
+		if cs == nil || cs.Kind != yaml.SequenceNode {
+			continue
+		}
+		for i, c := range cs.Content {
+			cpath := fmt.Sprintf("%s.%s[%d]", path, list, i)
+			if n := nodeAt(c, "name"); n != nil {
+				if names[n.Value] {
+					v.add("error", n, cpath+".name", "duplicate container name %q", n.Value)
+				}
+				names[n.Value] = true
+			}
+			if list == "containers" && nodeAt(c, "image") == nil {
+				v.add("error", c, cpath, "missing required field %q", "image")
+			}
+			vms := nodeAt(c, "volumeMounts")
+			if vms == nil || vms.Kind != yaml.SequenceNode {
+				continue
+			}
+			for j, vm := range vms.Content {
+				if n := nodeAt(vm, "name"); n != nil && !volumes[n.Value] {
+					v.add("error", n, fmt.Sprintf("%s.volumeMounts[%d]", cpath, j), "volume %q is not declared in volumes", n.Value)
+				}
+			}
+		}
+	}
+}
+
+// nodeAt walks mapping keys from n; nil when any step is missing.
+func nodeAt(n *yaml.Node, keys ...string) *yaml.Node {
+	for _, k := range keys {
+		if n == nil || n.Kind != yaml.MappingNode {
+			return nil
+		}
+		kv, ok := mapKeys(n)[k]
+		if !ok {
+			return nil
+		}
+		n = kv.value
+	}
+	return n
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// yamlTypeName names the type of a node as a schema would.
+func yamlTypeName(n *yaml.Node) string {
+	if n.Kind != yaml.ScalarNode {
+		return kindName(n)
+	}
+	switch n.Tag {
+	case "!!str":
+		return "string"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!bool":
+		return "boolean"
+	case "!!null":
+		return "null"
+	}
+	return strings.TrimPrefix(n.Tag, "!!")
+}
@@ -0,0 +1,126 @@
+package gen
+
+import (
+	_ "embed"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// k8sSchemaJSON is a trimmed OpenAPI v3 subset of the Kubernetes API for the
+// kinds generated most often. Object references use {"$ref": "<definition>"};
+// x-kubernetes-int-or-string and x-kubernetes-preserve-unknown-fields keep
+// their upstream meaning; "format": "quantity" is resource.Quantity, which
+// upstream accepts as a string or a number (cpu: 0.5).
+//
+//go:embed schemas/k8s.json
+var k8sSchemaJSON []byte
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Enum                 []string           `json:"enum"`
+	Format               string             `json:"format"`
+	IntOrString          bool               `json:"x-kubernetes-int-or-string"`
+	PreserveUnknown      bool               `json:"x-kubernetes-preserve-unknown-fields"`
+}
+
+type k8sKind struct {
+	APIVersion string
+	Kind       string
+	Namespaced bool
+	Schema     *schema
+}
+
+type k8sSchemaFile struct {
+	Kinds map[string]struct {
+		Ref        string `json:"ref"`
+		Namespaced bool   `json:"namespaced"`
+	} `json:"kinds"`
+	Definitions map[string]*schema `json:"definitions"`
+}
+
+// k8sKinds maps "apiVersion/Kind" to its schema; k8sByKind maps Kind alone,
+// to tell a wrong apiVersion from an unknown kind.
+var k8sKinds, k8sByKind = loadK8sSchemas()
+
+func loadK8sSchemas() (map[string]*k8sKind, map[string]*k8sKind) {
+	var f k8sSchemaFile
+	if err := json.Unmarshal(k8sSchemaJSON, &f); err != nil {
+		panic("gen: bundled k8s schema: " + err.Error())
+	}
+	defs := f.Definitions
+	byGVK := map[string]*k8sKind{}
+	byKind := map[string]*k8sKind{}
+	for gvk, k := range f.Kinds {
+		i := strings.LastIndex(gvk, "/")
+		root, ok := defs[k.Ref]
+		if i < 0 || !ok {
+			panic("gen: bundled k8s schema: bad kind " + gvk)
+		}
+		// Every top-level object carries the type and object metadata.
+		s := *root
+		props := map[string]*schema{
+			"apiVersion": {Type: "string"},
+			"kind":       {Type: "string"},
+			"metadata":   {Ref: "ObjectMeta"},
+			"status":     {PreserveUnknown: true},
+		}
+		for name, p := range root.Properties {
+			props[name] = p
+		}
+		s.Properties = props
+		kk := &k8sKind{APIVersion: gvk[:i], Kind: gvk[i+1:], Namespaced: k.Namespaced, Schema: &s}
+		byGVK[gvk] = kk
+		byKind[kk.Kind] = kk
+	}
+	resolveRefs(defs, byGVK)
+	return byGVK, byKind
+}
+
+// resolveRefs replaces every $ref with the referenced definition, in place.
+// Definitions may be recursive through refs, so pointers are shared rather
+// than copied.
+func resolveRefs(defs map[string]*schema, kinds map[string]*k8sKind) {
+	seen := map[*schema]bool{}
+	var walk func(s *schema) *schema
+	walk = func(s *schema) *schema {
+		if s == nil {
+			return nil
+		}
+		for s.Ref != "" {
+			d, ok := defs[s.Ref]
+			if !ok {
+				panic("gen: bundled k8s schema: unknown $ref " + s.Ref)
+			}
+			s = d
+		}
+		if seen[s] {
+			return s
+		}
+		seen[s] = true
+		for name, p := range s.Properties {
+			s.Properties[name] = walk(p)
+		}
+		s.Items = walk(s.Items)
+		s.AdditionalProperties = walk(s.AdditionalProperties)
+		return s
+	}
+	for _, k := range kinds {
+		k.Schema = walk(k.Schema)
+	}
+}
+
+// K8sKinds lists the bundled kinds as "apiVersion/Kind", sorted.
+func K8sKinds() []string {
+	out := make([]string, 0, len(k8sKinds))
+	for gvk := range k8sKinds {
+		out = append(out, gvk)
+	}
+	sort.Strings(out)
+	return out
+}
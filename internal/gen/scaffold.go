@@ -0,0 +1,259 @@
+package gen
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is one generated file of a scaffold, with a slash-separated path
+// relative to the target directory.
+type File struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Scaffold describes a multi-file layout (an Ansible role, a k8s bundle, ...)
+// the model fills in through the file envelope.
+type Scaffold struct {
+	Name        string
+	Description string
+	// System returns the layout-specific part of the system prompt.
+	System func(o Options) string
+	// Required lists paths that must be present.
+	Required []string
+	// Check validates one file; nil falls back to CheckByExtension.
+	Check func(path, content string, o Options) Result
+	// Cross checks references between files (optional).
+	Cross func(files []File) []Issue
+}
+
+var scaffolds = map[string]*Scaffold{}
+
+func registerScaffold(s *Scaffold) { scaffolds[s.Name] = s }
+
+// LookupScaffold returns the named scaffold, or nil.
+func LookupScaffold(name string) *Scaffold {
+	return scaffolds[strings.ToLower(strings.TrimSpace(name))]
+}
+
+// ScaffoldNames lists registered scaffolds, sorted.
+func ScaffoldNames() []string {
+	out := make([]string, 0, len(scaffolds))
+	for k := range scaffolds {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// MaxScaffoldFiles bounds how many files one answer may create.
+const MaxScaffoldFiles = 64
+
+// envelopeRules is appended to every scaffold system prompt.
+const envelopeRules = `
+[출력 형식]
+파일마다 아래 봉투(envelope) 형식으로만 출력하세요. 봉투 밖에는 아무것도 쓰지 마세요.
+=== FILE: <상대 경로> ===
+<파일 내용 원문>
+=== END ===
+- 경로는 대상 디렉터리 기준 상대 경로('/' 구분)이며 절대 경로나 '..' 은 금지입니다.
+- 파일 내용에 마크다운 코드펜스를 넣지 마세요.
+`
+
+// ScaffoldSystem returns the full system prompt for s.
+func ScaffoldSystem(s *Scaffold, o Options) string {
+	return strings.TrimSpace(s.System(o)) + "\n" + envelopeRules
+}
+
+var (
+	fileStart = regexp.MustCompile(`^\s*={3,}\s*FILE:\s*(.+?)\s*={3,}\s*$`)
+	fileEnd   = regexp.MustCompile(`^\s*={3,}\s*END(\s+FILE)?\s*={3,}\s*$`)
+)
+
+// ParseFiles extracts files from a model answer: the "=== FILE: p ===" envelope,
+// or a JSON {"files":[{"path","content"}]} object. Paths are cleaned and
+// checked; duplicates are an error.
+func ParseFiles(answer string) ([]File, error) {
+	var files []File
+	if trimmed := strings.TrimSpace(stripFences(answer)); strings.HasPrefix(trimmed, "{") {
+		var env struct {
+			Files []File `json:"files"`
+		}
+		if err := json.Unmarshal([]byte(trimmed), &env); err == nil {
+			files = env.Files
+		}
+	}
+	if files == nil {
+		files = parseEnvelope(answer)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files found (expected \"=== FILE: <path> ===\" blocks)")
+	}
+	if len(files) > MaxScaffoldFiles {
+		return nil, fmt.Errorf("too many files: %d (max %d)", len(files), MaxScaffoldFiles)
+	}
+	seen := map[string]bool{}
+	for i := range files {
+		p, err := cleanRelPath(files[i].Path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[p] {
+			return nil, fmt.Errorf("duplicate file %s", p)
+		}
+		seen[p] = true
+		files[i].Path = p
+		files[i].Content = strings.TrimRight(stripFences(files[i].Content), "\n") + "\n"
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func parseEnvelope(answer string) []File {
+	var files []File
+	var cur *File
+	var body []string
+	flush := func() {
+		if cur != nil {
+			cur.Content = strings.Join(body, "\n")
+			files = append(files, *cur)
+		}
+		cur, body = nil, nil
+	}
+	sc := bufio.NewScanner(strings.NewReader(answer))
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := fileStart.FindStringSubmatch(line); m != nil {
+			// A missing END is tolerated: the next FILE closes the previous one.
+			flush()
+			cur = &File{Path: strings.Trim(m[1], "`'\"")}
+			continue
+		}
+		if fileEnd.MatchString(line) {
+			flush()
+			continue
+		}
+		if cur != nil {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return files
+}
+
+// cleanRelPath normalizes p and rejects paths escaping the target directory.
+func cleanRelPath(p string) (string, error) {
+	p = strings.TrimSpace(strings.ReplaceAll(p, "\\", "/"))
+	if p == "" {
+		return "", fmt.Errorf("file with empty path")
+	}
+	if strings.HasPrefix(p, "/") || strings.HasPrefix(p, "~") {
+		return "", fmt.Errorf("absolute path not allowed: %s", p)
+	}
+	c := path.Clean(p)
+	if c == "." || c == ".." || strings.HasPrefix(c, "../") {
+		return "", fmt.Errorf("path escapes the target directory: %s", p)
+	}
+	return c, nil
+}
+
+// stripFences removes a single surrounding markdown code fence, if any.
+func stripFences(s string) string {
+	t := strings.TrimSpace(s)
+	if !strings.HasPrefix(t, "```") {
+		return s
+	}
+	lines := strings.Split(t, "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[len(lines)-1]) != "```" {
+		return s
+	}
+	return strings.Join(lines[1:len(lines)-1], "\n")
+}
+
+// CheckFiles validates each file with the scaffold's checker and the layout's
+// required paths. Issue paths are prefixed with the file path; content a
+// checker rewrites (e.g. an injected namespace) is stored back into files.
+func CheckFiles(s *Scaffold, files []File, o Options) (map[string]Result, []Issue) {
+	results := map[string]Result{}
+	var all []Issue
+	have := map[string]bool{}
+	for i, f := range files {
+		have[f.Path] = true
+		check := s.Check
+		if check == nil {
+			check = CheckByExtension
+		}
+		res := check(f.Path, f.Content, o)
+		if res.Content == "" {
+			res.Content = f.Content
+		}
+		files[i].Content = res.Content
+		results[f.Path] = res
+		for _, is := range res.Issues {
+			is.Path = strings.TrimSpace(f.Path + " " + is.Path)
+			all = append(all, is)
+		}
+	}
+	for _, req := range s.Required {
+		if !have[req] {
+			all = append(all, Issue{Severity: "error", Path: req, Msg: "required file is missing"})
+		}
+	}
+	if s.Cross != nil {
+		all = append(all, s.Cross(files)...)
+	}
+	return results, all
+}
+
+// CheckByExtension validates by file type: shell scripts with the bash
+// target, YAML and JSON by parsing; other files are accepted as is.
+func CheckByExtension(p, content string, o Options) Result {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".sh", ".bash":
+		return ValidateBash(content, o)
+	case ".yml", ".yaml":
+		return validateYAML(content)
+	case ".json":
+		res := Result{Content: content}
+		var v any
+		if err := json.Unmarshal([]byte(content), &v); err != nil {
+			res.Issues = append(res.Issues, Issue{Severity: "error", Msg: "invalid JSON: " + err.Error()})
+		}
+		return res
+	}
+	return Result{Content: content}
+}
+
+// validateYAML only checks that every document parses.
+func validateYAML(content string) Result {
+	res := Result{Content: content}
+	if _, err := splitK8sDocs(content); err != nil {
+		res.Issues = append(res.Issues, yamlIssue(err))
+	}
+	return res
+}
+
+// ValidateAnsibleTasks checks a role tasks/handlers file: a list of tasks.
+func ValidateAnsibleTasks(content string, o Options) Result {
+	res := Result{Content: content}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		res.Issues = append(res.Issues, yamlIssue(err))
+		return res
+	}
+	if len(doc.Content) == 0 {
+		return res // an empty handlers file is fine
+	}
+	v := &ansibleValidator{}
+	v.taskList(doc.Content[0], "tasks")
+	res.Issues = append(res.Issues, v.issues...)
+	return res
+}
@@ -0,0 +1,159 @@
+package gen
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	registerScaffold(&Scaffold{
+		Name:        "role",
+		Description: "Ansible role (tasks/handlers/defaults/templates/meta)",
+		System:      roleSystem,
+		Required:    []string{"tasks/main.yml"},
+		Check:       checkRoleFile,
+	})
+	registerScaffold(&Scaffold{
+		Name:        "k8s",
+		Description: "Kubernetes bundle (one manifest per resource + kustomization.yaml)",
+		System:      k8sBundleSystem,
+		Check:       checkK8sBundleFile,
+		Cross:       checkKustomization,
+	})
+	registerScaffold(&Scaffold{
+		Name:        "files",
+		Description: "any set of files (checked by extension: .sh, .yml/.yaml, .json)",
+		System: func(Options) string {
+			return "당신은 프로젝트 스캐폴드 생성기입니다. 요청에 필요한 파일들을 모두 생성하세요.\n" +
+				"- 각 파일은 완전한 내용이어야 하며 설명 문장은 쓰지 마세요."
+		},
+	})
+}
+
+func roleSystem(o Options) string {
+	var b strings.Builder
+	b.WriteString("당신은 Ansible 롤(role) 생성기입니다. 요청을 수행하는 롤 하나의 파일들을 생성하세요.\n")
+	b.WriteString("구조:\n")
+	b.WriteString("- tasks/main.yml (필수): 플레이가 아닌 task 의 리스트입니다. hosts/tasks 키를 쓰지 마세요.\n")
+	b.WriteString("- handlers/main.yml: notify 로 호출되는 핸들러 task 리스트\n")
+	b.WriteString("- defaults/main.yml: 변수 기본값(매핑), 롤 이름을 접두사로 사용 (예: nginx_port)\n")
+	b.WriteString("- templates/*.j2, files/*: 필요할 때만\n")
+	b.WriteString("- meta/main.yml: galaxy_info(author, description, license, min_ansible_version)\n")
+	b.WriteString("- README.md: 변수와 사용 예시\n")
+	b.WriteString("규칙: 모든 task 에 name, task 하나에 모듈 하나, 모듈은 FQCN(ansible.builtin.*)으로 쓰세요.\n")
+	if len(o.Inventory) > 0 {
+		b.WriteString("대상 호스트: " + strings.Join(o.Inventory, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// checkRoleFile validates role files by their place in the layout.
+func checkRoleFile(p, content string, o Options) Result {
+	dir, ext := strings.SplitN(p, "/", 2)[0], strings.ToLower(path.Ext(p))
+	yamlFile := ext == ".yml" || ext == ".yaml"
+	switch {
+	case yamlFile && (dir == "tasks" || dir == "handlers"):
+		return ValidateAnsibleTasks(content, o)
+	case yamlFile && (dir == "defaults" || dir == "vars" || dir == "meta"):
+		res := validateYAML(content)
+		if res.OK() {
+			res.Issues = append(res.Issues, requireMapping(content)...)
+		}
+		return res
+	case dir == "templates" && ext != ".j2":
+		return Result{Content: content, Issues: []Issue{{Severity: "warning", Msg: "templates are expected to end in .j2"}}}
+	case dir == "templates" || dir == "files":
+		return Result{Content: content}
+	}
+	return CheckByExtension(p, content, o)
+}
+
+func k8sBundleSystem(o Options) string {
+	var b strings.Builder
+	b.WriteString(k8sSystem(o))
+	b.WriteString("\n[번들 구조]\n")
+	b.WriteString("- 리소스마다 파일 하나: <kind 소문자>.yaml (예: deployment.yaml, service.yaml). 같은 kind 가 여럿이면 <이름>-<kind>.yaml\n")
+	b.WriteString("- kustomization.yaml: apiVersion kustomize.config.k8s.io/v1beta1, kind Kustomization, resources 에 모든 파일 나열\n")
+	return b.String()
+}
+
+// checkK8sBundleFile validates manifests against the bundled schemas;
+// kustomization.yaml is only parsed.
+func checkK8sBundleFile(p, content string, o Options) Result {
+	base := path.Base(p)
+	if base == "kustomization.yaml" || base == "kustomization.yml" {
+		res := validateYAML(content)
+		if res.OK() {
+			res.Issues = append(res.Issues, requireMapping(content)...)
+		}
+		return res
+	}
+	switch strings.ToLower(path.Ext(p)) {
+	case ".yml", ".yaml":
+		return ValidateK8s(content, o)
+	}
+	return CheckByExtension(p, content, o)
+}
+
+// checkKustomization checks that kustomization.yaml resources exist in the
+// bundle and that every manifest is listed.
+func checkKustomization(files []File) []Issue {
+	var kust *File
+	manifests := map[string]bool{}
+	for i, f := range files {
+		switch base := path.Base(f.Path); {
+		case base == "kustomization.yaml" || base == "kustomization.yml":
+			kust = &files[i]
+		case strings.HasSuffix(base, ".yaml") || strings.HasSuffix(base, ".yml"):
+			manifests[f.Path] = true
+		}
+	}
+	if kust == nil {
+		return nil
+	}
+	var k struct {
+		Resources []string `yaml:"resources"`
+	}
+	if err := yaml.Unmarshal([]byte(kust.Content), &k); err != nil {
+		return nil // reported by the per-file check
+	}
+	var issues []Issue
+	dir := path.Dir(kust.Path)
+	listed := map[string]bool{}
+	for _, r := range k.Resources {
+		if strings.Contains(r, "://") || strings.HasSuffix(r, "/") {
+			continue // remote or directory bases are not part of the bundle
+		}
+		p := path.Join(dir, r)
+		listed[p] = true
+		if !manifests[p] {
+			issues = append(issues, Issue{Severity: "error", Path: kust.Path, Msg: "resource " + r + " is not in the bundle"})
+		}
+	}
+	for p := range manifests {
+		if !listed[p] {
+			issues = append(issues, Issue{Severity: "warning", Path: kust.Path, Msg: p + " is not listed in resources"})
+		}
+	}
+	sortIssues(issues)
+	return issues
+}
+
+// requireMapping reports an error unless the first document is a mapping.
+func requireMapping(content string) []Issue {
+	docs, err := splitK8sDocs(content)
+	if err != nil || len(docs) == 0 {
+		return nil
+	}
+	if docs[0].root.Kind != yaml.MappingNode {
+		return []Issue{{Severity: "error", Line: docs[0].root.Line, Msg: "expected a mapping of variables (got " + kindName(docs[0].root) + ")"}}
+	}
+	return nil
+}
+
+func sortIssues(issues []Issue) {
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Msg < issues[j].Msg })
+}
@@ -0,0 +1,100 @@
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Options carries the per-run inputs a target may use in its prompt or validation.
+type Options struct {
+	Inventory   []string // hosts the generated code will run against (ansible)
+	Namespace   string   // default namespace (k8s, from :ctx ns)
+	SyntaxCheck bool     // run external syntax checkers when available
+}
+
+// Issue is one validation problem. Line is 1-based, 0 when unknown.
+type Issue struct {
+	Severity string // "error" | "warning"
+	Line     int
+	Path     string // location inside the document, e.g. play[0].tasks[2]
+	Msg      string
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	b.WriteString(i.Severity)
+	if i.Line > 0 {
+		fmt.Fprintf(&b, " line %d", i.Line)
+	}
+	if i.Path != "" {
+		b.WriteString(" " + i.Path)
+	}
+	b.WriteString(": " + i.Msg)
+	return b.String()
+}
+
+// Result is the outcome of validating one generated document.
+type Result struct {
+	Issues []Issue
+	// Content is the (possibly normalized) content to save; targets may rewrite
+	// the model output, e.g. inject a namespace.
+	Content string
+}
+
+// OK reports whether the result has no errors (warnings are allowed).
+func (r Result) OK() bool {
+	for _, i := range r.Issues {
+		if i.Severity == "error" {
+			return false
+		}
+	}
+	return true
+}
+
+// Errors returns the error issues only.
+func (r Result) Errors() []Issue {
+	var out []Issue
+	for _, i := range r.Issues {
+		if i.Severity == "error" {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// String renders all issues, one per line.
+func (r Result) String() string {
+	lines := make([]string, 0, len(r.Issues))
+	for _, i := range r.Issues {
+		lines = append(lines, i.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Target describes a kind of generated artifact: how to ask for it and how to check it.
+type Target struct {
+	Name        string
+	Description string
+	// System returns the system prompt for this target.
+	System func(o Options) string
+	// Validate checks generated content. nil means no validation.
+	Validate func(content string, o Options) Result
+}
+
+var targets = map[string]*Target{}
+
+func register(t *Target) { targets[t.Name] = t }
+
+// Lookup returns the named target, or nil.
+func Lookup(name string) *Target { return targets[strings.ToLower(strings.TrimSpace(name))] }
+
+// Names lists registered targets, sorted.
+func Names() []string {
+	out := make([]string, 0, len(targets))
+	for k := range targets {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,311 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Domain is one specialized analysis pass over a subset of artifacts.
+type Domain struct {
+	Name       string
+	Categories []string // artifact categories routed to this domain
+	Focus      string   // what the model should look for
+}
+
+// Domains routes artifact categories to specialized prompts. system artifacts
+// (uname/os-release/uptime) go to every domain as background.
+var Domains = []Domain{
+	{"disk", []string{"disk"},
+		"파일시스템/inode 사용률(85% 이상 주의, 95% 이상 위험), 읽기 전용 마운트, 누락된 마운트, 블록 디바이스 이상"},
+	{"memory", []string{"memory", "process", "pcp"},
+		"가용 메모리 부족, swap 사용, 메모리/CPU 점유 상위 프로세스, 좀비(Z)/D 상태 프로세스, load 대비 CPU 수, iowait"},
+	{"services", []string{"services", "logs", "k8s", "network"},
+		"실패한 systemd 유닛, 반복 재시작, kubelet/ovs 상태와 오류, journal 의 반복 오류/경고"},
+	{"kernel", []string{"kernel"},
+		"OOM killer, I/O 오류, 파일시스템 오류, hung task/soft lockup, 네트워크 드라이버 오류, segfault, 하드웨어(MCE/EDAC) 오류"},
+}
+
+// Severities from most to least severe.
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+func severityRank(s string) int {
+	for i, x := range Severities {
+		if x == s {
+			return i
+		}
+	}
+	return len(Severities)
+}
+
+// NormalizeSeverity maps model output (e.g. "CRIT", "warning") onto Severities.
+func NormalizeSeverity(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case strings.HasPrefix(s, "crit"), s == "emerg", s == "fatal":
+		return "critical"
+	case s == "high", s == "error", s == "err", s == "major":
+		return "high"
+	case s == "medium", s == "med", s == "warning", s == "warn", s == "moderate":
+		return "medium"
+	case s == "low", s == "minor", s == "notice":
+		return "low"
+	}
+	return "info"
+}
+
+// Finding is one ranked issue in the report.
+type Finding struct {
+	Host           string   `json:"host"`
+	Domain         string   `json:"domain"`
+	Severity       string   `json:"severity"`
+	Title          string   `json:"title"`
+	Detail         string   `json:"detail,omitempty"`
+	Evidence       []string `json:"evidence,omitempty"` // "<host>/<artifact>.txt: quoted line"
+	Recommendation string   `json:"recommendation,omitempty"`
+}
+
+// Report is the output of health-ai.
+type Report struct {
+	Bundle    string    `json:"bundle"`
+	CreatedAt time.Time `json:"created_at"`
+	Collected time.Time `json:"collected_at"`
+	Hosts     []string  `json:"hosts"`
+	Findings  []Finding `json:"findings"`
+	Errors    []string  `json:"errors,omitempty"` // analysis passes that failed
+}
+
+// Task is one LLM call: a domain on a host with its artifacts rendered.
+type Task struct {
+	Host    string
+	Domain  Domain
+	Sources []string // artifact paths included
+	Content string
+}
+
+// Tasks builds the analysis passes for b. Domains without any collected
+// artifact on a host are skipped; domains filters by name when non-empty.
+func (b *Bundle) Tasks(domains []string, maxArtifactChars int) []Task {
+	var out []Task
+	for _, h := range b.Manifest.Hosts {
+		if h.Error != "" {
+			continue
+		}
+		var background strings.Builder
+		for _, a := range h.Artifacts {
+			if a.Category == "system" && a.Path != "" {
+				fmt.Fprintf(&background, "### %s\n%s\n", a.Path, clip(string(b.Files[a.Path]), 2000))
+			}
+		}
+		for _, d := range Domains {
+			if len(domains) > 0 && !containsStr(domains, d.Name) {
+				continue
+			}
+			var sb strings.Builder
+			var sources []string
+			for _, a := range h.Artifacts {
+				if !containsStr(d.Categories, a.Category) {
+					continue
+				}
+				switch {
+				case a.Skipped:
+					fmt.Fprintf(&sb, "### %s (n/a on this host)\n\n", a.Name)
+				case a.Path == "":
+					fmt.Fprintf(&sb, "### %s (no output, exit=%d %s)\n\n", a.Name, a.ExitCode, a.Error)
+				default:
+					sources = append(sources, a.Path)
+					fmt.Fprintf(&sb, "### %s (exit=%d)\n%s\n\n", a.Path, a.ExitCode, clip(string(b.Files[a.Path]), maxArtifactChars))
+				}
+			}
+			if len(sources) == 0 {
+				continue
+			}
+			out = append(out, Task{
+				Host:    h.Host,
+				Domain:  d,
+				Sources: sources,
+				Content: "[HOST] " + h.Host + "\n" + background.String() + "\n" + sb.String(),
+			})
+		}
+	}
+	return out
+}
+
+// unreachable turns hosts that could not be collected into findings, without the LLM.
+func (b *Bundle) unreachable() []Finding {
+	var out []Finding
+	for _, h := range b.Manifest.Hosts {
+		if h.Error != "" {
+			out = append(out, Finding{Host: h.Host, Domain: "collect", Severity: "high", Title: "호스트 수집 실패", Detail: h.Error, Evidence: []string{ManifestName}})
+		}
+	}
+	return out
+}
+
+// SystemPrompt returns the specialized prompt for a domain pass.
+func (t Task) SystemPrompt() string {
+	return "당신은 리눅스/쿠버네티스 SRE 입니다. 지금은 '" + t.Domain.Name + "' 관점만 점검합니다.\n" +
+		"중점: " + t.Domain.Focus + "\n" +
+		"첨부된 아티팩트(### <경로>)만 근거로 판단하고, 문제가 없으면 빈 findings 를 반환하세요.\n" +
+		"반드시 아래 JSON 하나만 출력하세요(설명/마크다운 금지):\n" +
+		`{"findings":[{"severity":"critical|high|medium|low|info","title":"한 줄 요약","detail":"근거 해석",` +
+		`"evidence":["<경로>: 근거가 되는 원문 줄"],"recommendation":"확인 명령/조치"}]}`
+}
+
+// Question is the user question for a domain pass.
+func (t Task) Question() string {
+	return fmt.Sprintf("호스트 %s 의 %s 관련 문제를 찾아 JSON 으로 보고하세요.", t.Host, t.Domain.Name)
+}
+
+// ParseFindings extracts findings from a model answer. Answers that are not
+// the requested JSON become a single info finding carrying the text, so
+// nothing the model said is dropped.
+func (t Task) ParseFindings(answer string) []Finding {
+	var env struct {
+		Findings []Finding `json:"findings"`
+	}
+	raw := answer
+	if i, j := strings.Index(raw, "{"), strings.LastIndex(raw, "}"); i >= 0 && j > i {
+		raw = raw[i : j+1]
+	}
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		txt := strings.TrimSpace(answer)
+		if txt == "" {
+			return nil
+		}
+		return []Finding{{Host: t.Host, Domain: t.Domain.Name, Severity: "info", Title: "구조화되지 않은 응답", Detail: clip(txt, 1500), Evidence: t.Sources}}
+	}
+	out := make([]Finding, 0, len(env.Findings))
+	for _, f := range env.Findings {
+		if strings.TrimSpace(f.Title) == "" {
+			continue
+		}
+		f.Host = t.Host
+		f.Domain = t.Domain.Name
+		f.Severity = NormalizeSeverity(f.Severity)
+		if len(f.Evidence) == 0 {
+			f.Evidence = t.Sources
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// AskFunc sends one analysis pass to the LLM.
+type AskFunc func(system, question, content string) (string, error)
+
+// Analyze runs every task through ask and returns the ranked report.
+// progress (optional) is called before each pass.
+func (b *Bundle) Analyze(name string, tasks []Task, ask AskFunc, progress func(Task)) *Report {
+	r := &Report{Bundle: name, CreatedAt: time.Now(), Collected: b.Manifest.CreatedAt}
+	for _, h := range b.Manifest.Hosts {
+		r.Hosts = append(r.Hosts, h.Host)
+	}
+	r.Findings = append(r.Findings, b.unreachable()...)
+	for _, t := range tasks {
+		if progress != nil {
+			progress(t)
+		}
+		out, err := ask(t.SystemPrompt(), t.Question(), t.Content)
+		if err != nil {
+			r.Errors = append(r.Errors, fmt.Sprintf("%s/%s: %v", t.Host, t.Domain.Name, err))
+			continue
+		}
+		r.Findings = append(r.Findings, t.ParseFindings(out)...)
+	}
+	r.Rank()
+	return r
+}
+
+// Rank orders findings by severity, then host, then domain.
+func (r *Report) Rank() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+		if ra, rb := severityRank(a.Severity), severityRank(b.Severity); ra != rb {
+			return ra < rb
+		}
+		if a.Host != b.Host {
+			return a.Host < b.Host
+		}
+		return a.Domain < b.Domain
+	})
+}
+
+// JSON renders the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) { return json.MarshalIndent(r, "", "  ") }
+
+// Markdown renders the report for humans.
+func (r *Report) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Health report\n\n")
+	fmt.Fprintf(&sb, "- bundle: `%s`\n- collected: %s\n- hosts: %s\n", r.Bundle, r.Collected.Format(time.RFC3339), strings.Join(r.Hosts, ", "))
+	counts := map[string]int{}
+	for _, f := range r.Findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, s := range Severities {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", s, counts[s]))
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "none")
+	}
+	fmt.Fprintf(&sb, "- findings: %s\n\n", strings.Join(parts, " "))
+
+	if len(r.Findings) > 0 {
+		sb.WriteString("| # | severity | host | domain | finding |\n|---|---|---|---|---|\n")
+		for i, f := range r.Findings {
+			fmt.Fprintf(&sb, "| %d | %s | %s | %s | %s |\n", i+1, strings.ToUpper(f.Severity), f.Host, f.Domain, mdCell(f.Title))
+		}
+		sb.WriteString("\n")
+	}
+	for i, f := range r.Findings {
+		fmt.Fprintf(&sb, "## %d. [%s] %s — %s\n\n", i+1, strings.ToUpper(f.Severity), f.Host, f.Title)
+		if f.Detail != "" {
+			sb.WriteString(f.Detail + "\n\n")
+		}
+		if len(f.Evidence) > 0 {
+			sb.WriteString("Evidence:\n")
+			for _, e := range f.Evidence {
+				fmt.Fprintf(&sb, "- `%s`\n", strings.ReplaceAll(e, "`", "'"))
+			}
+			sb.WriteString("\n")
+		}
+		if f.Recommendation != "" {
+			sb.WriteString("Recommendation: " + f.Recommendation + "\n\n")
+		}
+	}
+	if len(r.Errors) > 0 {
+		sb.WriteString("## Analysis errors\n\n")
+		for _, e := range r.Errors {
+			sb.WriteString("- " + e + "\n")
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n") + "\n"
+}
+
+func mdCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", "\\|"), "\n", " ")
+}
+
+// clip keeps the end of s (latest lines) when it exceeds n runes.
+func clip(s string, n int) string {
+	r := []rune(strings.TrimSpace(s))
+	if n <= 0 || len(r) <= n {
+		return string(r)
+	}
+	return "...(truncated)\n" + string(r[len(r)-n:])
+}
+
+func containsStr(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package health
+
+import "fmt"
+
+// Artifact is one piece of evidence collected from a host. Cmd runs under
+// `bash -c` (locally or through ssh); exit status 127 marks the artifact as
+// not applicable on that host (tool or unit missing) rather than failed.
+type Artifact struct {
+	Name     string // file name inside the bundle: <host>/<Name>.txt
+	Category string // system|disk|memory|process|kernel|services|logs|pcp|k8s|network
+	Cmd      string
+}
+
+// exitNotPresent is what artifacts return when their tool/unit does not exist.
+const exitNotPresent = 127
+
+// DefaultArtifacts is the standard evidence set. journalLines bounds the
+// journal/dmesg tails.
+func DefaultArtifacts(journalLines int) []Artifact {
+	if journalLines <= 0 {
+		journalLines = 500
+	}
+	return []Artifact{
+		{"uname", "system", "uname -a"},
+		{"os-release", "system", "cat /etc/os-release"},
+		{"uptime", "system", "uptime"},
+		{"df", "disk", "df -hT -x tmpfs -x devtmpfs -x overlay"},
+		{"df-inodes", "disk", "df -i -x tmpfs -x devtmpfs -x overlay"},
+		{"lsblk", "disk", "command -v lsblk >/dev/null || exit 127; lsblk -o NAME,SIZE,TYPE,FSTYPE,MOUNTPOINT"},
+		{"free", "memory", "free -m"},
+		{"meminfo", "memory", "cat /proc/meminfo"},
+		{"ps", "process", "ps -eo pid,ppid,user,%cpu,%mem,rss,stat,lstart,args --sort=-%cpu | head -n 60"},
+		{"ps-mem", "process", "ps -eo pid,user,%mem,rss,args --sort=-rss | head -n 30"},
+		{"dmesg", "kernel", fmt.Sprintf("(dmesg -T --level=emerg,alert,crit,err,warn 2>/dev/null || dmesg) | tail -n %d", journalLines)},
+		{"systemctl-failed", "services", "command -v systemctl >/dev/null || exit 127; systemctl --failed --no-pager --plain"},
+		{"journal", "logs", fmt.Sprintf("command -v journalctl >/dev/null || exit 127; journalctl -p warning -n %d --no-pager -o short-iso", journalLines)},
+		{"pcp", "pcp", "command -v pmrep >/dev/null || exit 127; pmrep -t 1 -s 5 kernel.all.load kernel.all.cpu.user kernel.all.cpu.sys kernel.all.cpu.wait.total mem.util.available mem.util.used swap.used disk.all.read_bytes disk.all.write_bytes"},
+		{"kubelet", "k8s", fmt.Sprintf("systemctl cat kubelet >/dev/null 2>&1 || exit 127; systemctl status kubelet --no-pager -l; echo; journalctl -u kubelet -p warning -n %d --no-pager -o short-iso", journalLines/2)},
+		{"ovs", "network", "command -v ovs-vsctl >/dev/null || exit 127; ovs-vsctl show; echo; systemctl status openvswitch ovs-vswitchd ovsdb-server --no-pager -l 2>/dev/null"},
+	}
+}
@@ -0,0 +1,158 @@
+package health
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ManifestName is the manifest path inside a bundle.
+const ManifestName = "manifest.json"
+
+// WriteBundle writes m and its collected outputs as a gzipped tarball. The file
+// is written to a temp name first so a failed run never leaves a partial bundle.
+func WriteBundle(path string, m *Manifest) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".health-*.tgz")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	tw := tar.NewWriter(gz)
+	mj, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	now := m.CreatedAt
+	if err := addFile(tw, ManifestName, mj, now); err != nil {
+		return err
+	}
+	for _, h := range m.Hosts {
+		paths := make([]string, 0, len(h.outputs))
+		for p := range h.outputs {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			if err := addFile(tw, p, h.outputs[p], h.Finished); err != nil {
+				return err
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func addFile(tw *tar.Writer, name string, data []byte, mod time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(data)), ModTime: mod, Typeflag: tar.TypeReg}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// Bundle is an opened health bundle: the manifest plus artifact contents by path.
+type Bundle struct {
+	Manifest Manifest
+	Files    map[string][]byte
+}
+
+// OpenBundle reads a bundle written by WriteBundle.
+func OpenBundle(path string) (*Bundle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip bundle: %w", err)
+	}
+	defer gz.Close()
+	b := &Bundle{Files: map[string][]byte{}}
+	found := false
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxArtifactBytes))
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimPrefix(hdr.Name, "./")
+		if name == ManifestName {
+			if err := json.Unmarshal(data, &b.Manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			found = true
+			continue
+		}
+		b.Files[name] = data
+	}
+	if !found {
+		return nil, fmt.Errorf("%s: no %s (not a health-collect bundle?)", path, ManifestName)
+	}
+	return b, nil
+}
+
+// Summary renders a short per-host table of what was collected.
+func (m *Manifest) Summary() string {
+	var sb strings.Builder
+	for _, h := range m.Hosts {
+		if h.Error != "" {
+			fmt.Fprintf(&sb, "%-24s ERROR %s\n", h.Host, h.Error)
+			continue
+		}
+		var ok, skipped, failed []string
+		for _, a := range h.Artifacts {
+			switch {
+			case a.Skipped:
+				skipped = append(skipped, a.Name)
+			case a.Error != "" || a.ExitCode != 0:
+				failed = append(failed, a.Name)
+			default:
+				ok = append(ok, a.Name)
+			}
+		}
+		fmt.Fprintf(&sb, "%-24s ok=%d failed=%d skipped=%d (%s)", h.Host, len(ok), len(failed), len(skipped), h.Finished.Sub(h.Started).Round(100*time.Millisecond))
+		if len(failed) > 0 {
+			fmt.Fprintf(&sb, " failed: %s", strings.Join(failed, ","))
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(&sb, " n/a: %s", strings.Join(skipped, ","))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
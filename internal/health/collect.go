@@ -0,0 +1,261 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"kiki-ai-shell/internal/pcp"
+)
+
+// ManifestVersion is bumped when the bundle layout changes.
+const ManifestVersion = 1
+
+// maxArtifactBytes caps a single artifact so one runaway command cannot bloat the bundle.
+const maxArtifactBytes = 8 << 20
+
+// Manifest describes a bundle; it is stored as manifest.json at the tarball root.
+type Manifest struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Collector string       `json:"collector"`
+	Hosts     []HostResult `json:"hosts"`
+}
+
+// HostResult is what was collected from one host.
+type HostResult struct {
+	Host      string           `json:"host"`
+	Remote    bool             `json:"remote"`
+	Started   time.Time        `json:"started"`
+	Finished  time.Time        `json:"finished"`
+	Error     string           `json:"error,omitempty"` // host unreachable; no artifacts
+	Artifacts []ArtifactResult `json:"artifacts"`
+
+	outputs map[string][]byte
+}
+
+// ArtifactResult records one artifact; Path is relative to the bundle root.
+type ArtifactResult struct {
+	Name       string `json:"name"`
+	Category   string `json:"category"`
+	Command    string `json:"command"`
+	Path       string `json:"path,omitempty"`
+	ExitCode   int    `json:"exit_code"`
+	Bytes      int    `json:"bytes"`
+	SHA256     string `json:"sha256,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	Skipped    bool   `json:"skipped,omitempty"` // not applicable on this host
+	Truncated  bool   `json:"truncated,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Options controls Collect.
+type Options struct {
+	Hosts     []string // empty or "local" = this machine
+	Artifacts []Artifact
+	SSHUser   string
+	SSHOpts   []string      // extra ssh arguments, e.g. -i key -p 2222
+	Timeout   time.Duration // per artifact
+	Parallel  int           // hosts collected concurrently
+	Progress  func(host, msg string)
+}
+
+// IsLocal reports whether host refers to this machine.
+func IsLocal(host string) bool {
+	h := strings.ToLower(strings.TrimSpace(host))
+	return h == "" || h == "local" || h == "localhost" || h == "127.0.0.1"
+}
+
+// LoadInventory resolves --inventory: a file (one host per line, Ansible INI
+// style [groups] and host vars are tolerated) or an inline spec understood by
+// pcp.ExpandHosts ("node1,node2", "kube-worker[1:5]").
+func LoadInventory(spec string) ([]string, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return []string{"local"}, nil
+	}
+	st, err := os.Stat(spec)
+	if err != nil || st.IsDir() {
+		return pcp.ExpandHosts(spec)
+	}
+	f, err := os.Open(spec)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var terms []string
+	skip := false
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			// [group:vars] holds variables, [group:children] holds group names.
+			skip = strings.Contains(line, ":")
+			continue
+		}
+		if skip {
+			continue
+		}
+		terms = append(terms, strings.Fields(line)[0])
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return pcp.ExpandHosts(strings.Join(terms, ","))
+}
+
+// Collect runs the artifacts on every host and returns the manifest with
+// outputs attached; use WriteBundle to persist it.
+func Collect(ctx context.Context, opts Options) *Manifest {
+	hosts := opts.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{"local"}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = 8
+	}
+	if len(opts.Artifacts) == 0 {
+		opts.Artifacts = DefaultArtifacts(0)
+	}
+	m := &Manifest{Version: ManifestVersion, CreatedAt: time.Now(), Collector: "kiki-ai-shell health-collect"}
+	m.Hosts = make([]HostResult, len(hosts))
+	sem := make(chan struct{}, opts.Parallel)
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			m.Hosts[i] = collectHost(ctx, h, opts)
+		}(i, h)
+	}
+	wg.Wait()
+	return m
+}
+
+func collectHost(ctx context.Context, host string, opts Options) (hr HostResult) {
+	hr = HostResult{Host: host, Remote: !IsLocal(host), Started: time.Now(), outputs: map[string][]byte{}}
+	defer func() { hr.Finished = time.Now() }()
+	progress := func(msg string) {
+		if opts.Progress != nil {
+			opts.Progress(host, msg)
+		}
+	}
+
+	if hr.Remote {
+		cctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+		_, code, err := runOn(cctx, host, "true", opts)
+		cancel()
+		if err != nil || code != 0 {
+			hr.Error = fmt.Sprintf("ssh failed (exit=%d): %v", code, err)
+			progress(hr.Error)
+			return hr
+		}
+	}
+	dir := safeName(host)
+	for _, a := range opts.Artifacts {
+		if ctx.Err() != nil {
+			break
+		}
+		progress(a.Name)
+		start := time.Now()
+		actx, cancel := context.WithTimeout(ctx, opts.Timeout)
+		out, code, err := runOn(actx, host, a.Cmd, opts)
+		timedOut := actx.Err() == context.DeadlineExceeded
+		cancel()
+
+		ar := ArtifactResult{Name: a.Name, Category: a.Category, Command: a.Cmd, ExitCode: code, DurationMs: time.Since(start).Milliseconds()}
+		switch {
+		case timedOut:
+			ar.Error = fmt.Sprintf("timeout after %s", opts.Timeout)
+		case code == exitNotPresent:
+			ar.Skipped = true
+			hr.Artifacts = append(hr.Artifacts, ar)
+			continue
+		case err != nil && code < 0:
+			ar.Error = err.Error()
+		}
+		if len(out) > maxArtifactBytes {
+			out = out[len(out)-maxArtifactBytes:]
+			ar.Truncated = true
+		}
+		if len(out) > 0 {
+			sum := sha256.Sum256(out)
+			ar.Path = dir + "/" + a.Name + ".txt"
+			ar.Bytes = len(out)
+			ar.SHA256 = hex.EncodeToString(sum[:])
+			hr.outputs[ar.Path] = out
+		}
+		hr.Artifacts = append(hr.Artifacts, ar)
+	}
+	return hr
+}
+
+// runOn runs cmd on host and returns combined output and exit code (-1 if the
+// command could not be started).
+func runOn(ctx context.Context, host, cmd string, opts Options) ([]byte, int, error) {
+	var c *exec.Cmd
+	if IsLocal(host) {
+		c = exec.CommandContext(ctx, "bash", "-c", cmd)
+		c.Env = append(os.Environ(), "LC_ALL=C")
+	} else {
+		target := host
+		if opts.SSHUser != "" && !strings.Contains(host, "@") {
+			target = opts.SSHUser + "@" + host
+		}
+		args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+		args = append(args, opts.SSHOpts...)
+		args = append(args, target, "--", "LC_ALL=C bash -c "+shellQuote(cmd))
+		c = exec.CommandContext(ctx, "ssh", args...)
+	}
+	var buf bytes.Buffer
+	c.Stdout = &buf
+	c.Stderr = &buf
+	err := c.Run()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		return buf.Bytes(), ee.ExitCode(), err
+	}
+	if err != nil {
+		return buf.Bytes(), -1, err
+	}
+	return buf.Bytes(), 0, nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// safeName makes a host usable as a directory name inside the bundle.
+func safeName(host string) string {
+	if IsLocal(host) {
+		if h, err := os.Hostname(); err == nil && h != "" {
+			host = h
+		} else {
+			host = "local"
+		}
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, host)
+}
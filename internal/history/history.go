@@ -2,12 +2,14 @@ package history
 
 import (
 	"encoding/json"
+	"net/url"
 	"os"
-	"path/filepath"
 	"strings"
 )
 
 type Record struct {
+	ID           string            `json:"id,omitempty"`   // content hash (see Key); set by Append
+	Host         string            `json:"host,omitempty"` // machine the record was made on
 	Time         string            `json:"time"`
 	Endpoint     string            `json:"endpoint"`
 	Profile      string            `json:"profile"`
@@ -24,18 +26,37 @@ type Record struct {
 	ResponsePrev string            `json:"response_preview,omitempty"`
 }
 
-func Append(path string, rec Record) {
-	_ = os.MkdirAll(filepath.Dir(path), 0o755)
-	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
-	if err != nil {
-		return
+// Append adds rec to the history file, stamped with this host and its
+// content hash, and returns the record as written.
+func Append(path string, rec Record) (Record, error) {
+	rec.Endpoint = redactURL(rec.Endpoint)
+	if rec.Host == "" {
+		rec.Host = Hostname()
 	}
-	defer f.Close()
+	rec.ID = rec.Hash()
 	b, err := json.Marshal(rec)
 	if err != nil {
-		return
+		return rec, err
+	}
+	return rec, withLock(path, func() error {
+		return appendLines(path, [][]byte{b})
+	})
+}
+
+func appendLines(path string, lines [][]byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	var buf []byte
+	for _, b := range lines {
+		buf = append(append(buf, b...), '\n')
+	}
+	if _, err := f.Write(buf); err != nil {
+		f.Close()
+		return err
 	}
-	_, _ = f.Write(append(b, '\n'))
+	return f.Close()
 }
 
 func ReadAll(path string) ([]Record, error) {
@@ -60,3 +81,15 @@ func ReadAll(path string) ([]Record, error) {
 	}
 	return out, nil
 }
+
+// redactURL drops credentials embedded in an endpoint URL (user:pass@host)
+// so they never reach the history file. Auth headers are not part of a
+// Record at all.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,231 @@
+package history
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+var (
+	hostOnce sync.Once
+	hostName string
+)
+
+// Hostname is the short host name records made here are stamped with.
+func Hostname() string {
+	hostOnce.Do(func() {
+		h, _ := os.Hostname()
+		hostName, _, _ = strings.Cut(h, ".")
+		if hostName == "" {
+			hostName = "unknown"
+		}
+	})
+	return hostName
+}
+
+// Hash identifies a record by what was asked, where and when, so the same
+// record pulled back from agentd (or from another bastion) is recognised.
+// The time is compared in UTC; options such as temperature are left out.
+func (r Record) Hash() string {
+	t := r.Time
+	if pt, err := time.Parse(time.RFC3339, r.Time); err == nil {
+		t = pt.UTC().Format(time.RFC3339)
+	}
+	h := sha256.New()
+	for _, s := range []string{t, r.Host, r.Cwd, r.Profile, r.Model, r.Prompt, r.ResponsePrev} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:12])
+}
+
+// Key is the record's ID, or its hash for records written before IDs.
+func (r Record) Key() string {
+	if r.ID != "" {
+		return r.ID
+	}
+	return r.Hash()
+}
+
+// QueuePath is where records waiting to be pushed to agentd are kept
+// (next to the history file, so a failed push survives a restart).
+func QueuePath(path string) string { return path + ".queue" }
+
+// Enqueue adds records to the push queue.
+func Enqueue(path string, recs ...Record) error {
+	lines := make([][]byte, 0, len(recs))
+	for _, r := range recs {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		lines = append(lines, b)
+	}
+	return withLock(path, func() error {
+		return appendLines(QueuePath(path), lines)
+	})
+}
+
+// Pending returns the queued records, oldest first.
+func Pending(path string) ([]Record, error) {
+	var out []Record
+	err := withLock(path, func() error {
+		var err error
+		out, err = ReadAll(QueuePath(path))
+		return err
+	})
+	return out, err
+}
+
+// Dequeue drops sent records from the queue; records queued meanwhile stay.
+func Dequeue(path string, sent []Record) error {
+	done := make(map[string]bool, len(sent))
+	for _, r := range sent {
+		done[r.Key()] = true
+	}
+	return withLock(path, func() error {
+		lines, err := readLines(QueuePath(path))
+		if err != nil {
+			return err
+		}
+		keep := lines[:0]
+		for _, l := range lines {
+			if l.rec == nil || !done[l.rec.Key()] {
+				keep = append(keep, l)
+			}
+		}
+		if len(keep) == 0 {
+			err := os.Remove(QueuePath(path))
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		return rewrite(QueuePath(path), keep)
+	})
+}
+
+// Merge adds the records not already in the history file (by Key) and
+// rewrites it in time order, so pulled records land among the local ones.
+// Lines that do not parse are kept where they were. It returns the records
+// added.
+func Merge(path string, recs []Record) ([]Record, error) {
+	var added []Record
+	err := withLock(path, func() error {
+		lines, err := readLines(path)
+		if err != nil {
+			return err
+		}
+		seen := make(map[string]bool, len(lines)+len(recs))
+		for _, l := range lines {
+			if l.rec != nil {
+				seen[l.rec.Key()] = true
+				seen[l.rec.Hash()] = true
+			}
+		}
+		for _, r := range recs {
+			if seen[r.Key()] || seen[r.Hash()] {
+				continue
+			}
+			seen[r.Key()] = true
+			r.Endpoint = redactURL(r.Endpoint)
+			if r.ID == "" {
+				r.ID = r.Hash()
+			}
+			added = append(added, r)
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		for i := range added {
+			b, err := json.Marshal(added[i])
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line{raw: b, rec: &added[i], time: parseTime(added[i].Time)})
+		}
+		sort.SliceStable(lines, func(i, j int) bool { return lines[i].time.Before(lines[j].time) })
+		return rewrite(path, lines)
+	})
+	return added, err
+}
+
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+// line is one line of a history or queue file. rec is nil for a line that
+// does not parse; such a line takes the time of the line before it, so a
+// time-order sort leaves it in place.
+type line struct {
+	raw  []byte
+	rec  *Record
+	time time.Time
+}
+
+// readLines is ReadAll keeping the raw lines, including unparseable ones,
+// so rewriting a file never drops what it could not read.
+func readLines(path string) ([]line, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []line
+	var prev time.Time
+	for _, raw := range bytes.Split(b, []byte("\n")) {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+		l := line{raw: raw, time: prev}
+		var r Record
+		if json.Unmarshal(raw, &r) == nil {
+			l.rec, l.time = &r, parseTime(r.Time)
+		}
+		out = append(out, l)
+		prev = l.time
+	}
+	return out, nil
+}
+
+// rewrite replaces path with lines (mode 0600) atomically.
+func rewrite(path string, lines []line) error {
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.Write(l.raw)
+		buf.WriteByte('\n')
+	}
+	return fsutil.WritePrivate(path, buf.Bytes())
+}
+
+// withLock serialises writers of the history file and its queue across
+// kiki-ai-shell processes (flock on <path>.lock).
+func withLock(path string, fn func() error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return err
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return fn()
+}
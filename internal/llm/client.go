@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,7 +15,7 @@ import (
 	"time"
 )
 
-func makeHTTPClient(timeoutSec int) *http.Client {
+func makeHTTPClient(timeoutSec int, tlsConfig *tls.Config) *http.Client {
 	tr := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -25,6 +26,7 @@ func makeHTTPClient(timeoutSec int) *http.Client {
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
 	}
 	if timeoutSec <= 0 {
 		return &http.Client{Transport: tr}
@@ -32,64 +34,133 @@ func makeHTTPClient(timeoutSec int) *http.Client {
 	return &http.Client{Timeout: time.Duration(timeoutSec) * time.Second, Transport: tr}
 }
 
-func DoNonStream(ctx context.Context, endpoint string, timeoutSec int, reqPayload ChatRequest) (string, error) {
-	client := makeHTTPClient(timeoutSec)
-	body, err := json.Marshal(reqPayload)
+// client returns an HTTP client for the endpoint's TLS settings.
+func (e Endpoint) client(timeoutSec int) (*http.Client, error) {
+	tc, err := e.TLS.Config()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	return makeHTTPClient(timeoutSec, tc), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+// newRequest builds a request to endpoint.URL with the endpoint headers.
+func newRequest(ctx context.Context, method string, endpoint Endpoint, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range endpoint.Header {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func DoNonStream(ctx context.Context, endpoint Endpoint, timeoutSec int, reqPayload ChatRequest) (string, error) {
+	cr, err := DoChat(ctx, endpoint, timeoutSec, reqPayload)
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return cr.Content(), nil
+}
+
+// DoChat sends a non-streaming chat completion and returns the whole
+// response (including usage when the server reports it).
+func DoChat(ctx context.Context, endpoint Endpoint, timeoutSec int, reqPayload ChatRequest) (*ChatResponse, error) {
+	client, err := endpoint.client(timeoutSec)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(reqPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	raw, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if resp.StatusCode >= 400 {
 		var ew APIErrorWrapper
 		if json.Unmarshal(raw, &ew) == nil && ew.Error.Message != "" {
-			return "", fmt.Errorf("API Error: %s", ew.Error.Message)
+			return nil, fmt.Errorf("API Error: %s", ew.Error.Message)
 		}
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(raw))
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(raw))
 	}
 
 	var cr ChatResponse
 	if err := json.Unmarshal(raw, &cr); err != nil {
-		return "", fmt.Errorf("응답 파싱 실패: %w", err)
+		return nil, fmt.Errorf("응답 파싱 실패: %w", err)
+	}
+	if len(cr.Choices) == 0 {
+		return nil, errors.New("choices가 비어있음")
 	}
+	return &cr, nil
+}
+
+// Content returns the text of the first choice.
+func (cr *ChatResponse) Content() string {
 	if len(cr.Choices) == 0 {
-		return "", errors.New("choices가 비어있음")
+		return ""
 	}
 	content := strings.TrimSpace(cr.Choices[0].Message.Content)
 	if content == "" {
 		content = strings.TrimSpace(cr.Choices[0].Text)
 	}
-	return content, nil
+	return content
+}
+
+// Ping issues a GET to endpoint.URL and returns the HTTP status. Any
+// response counts as reachable; the caller decides what a non-2xx status
+// (401 without credentials, 404 without the route) means.
+func Ping(ctx context.Context, endpoint Endpoint, timeoutSec int) (int, error) {
+	client, err := endpoint.client(timeoutSec)
+	if err != nil {
+		return 0, err
+	}
+	req, err := newRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, nil
 }
 
-func DoStream(ctx context.Context, endpoint string, reqPayload ChatRequest, capLimit int, onText func(string)) (string, error) {
-	client := makeHTTPClient(0)
+func DoStream(ctx context.Context, endpoint Endpoint, reqPayload ChatRequest, capLimit int, onText func(string)) (string, error) {
+	client, err := endpoint.client(0)
+	if err != nil {
+		return "", err
+	}
 	body, err := json.Marshal(reqPayload)
 	if err != nil {
 		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	req, err := newRequest(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
 		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures the HTTPS client of an endpoint. The zero value
+// (or nil) keeps Go's defaults: system roots, TLS 1.2+, SNI from the URL.
+type TLSOptions struct {
+	CAFile             string // PEM bundle trusted in addition to the system roots
+	CertFile           string // client certificate for mTLS
+	KeyFile            string
+	ServerName         string // overrides SNI and the name verified
+	InsecureSkipVerify bool
+	MinVersion         string // "1.0" .. "1.3"
+}
+
+// Config builds the tls.Config, reading the CA bundle and client key pair.
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	if o == nil {
+		return nil, nil
+	}
+	c := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify, // opt-in per endpoint; the shell warns loudly
+	}
+	if o.MinVersion != "" {
+		v, err := ParseTLSVersion(o.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+		c.MinVersion = v
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls ca_file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca_file %s: no PEM certificates found", o.CAFile)
+		}
+		c.RootCAs = pool
+	}
+	if o.CertFile != "" || o.KeyFile != "" {
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("tls: cert_file and key_file go together")
+		}
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls client certificate: %w", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+// ParseTLSVersion maps "1.2" (or "tls1.2") to tls.VersionTLS12 etc.
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(s)), "tls") {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q (1.0, 1.1, 1.2, 1.3)", s)
+}
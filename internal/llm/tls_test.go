@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a throwaway CA with a server certificate for 127.0.0.1 and a
+// client certificate, written as PEM files into a temp dir.
+type testPKI struct {
+	pool       *x509.CertPool
+	server     tls.Certificate
+	caFile     string
+	clientCert string
+	clientKey  string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+	caKey := newKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kiki test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testPKI{pool: x509.NewCertPool(), caFile: filepath.Join(dir, "ca.pem")}
+	p.pool.AddCert(ca)
+	writePEM(t, p.caFile, "CERTIFICATE", caDER)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage, ips []net.IP) ([]byte, *ecdsa.PrivateKey) {
+		key := newKey(t)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  ips,
+		}, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der, key
+	}
+
+	der, key := issue(2, "127.0.0.1", x509.ExtKeyUsageServerAuth, []net.IP{net.IPv4(127, 0, 0, 1)})
+	p.server = tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	der, key = issue(3, "kiki client", x509.ExtKeyUsageClientAuth, nil)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.clientCert, p.clientKey = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writePEM(t, p.clientCert, "CERTIFICATE", der)
+	writePEM(t, p.clientKey, "EC PRIVATE KEY", keyDER)
+	return p
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startTLS serves 200 OK over TLS with the test server certificate; edit
+// adjusts the server's tls.Config before it starts.
+func startTLS(t *testing.T, p *testPKI, edit func(*tls.Config)) *httptest.Server {
+	t.Helper()
+	s := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	s.Config.ErrorLog = log.New(io.Discard, "", 0) // failed handshakes are expected
+	s.TLS = &tls.Config{Certificates: []tls.Certificate{p.server}}
+	if edit != nil {
+		edit(s.TLS)
+	}
+	s.StartTLS()
+	t.Cleanup(s.Close)
+	return s
+}
+
+func ping(url string, o *TLSOptions) error {
+	_, err := Ping(context.Background(), Endpoint{URL: url, TLS: o}, 5)
+	return err
+}
+
+func TestTLSCAFile(t *testing.T) {
+	p := newTestPKI(t)
+	s := startTLS(t, p, nil)
+	if err := ping(s.URL, nil); err == nil {
+		t.Fatal("untrusted server certificate was accepted without ca_file")
+	}
+	if err := ping(s.URL, &TLSOptions{CAFile: p.caFile}); err != nil {
+		t.Fatalf("ca_file: %v", err)
+	}
+}
+
+func TestTLSClientCertificate(t *testing.T) {
+	p := newTestPKI(t)
+	s := startTLS(t, p, func(c *tls.Config) {
+		c.ClientAuth = tls.RequireAndVerifyClientCert
+		c.ClientCAs = p.pool
+	})
+	if err := ping(s.URL, &TLSOptions{CAFile: p.caFile}); err == nil {
+		t.Fatal("server requiring a client certificate accepted a client without one")
+	}
+	o := &TLSOptions{CAFile: p.caFile, CertFile: p.clientCert, KeyFile: p.clientKey}
+	if err := ping(s.URL, o); err != nil {
+		t.Fatalf("mTLS: %v", err)
+	}
+}
+
+func TestTLSMinVersion(t *testing.T) {
+	p := newTestPKI(t)
+	s := startTLS(t, p, func(c *tls.Config) { c.MaxVersion = tls.VersionTLS12 })
+	if err := ping(s.URL, &TLSOptions{CAFile: p.caFile, MinVersion: "1.3"}); err == nil {
+		t.Fatal("min_version 1.3 connected to a TLS 1.2 server")
+	}
+	if err := ping(s.URL, &TLSOptions{CAFile: p.caFile, MinVersion: "1.2"}); err != nil {
+		t.Fatalf("min_version 1.2: %v", err)
+	}
+}
+
+func TestTLSInsecureSkipVerify(t *testing.T) {
+	p := newTestPKI(t)
+	s := startTLS(t, p, nil)
+	if err := ping(s.URL, &TLSOptions{InsecureSkipVerify: true}); err != nil {
+		t.Fatalf("insecure_skip_verify: %v", err)
+	}
+}
@@ -1,5 +1,16 @@
 package llm
 
+import "net/http"
+
+// Endpoint is a chat completions URL plus the headers every request to it
+// carries (Authorization, gateway headers) and its TLS settings. Header
+// holds resolved secrets, so never log or record it; record URL only.
+type Endpoint struct {
+	URL    string
+	Header http.Header
+	TLS    *TLSOptions
+}
+
 type ChatMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
@@ -20,6 +31,14 @@ type ChatResponse struct {
 		} `json:"message"`
 		Text string `json:"text"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage is the token accounting OpenAI-compatible servers return.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 type StreamChunk struct {
@@ -0,0 +1,171 @@
+package logs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter selects records. Zero values disable the corresponding condition.
+type Filter struct {
+	MaxSeverity Severity // keep records with Severity <= MaxSeverity
+	Since       time.Time
+	Until       time.Time
+	Grep        *regexp.Regexp
+	Units       []string // substring match on Unit (any)
+}
+
+// DefaultFilter keeps warnings and worse.
+func DefaultFilter() Filter { return Filter{MaxSeverity: SevWarning} }
+
+// Match reports whether rec passes the filter. Records without a timestamp are
+// kept by time conditions (we cannot tell), but still subject to the others.
+func (f Filter) Match(rec Record) bool {
+	if rec.Severity > f.MaxSeverity {
+		return false
+	}
+	if !rec.Time.IsZero() {
+		if !f.Since.IsZero() && rec.Time.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && rec.Time.After(f.Until) {
+			return false
+		}
+	}
+	if f.Grep != nil && !f.Grep.MatchString(rec.Message) && !f.Grep.MatchString(rec.Unit) {
+		return false
+	}
+	if len(f.Units) > 0 {
+		ok := false
+		for _, u := range f.Units {
+			if strings.Contains(rec.Unit, u) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns the records that pass f, in input order.
+func Apply(recs []Record, f Filter) []Record {
+	out := make([]Record, 0, len(recs)/4)
+	for _, r := range recs {
+		if f.Match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Describe summarizes the applied filter for reports and history.
+func (f Filter) Describe() string {
+	parts := []string{"level<=" + strings.ToLower(f.MaxSeverity.String())}
+	if !f.Since.IsZero() {
+		parts = append(parts, "since="+f.Since.Format(time.RFC3339))
+	}
+	if !f.Until.IsZero() {
+		parts = append(parts, "until="+f.Until.Format(time.RFC3339))
+	}
+	if f.Grep != nil {
+		parts = append(parts, "grep="+f.Grep.String())
+	}
+	if len(f.Units) > 0 {
+		parts = append(parts, "unit="+strings.Join(f.Units, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Overview renders counts for the whole input and for the kept records,
+// so the model knows how much was filtered out.
+func Overview(all, kept []Record, f Filter) string {
+	var b strings.Builder
+	first, last := timeSpan(all)
+	fmt.Fprintf(&b, "total=%d kept=%d filter=[%s]", len(all), len(kept), f.Describe())
+	if !first.IsZero() {
+		fmt.Fprintf(&b, " span=%s~%s", first.Format("2006-01-02 15:04:05"), last.Format("2006-01-02 15:04:05"))
+	}
+	b.WriteString("\nby severity:")
+	counts := map[Severity]int{}
+	for _, r := range all {
+		counts[r.Severity]++
+	}
+	for s := SevEmerg; s <= SevDebug; s++ {
+		if counts[s] > 0 {
+			fmt.Fprintf(&b, " %s=%d", s, counts[s])
+		}
+	}
+	if top := topUnits(kept, 8); top != "" {
+		b.WriteString("\ntop units (kept): ")
+		b.WriteString(top)
+	}
+	return b.String()
+}
+
+func timeSpan(recs []Record) (time.Time, time.Time) {
+	var first, last time.Time
+	for _, r := range recs {
+		if r.Time.IsZero() {
+			continue
+		}
+		if first.IsZero() || r.Time.Before(first) {
+			first = r.Time
+		}
+		if r.Time.After(last) {
+			last = r.Time
+		}
+	}
+	return first, last
+}
+
+func topUnits(recs []Record, n int) string {
+	counts := map[string]int{}
+	for _, r := range recs {
+		u := r.Unit
+		if u == "" {
+			u = "(none)"
+		}
+		counts[u]++
+	}
+	type kv struct {
+		k string
+		v int
+	}
+	list := make([]kv, 0, len(counts))
+	for k, v := range counts {
+		list = append(list, kv{k, v})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].v != list[j].v {
+			return list[i].v > list[j].v
+		}
+		return list[i].k < list[j].k
+	})
+	if len(list) > n {
+		list = list[:n]
+	}
+	parts := make([]string, 0, len(list))
+	for _, e := range list {
+		parts = append(parts, fmt.Sprintf("%s=%d", e.k, e.v))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Render returns the kept records, one per line, newest last. When maxLines > 0
+// only the last maxLines records are rendered.
+func Render(recs []Record, maxLines int) string {
+	if maxLines > 0 && len(recs) > maxLines {
+		recs = recs[len(recs)-maxLines:]
+	}
+	var b strings.Builder
+	for _, r := range recs {
+		b.WriteString(r.String())
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,302 @@
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats accepted by Parse. "auto" detects the format line by line, which also
+// copes with files that mix e.g. syslog lines and embedded klog messages.
+var Formats = []string{"auto", "syslog", "journal", "klog"}
+
+var (
+	// May  1 10:00:00 node1 kubelet[1234]: message   (optionally prefixed by <PRI>)
+	reRFC3164 = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2}\s+\d{1,2}\s\d{2}:\d{2}:\d{2})\s+(\S+)\s+([^\s:\[]+)(?:\[(\d+)\])?:\s?(.*)$`)
+	// 2024-05-01T10:00:00.123456+09:00 node1 kubelet[1234]: message   (rsyslog high precision)
+	reISO = regexp.MustCompile(`^(?:<(\d{1,3})>)?(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\s+(\S+)\s+([^\s:\[]+)(?:\[(\d+)\])?:\s?(.*)$`)
+	// <34>1 2024-05-01T10:00:00Z node1 app 1234 ID47 [sd] message   (RFC5424)
+	reRFC5424 = regexp.MustCompile(`^<(\d{1,3})>1\s+(\S+)\s+(\S+)\s+(\S+)\s+(\S+)\s+\S+\s+(?:-|(?:\[[^\]]*\])+)\s?(.*)$`)
+	// E0501 10:00:00.123456    1234 kubelet.go:123] message
+	reKlog = regexp.MustCompile(`^([IWEF])(\d{2})(\d{2})\s+(\d{2}:\d{2}:\d{2}(?:\.\d+)?)\s+(\d+)\s+([^\s\]]+:\d+)\]\s?(.*)$`)
+)
+
+// ParseFile parses a log file. maxBytes > 0 reads only the last maxBytes of it
+// (incidents are at the end of a log, not the beginning).
+func ParseFile(path, format string, maxBytes int64) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if maxBytes > 0 {
+		if st, err := f.Stat(); err == nil && st.Size() > maxBytes {
+			if _, err := f.Seek(st.Size()-maxBytes, io.SeekStart); err != nil {
+				return nil, err
+			}
+			br := bufio.NewReader(f)
+			_, _ = br.ReadString('\n') // drop the partial first line
+			r = br
+		}
+	}
+	return Parse(r, format)
+}
+
+// Parse reads r line by line and parses each line according to format.
+// Lines that do not match the format are kept as "plain" records so nothing is lost.
+func Parse(r io.Reader, format string) ([]Record, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if format == "" {
+		format = "auto"
+	}
+	if !validFormat(format) {
+		return nil, fmt.Errorf("unknown log format: %s (use %s)", format, strings.Join(Formats, "|"))
+	}
+	now := time.Now()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var out []Record
+	n := 0
+	for sc.Scan() {
+		n++
+		line := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rec := ParseLine(line, format, now)
+		rec.Line = n
+		rec.Raw = line
+		out = append(out, rec)
+	}
+	return out, sc.Err()
+}
+
+func validFormat(f string) bool {
+	for _, x := range Formats {
+		if x == f {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLine parses one line. now supplies the year for formats that omit it.
+func ParseLine(line, format string, now time.Time) Record {
+	try := func(fn func(string, time.Time) (Record, bool)) (Record, bool) { return fn(line, now) }
+	switch format {
+	case "journal":
+		if rec, ok := try(parseJournal); ok {
+			return rec
+		}
+	case "klog":
+		if rec, ok := try(parseKlog); ok {
+			return rec
+		}
+	case "syslog":
+		if rec, ok := try(parseSyslog); ok {
+			return rec
+		}
+	default:
+		for _, fn := range []func(string, time.Time) (Record, bool){parseJournal, parseKlog, parseSyslog} {
+			if rec, ok := try(fn); ok {
+				return rec
+			}
+		}
+	}
+	return Record{Format: "plain", Message: line, Severity: GuessSeverity(line)}
+}
+
+func parseSyslog(line string, now time.Time) (Record, bool) {
+	if m := reRFC5424.FindStringSubmatch(line); m != nil {
+		rec := Record{Format: "syslog", Host: nilDash(m[3]), Unit: nilDash(m[4]), PID: nilDash(m[5]), Message: m[6]}
+		rec.Time, _ = time.Parse(time.RFC3339Nano, m[2])
+		rec.Severity = priSeverity(m[1], rec.Message)
+		refineFromKlog(&rec)
+		return rec, true
+	}
+	if m := reISO.FindStringSubmatch(line); m != nil {
+		rec := Record{Format: "syslog", Host: m[3], Unit: m[4], PID: m[5], Message: m[6]}
+		rec.Time = parseISO(m[2])
+		rec.Severity = priSeverity(m[1], rec.Message)
+		refineFromKlog(&rec)
+		return rec, true
+	}
+	if m := reRFC3164.FindStringSubmatch(line); m != nil {
+		rec := Record{Format: "syslog", Host: m[3], Unit: m[4], PID: m[5], Message: m[6]}
+		rec.Time = parseStamp(m[2], now)
+		rec.Severity = priSeverity(m[1], rec.Message)
+		refineFromKlog(&rec)
+		return rec, true
+	}
+	return Record{}, false
+}
+
+func parseKlog(line string, now time.Time) (Record, bool) {
+	m := reKlog.FindStringSubmatch(line)
+	if m == nil {
+		return Record{}, false
+	}
+	rec := Record{Format: "klog", PID: m[5], Unit: m[6], Message: m[7], Severity: klogSeverity(m[1])}
+	mon, _ := strconv.Atoi(m[2])
+	day, _ := strconv.Atoi(m[3])
+	if t, err := time.ParseInLocation("15:04:05.999999", m[4], time.Local); err == nil {
+		rec.Time = fixYear(time.Date(now.Year(), time.Month(mon), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local), now)
+	}
+	return rec, true
+}
+
+// journalEntry is the subset of `journalctl -o json` fields we use.
+type journalEntry struct {
+	Realtime   string          `json:"__REALTIME_TIMESTAMP"`
+	Hostname   string          `json:"_HOSTNAME"`
+	Unit       string          `json:"_SYSTEMD_UNIT"`
+	Identifier string          `json:"SYSLOG_IDENTIFIER"`
+	PID        string          `json:"_PID"`
+	Priority   string          `json:"PRIORITY"`
+	Message    json.RawMessage `json:"MESSAGE"`
+}
+
+func parseJournal(line string, _ time.Time) (Record, bool) {
+	t := strings.TrimSpace(line)
+	if !strings.HasPrefix(t, "{") {
+		return Record{}, false
+	}
+	var e journalEntry
+	if json.Unmarshal([]byte(t), &e) != nil {
+		return Record{}, false
+	}
+	rec := Record{Format: "journal", Host: e.Hostname, PID: e.PID, Message: journalMessage(e.Message)}
+	rec.Unit = e.Unit
+	if rec.Unit == "" {
+		rec.Unit = e.Identifier
+	}
+	if us, err := strconv.ParseInt(e.Realtime, 10, 64); err == nil {
+		rec.Time = time.UnixMicro(us)
+	}
+	rec.Severity = SevInfo
+	if p, err := strconv.Atoi(e.Priority); err == nil && p >= 0 && p <= 7 {
+		rec.Severity = Severity(p)
+	} else {
+		rec.Severity = GuessSeverity(rec.Message)
+	}
+	refineFromKlog(&rec)
+	return rec, true
+}
+
+// journalMessage decodes MESSAGE, which journald exports as a byte array when
+// the payload is not valid UTF-8.
+func journalMessage(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var bs []byte
+	var ints []int
+	if json.Unmarshal(raw, &ints) == nil {
+		bs = make([]byte, len(ints))
+		for i, v := range ints {
+			bs[i] = byte(v)
+		}
+		return strings.ToValidUTF8(string(bs), "?")
+	}
+	return string(raw)
+}
+
+// refineFromKlog takes severity/source from a klog message embedded in a
+// syslog/journal record (kubelet, kube-proxy, ... log through journald).
+func refineFromKlog(rec *Record) {
+	m := reKlog.FindStringSubmatch(rec.Message)
+	if m == nil {
+		return
+	}
+	rec.Severity = klogSeverity(m[1])
+	rec.Message = m[6] + "] " + m[7]
+}
+
+func klogSeverity(c string) Severity {
+	switch c {
+	case "F":
+		return SevCrit
+	case "E":
+		return SevErr
+	case "W":
+		return SevWarning
+	}
+	return SevInfo
+}
+
+// priSeverity derives severity from a syslog PRI (facility*8+severity), or
+// guesses from the message when there is none.
+func priSeverity(pri, msg string) Severity {
+	if pri != "" {
+		if n, err := strconv.Atoi(pri); err == nil {
+			return Severity(n % 8)
+		}
+	}
+	return GuessSeverity(msg)
+}
+
+var (
+	reSevCrit = regexp.MustCompile(`(?i)\b(panic|fatal|critical|kernel bug|call trace|hung_task|soft lockup|hard lockup)\b`)
+	reSevErr  = regexp.MustCompile(`(?i)\b(error|err|errors|failed|failure|fail|exception|denied|refused|oom|out of memory|segfault|killed process|i/o error|unreachable)\b`)
+	reSevWarn = regexp.MustCompile(`(?i)\b(warn|warning|deprecated|timeout|timed out|retry|retrying|throttl\w*|degraded|not ready)\b`)
+)
+
+// GuessSeverity classifies free text by keywords (used when no PRI/level exists).
+func GuessSeverity(msg string) Severity {
+	switch {
+	case reSevCrit.MatchString(msg):
+		return SevCrit
+	case reSevErr.MatchString(msg):
+		return SevErr
+	case reSevWarn.MatchString(msg):
+		return SevWarning
+	}
+	return SevInfo
+}
+
+func parseStamp(s string, now time.Time) time.Time {
+	t, err := time.ParseInLocation("Jan _2 15:04:05", strings.Join(strings.Fields(s), " "), time.Local)
+	if err != nil {
+		t, err = time.ParseInLocation("Jan 2 15:04:05", strings.Join(strings.Fields(s), " "), time.Local)
+		if err != nil {
+			return time.Time{}
+		}
+	}
+	return fixYear(time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.Local), now)
+}
+
+// fixYear moves timestamps that would lie in the future (year-less formats
+// around new year) back by one year.
+func fixYear(t, now time.Time) time.Time {
+	if t.After(now.Add(24 * time.Hour)) {
+		return t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+func parseISO(s string) time.Time {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999-0700", "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func nilDash(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,99 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// now is late on 2024-05-02, so year-less stamps land in 2024.
+var now = time.Date(2024, 5, 2, 23, 0, 0, 0, time.Local)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		line   string
+		want   Record
+	}{
+		{
+			name: "rfc3164", format: "syslog",
+			line: "May  1 10:00:00 node1 sshd[1234]: error: connection refused",
+			want: Record{Format: "syslog", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local), Host: "node1", Unit: "sshd", PID: "1234", Severity: SevErr, Message: "error: connection refused"},
+		},
+		{
+			name: "rfc3164 with pri", format: "syslog",
+			line: "<28>May 12 10:00:00 node1 chronyd: clock stepped",
+			want: Record{Format: "syslog", Time: time.Date(2023, 5, 12, 10, 0, 0, 0, time.Local), Host: "node1", Unit: "chronyd", Severity: SevWarning, Message: "clock stepped"},
+		},
+		{
+			name: "iso", format: "syslog",
+			line: "2024-05-01T10:00:00.5+09:00 node1 kernel: Out of memory: Killed process 42",
+			want: Record{Format: "syslog", Time: time.Date(2024, 5, 1, 1, 0, 0, 5e8, time.UTC), Host: "node1", Unit: "kernel", Severity: SevErr, Message: "Out of memory: Killed process 42"},
+		},
+		{
+			name: "rfc5424", format: "syslog",
+			line: `<34>1 2024-05-01T10:00:00Z node1 app 99 ID47 [ex@1 a="b"] disk full`,
+			want: Record{Format: "syslog", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), Host: "node1", Unit: "app", PID: "99", Severity: SevCrit, Message: "disk full"},
+		},
+		{
+			name: "syslog with embedded klog", format: "syslog",
+			line: "May  1 10:00:00 node1 kubelet[7]: E0501 10:00:00.123456       7 pod_workers.go:1298] failed to sync pod",
+			want: Record{Format: "syslog", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local), Host: "node1", Unit: "kubelet", PID: "7", Severity: SevErr, Message: "pod_workers.go:1298] failed to sync pod"},
+		},
+		{
+			name: "klog", format: "klog",
+			line: "W0501 10:00:00.250000    1234 reflector.go:424] watch ended",
+			want: Record{Format: "klog", Time: time.Date(2024, 5, 1, 10, 0, 0, 25e7, time.Local), Unit: "reflector.go:424", PID: "1234", Severity: SevWarning, Message: "watch ended"},
+		},
+		{
+			name: "journal", format: "journal",
+			line: `{"__REALTIME_TIMESTAMP":"1714557600000000","_HOSTNAME":"node1","_SYSTEMD_UNIT":"containerd.service","_PID":"55","PRIORITY":"3","MESSAGE":"shim exited"}`,
+			want: Record{Format: "journal", Time: time.UnixMicro(1714557600000000), Host: "node1", Unit: "containerd.service", PID: "55", Severity: SevErr, Message: "shim exited"},
+		},
+		{
+			name: "journal byte-array message", format: "journal",
+			line: `{"SYSLOG_IDENTIFIER":"app","MESSAGE":[104,105,255]}`,
+			want: Record{Format: "journal", Unit: "app", Severity: SevInfo, Message: "hi?"},
+		},
+		{
+			name: "auto detects klog", format: "auto",
+			line: "E0501 10:00:00.000000       1 server.go:1] failed",
+			want: Record{Format: "klog", Time: time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local), Unit: "server.go:1", PID: "1", Severity: SevErr, Message: "failed"},
+		},
+		{
+			name: "plain", format: "syslog",
+			line: "panic: runtime error",
+			want: Record{Format: "plain", Severity: SevCrit, Message: "panic: runtime error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseLine(tt.line, tt.format, now)
+			if !got.Time.Equal(tt.want.Time) {
+				t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+			}
+			got.Time, tt.want.Time = time.Time{}, time.Time{}
+			if got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	in := "May  1 10:00:00 node1 sshd[1]: ok\n\r\n\nnot a log line\r\n"
+	recs, err := Parse(strings.NewReader(in), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("got %d records, want 2 (blank lines skipped)", len(recs))
+	}
+	if recs[1].Line != 4 || recs[1].Raw != "not a log line" || recs[1].Format != "plain" {
+		t.Errorf("second record = %+v", recs[1])
+	}
+	if _, err := Parse(strings.NewReader(in), "apache"); err == nil {
+		t.Error("unknown format was accepted")
+	}
+}
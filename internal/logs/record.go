@@ -0,0 +1,88 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Severity follows syslog priorities (lower is more severe).
+type Severity int
+
+const (
+	SevEmerg Severity = iota
+	SevAlert
+	SevCrit
+	SevErr
+	SevWarning
+	SevNotice
+	SevInfo
+	SevDebug
+)
+
+var sevNames = []string{"EMERG", "ALERT", "CRIT", "ERR", "WARN", "NOTICE", "INFO", "DEBUG"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(sevNames) {
+		return "INFO"
+	}
+	return sevNames[s]
+}
+
+// ParseSeverity accepts names (err, error, warning, warn, info, ...) or 0-7.
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch s {
+	case "0", "emerg", "emergency", "panic":
+		return SevEmerg, nil
+	case "1", "alert":
+		return SevAlert, nil
+	case "2", "crit", "critical", "fatal":
+		return SevCrit, nil
+	case "3", "err", "error":
+		return SevErr, nil
+	case "4", "warn", "warning":
+		return SevWarning, nil
+	case "5", "notice":
+		return SevNotice, nil
+	case "6", "info":
+		return SevInfo, nil
+	case "7", "debug", "all":
+		return SevDebug, nil
+	}
+	return SevInfo, fmt.Errorf("unknown level: %s", s)
+}
+
+// Record is one parsed log entry.
+type Record struct {
+	Time     time.Time // zero if the line had no parsable timestamp
+	Host     string
+	Unit     string // systemd unit, syslog identifier or klog source file
+	PID      string
+	Severity Severity
+	Message  string
+	Format   string // "syslog" | "journal" | "klog" | "plain"
+	Line     int    // 1-based line number in the input
+	Raw      string // original line
+}
+
+// String renders a compact single line suited for LLM prompts.
+func (r Record) String() string {
+	var b strings.Builder
+	if !r.Time.IsZero() {
+		b.WriteString(r.Time.Format("2006-01-02T15:04:05"))
+		b.WriteString(" ")
+	}
+	if r.Host != "" {
+		b.WriteString(r.Host)
+		b.WriteString(" ")
+	}
+	if r.Unit != "" {
+		b.WriteString(r.Unit)
+		b.WriteString(" ")
+	}
+	b.WriteString(r.Severity.String())
+	b.WriteString(" ")
+	b.WriteString(r.Message)
+	return b.String()
+}
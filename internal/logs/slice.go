@@ -0,0 +1,90 @@
+package logs
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sliceScanBytes bounds how much of a file ReadSlice looks at. Incidents are at
+// the end of a log, so the window is taken from the end.
+const sliceScanBytes = 64 << 20
+
+// Slice selects the interesting part of a file for attachment: records that
+// pass Filter, then only the last Tail of them.
+type Slice struct {
+	Filter Filter
+	Tail   int // keep the last N lines (0 = all)
+}
+
+// Empty reports whether the slice selects the whole file.
+func (s Slice) Empty() bool {
+	f := s.Filter
+	return s.Tail <= 0 && f.MaxSeverity >= SevDebug && f.Since.IsZero() && f.Until.IsZero() && f.Grep == nil && len(f.Units) == 0
+}
+
+// Describe summarizes the slice, e.g. "level<=err since=... tail=500".
+func (s Slice) Describe() string {
+	var parts []string
+	if d := s.Filter.Describe(); d != "level<=debug" {
+		parts = append(parts, strings.TrimPrefix(d, "level<=debug "))
+	}
+	if s.Tail > 0 {
+		parts = append(parts, "tail="+strconv.Itoa(s.Tail))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ReadSlice returns the original lines of path selected by s, oldest first,
+// and the number of lines scanned. Without severity/time/grep/unit
+// conditions the file is not parsed and only the tail is cut.
+func ReadSlice(path string, s Slice) (string, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if st, err := f.Stat(); err == nil && st.Size() > sliceScanBytes {
+		if _, err := f.Seek(st.Size()-sliceScanBytes, io.SeekStart); err != nil {
+			return "", 0, err
+		}
+		br := bufio.NewReader(f)
+		_, _ = br.ReadString('\n')
+		r = br
+	}
+
+	var lines []string
+	var scanned int
+	if (Slice{Filter: s.Filter}).Empty() {
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			scanned++
+			lines = append(lines, sc.Text())
+			if s.Tail > 0 && len(lines) > 2*s.Tail {
+				lines = append(lines[:0], lines[len(lines)-s.Tail:]...)
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return "", scanned, err
+		}
+	} else {
+		recs, err := Parse(r, "auto")
+		if err != nil {
+			return "", 0, err
+		}
+		scanned = len(recs)
+		for _, rec := range recs {
+			if s.Filter.Match(rec) {
+				lines = append(lines, rec.Raw)
+			}
+		}
+	}
+	if s.Tail > 0 && len(lines) > s.Tail {
+		lines = lines[len(lines)-s.Tail:]
+	}
+	return strings.Join(lines, "\n"), scanned, nil
+}
@@ -0,0 +1,305 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Wildcard marks a variable position in a template.
+const Wildcard = "<*>"
+
+// Template is a cluster of log messages that share the same constant tokens.
+type Template struct {
+	ID       int
+	Unit     string
+	Tokens   []string // constant tokens, Wildcard for variable positions
+	Count    int
+	Severity Severity // most severe record seen
+	First    time.Time
+	Last     time.Time
+	Params   map[int][]string // wildcard position -> up to maxExamples distinct values
+}
+
+// Text returns the template as a single line. Masked positions that only ever
+// saw one value (e.g. "EXT4-fs") are shown as that value.
+func (t *Template) Text() string {
+	out := make([]string, len(t.Tokens))
+	for i, tok := range t.Tokens {
+		if t.constant(i) {
+			tok = t.Params[i][0]
+		}
+		out[i] = tok
+	}
+	return strings.Join(out, " ")
+}
+
+func (t *Template) constant(i int) bool {
+	return t.Tokens[i] == Wildcard && len(t.Params[i]) == 1
+}
+
+// Miner clusters messages into templates, Drain-style: records are routed by
+// unit, token count and leading tokens to a small bucket of candidate templates,
+// and joined to the most similar one when enough tokens agree.
+type Miner struct {
+	SimThreshold float64 // fraction of matching tokens needed to join a template
+	Depth        int     // leading tokens used for routing
+	MaxChildren  int     // routing keys per level before falling back to Wildcard
+
+	buckets  map[string][]*Template
+	children map[string]int
+	all      []*Template
+	total    int
+}
+
+const (
+	maxExamples  = 3
+	maxTokens    = 64
+	maxTokenLen  = 80
+	defaultSim   = 0.5
+	defaultDepth = 2
+)
+
+// NewMiner returns a miner with Drain's usual defaults.
+func NewMiner() *Miner {
+	return &Miner{
+		SimThreshold: defaultSim,
+		Depth:        defaultDepth,
+		MaxChildren:  100,
+		buckets:      map[string][]*Template{},
+		children:     map[string]int{},
+	}
+}
+
+// Add assigns rec to a template, creating one when nothing is similar enough.
+func (m *Miner) Add(rec Record) *Template {
+	m.total++
+	toks := tokenize(rec.Message)
+	key := m.route(rec.Unit, toks)
+
+	var best *Template
+	bestSim, bestWild := -1.0, 0
+	mtoks := masked(toks)
+	for _, t := range m.buckets[key] {
+		if s, w := similarity(t.Tokens, mtoks); s > bestSim || (s == bestSim && w > bestWild) {
+			best, bestSim, bestWild = t, s, w
+		}
+	}
+	if best == nil || bestSim < m.SimThreshold {
+		best = &Template{ID: len(m.all) + 1, Unit: rec.Unit, Tokens: masked(toks), Severity: rec.Severity, Params: map[int][]string{}}
+		for i, tok := range toks {
+			if best.Tokens[i] == Wildcard {
+				best.addParam(i, tok)
+			}
+		}
+		m.buckets[key] = append(m.buckets[key], best)
+		m.all = append(m.all, best)
+	} else {
+		best.merge(toks)
+	}
+	best.Count++
+	if rec.Severity < best.Severity {
+		best.Severity = rec.Severity
+	}
+	if !rec.Time.IsZero() {
+		if best.First.IsZero() || rec.Time.Before(best.First) {
+			best.First = rec.Time
+		}
+		if rec.Time.After(best.Last) {
+			best.Last = rec.Time
+		}
+	}
+	return best
+}
+
+// route builds the bucket key: unit, token count, then up to Depth leading
+// tokens. Tokens with digits route as Wildcard, and a level that already has
+// MaxChildren distinct keys sends new tokens to Wildcard too.
+func (m *Miner) route(unit string, toks []string) string {
+	key := unit + "\x00" + fmt.Sprint(len(toks))
+	for i := 0; i < m.Depth && i < len(toks); i++ {
+		tok := toks[i]
+		if hasDigit(tok) {
+			tok = Wildcard
+		}
+		next := key + "\x00" + tok
+		if _, ok := m.children[next]; !ok && tok != Wildcard {
+			if m.children[key] >= m.MaxChildren {
+				next = key + "\x00" + Wildcard
+			} else {
+				m.children[key]++
+				m.children[next] = 0
+			}
+		}
+		key = next
+	}
+	return key
+}
+
+func (t *Template) merge(toks []string) {
+	for i, tok := range toks {
+		if t.Tokens[i] == tok {
+			continue
+		}
+		if t.Tokens[i] != Wildcard {
+			t.addParam(i, t.Tokens[i])
+			t.Tokens[i] = Wildcard
+		}
+		t.addParam(i, tok)
+	}
+}
+
+func (t *Template) addParam(i int, v string) {
+	vals := t.Params[i]
+	if len(vals) >= maxExamples {
+		return
+	}
+	for _, x := range vals {
+		if x == v {
+			return
+		}
+	}
+	t.Params[i] = append(vals, v)
+}
+
+// Templates returns the templates, most severe first, then most frequent.
+func (m *Miner) Templates() []*Template {
+	out := append([]*Template(nil), m.all...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Severity != out[j].Severity {
+			return out[i].Severity < out[j].Severity
+		}
+		return out[i].Count > out[j].Count
+	})
+	return out
+}
+
+// Total is the number of records added.
+func (m *Miner) Total() int { return m.total }
+
+// Mine clusters recs into templates.
+func Mine(recs []Record) *Miner {
+	m := NewMiner()
+	for _, r := range recs {
+		m.Add(r)
+	}
+	return m
+}
+
+// RenderTemplates renders at most max templates (0 = all), one per line:
+//
+//	#3 ERR x4120 kubelet 05-01 10:00:01~05-01 10:59:58 | failed to sync pod <*> | $5=a,b,c
+func (m *Miner) RenderTemplates(max int) string {
+	tpls := m.Templates()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d templates from %d records", len(tpls), m.total)
+	if max > 0 && len(tpls) > max {
+		fmt.Fprintf(&b, " (showing top %d)", max)
+		tpls = tpls[:max]
+	}
+	b.WriteString("\n")
+	for _, t := range tpls {
+		fmt.Fprintf(&b, "#%d %s x%d", t.ID, t.Severity, t.Count)
+		if t.Unit != "" {
+			b.WriteString(" " + t.Unit)
+		}
+		if !t.First.IsZero() {
+			if t.First.Equal(t.Last) {
+				b.WriteString(" " + t.First.Format("01-02 15:04:05"))
+			} else {
+				b.WriteString(" " + t.First.Format("01-02 15:04:05") + "~" + t.Last.Format("01-02 15:04:05"))
+			}
+		}
+		b.WriteString(" | ")
+		b.WriteString(t.Text())
+		if ex := t.examples(); ex != "" {
+			b.WriteString(" | ")
+			b.WriteString(ex)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (t *Template) examples() string {
+	pos := make([]int, 0, len(t.Params))
+	for i := range t.Params {
+		if i < len(t.Tokens) && t.Tokens[i] == Wildcard && !t.constant(i) {
+			pos = append(pos, i)
+		}
+	}
+	sort.Ints(pos)
+	parts := make([]string, 0, len(pos))
+	for n, i := range pos {
+		if n >= 4 {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, fmt.Sprintf("$%d=%s", i+1, strings.Join(t.Params[i], ",")))
+	}
+	return strings.Join(parts, " ")
+}
+
+// tokenize splits on whitespace, caps the number and length of tokens so a
+// single pathological line cannot dominate memory or the prompt.
+func tokenize(msg string) []string {
+	toks := strings.Fields(msg)
+	if len(toks) > maxTokens {
+		toks = append(toks[:maxTokens-1], strings.Join(toks[maxTokens-1:], " "))
+	}
+	for i, t := range toks {
+		if len(t) > maxTokenLen {
+			toks[i] = strings.ToValidUTF8(t[:maxTokenLen], "")
+		}
+	}
+	return toks
+}
+
+// masked returns toks with numeric-looking tokens (ids, addresses, durations,
+// counters) replaced by Wildcard, which is what new templates start from.
+func masked(toks []string) []string {
+	out := make([]string, len(toks))
+	for i, t := range toks {
+		if hasDigit(t) {
+			out[i] = Wildcard
+		} else {
+			out[i] = t
+		}
+	}
+	return out
+}
+
+// similarity is the fraction of positions where the template and the masked
+// message have the same token, and the number of template wildcards facing a
+// different token. As in Drain, a wildcard only matches a masked (numeric)
+// token, so a template generalised to <*> does not absorb unrelated lines;
+// the wildcard count breaks ties between equally similar templates.
+func similarity(tpl, toks []string) (float64, int) {
+	if len(tpl) != len(toks) {
+		return 0, 0
+	}
+	if len(tpl) == 0 {
+		return 1, 0
+	}
+	same, wild := 0, 0
+	for i := range tpl {
+		switch {
+		case tpl[i] == toks[i]:
+			same++
+		case tpl[i] == Wildcard:
+			wild++
+		}
+	}
+	return float64(same) / float64(len(tpl)), wild
+}
+
+func hasDigit(s string) bool {
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMine(t *testing.T) {
+	tests := []struct {
+		name string
+		msgs []string
+		want []string // template texts, in ID order
+	}{
+		{
+			name: "numbers are masked from the start",
+			msgs: []string{"took 12 ms", "took 345 ms"},
+			want: []string{"took <*> ms"},
+		},
+		{
+			name: "differing word becomes a wildcard",
+			msgs: []string{"session opened for alice", "session opened for bob", "session opened for carol"},
+			want: []string{"session opened for <*>"},
+		},
+		{
+			// Routing uses the leading tokens, so all of these share a bucket.
+			name: "wildcards do not absorb unrelated lines",
+			msgs: []string{"kubelet sync pod web in default", "kubelet sync job api in default", "kubelet sync stopped after too long"},
+			want: []string{"kubelet sync <*> <*> in default", "kubelet sync stopped after too long"},
+		},
+		{
+			name: "different lengths never merge",
+			msgs: []string{"connection reset", "connection reset by peer"},
+			want: []string{"connection reset", "connection reset by peer"},
+		},
+		{
+			name: "below the threshold starts a new template",
+			msgs: []string{"volume mount ok for web", "volume mount failed with timeout"},
+			want: []string{"volume mount ok for web", "volume mount failed with timeout"},
+		},
+		{
+			name: "a single seen value is shown as is",
+			msgs: []string{"EXT4-fs error on sda1", "EXT4-fs error on sda1"},
+			want: []string{"EXT4-fs error on sda1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := make([]Record, len(tt.msgs))
+			for i, m := range tt.msgs {
+				recs[i] = Record{Message: m, Severity: SevInfo}
+			}
+			m := Mine(recs)
+			var got []string
+			for _, tpl := range m.all {
+				got = append(got, tpl.Text())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("templates:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if m.Total() != len(tt.msgs) {
+				t.Errorf("Total = %d, want %d", m.Total(), len(tt.msgs))
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		tpl, toks string
+		sim       float64
+		wild      int
+	}{
+		{"a b c d", "a b c d", 1, 0},
+		{"a <*> c d", "a x c d", 0.75, 1},
+		{"a <*> c d", "a <*> c d", 1, 0},
+		{"<*> <*> c d", "x y c d", 0.5, 2},
+		{"a b", "a b c", 0, 0},
+		{"", "", 1, 0},
+	}
+	for _, tt := range tests {
+		sim, wild := similarity(strings.Fields(tt.tpl), strings.Fields(tt.toks))
+		if sim != tt.sim || wild != tt.wild {
+			t.Errorf("similarity(%q, %q) = %v, %d; want %v, %d", tt.tpl, tt.toks, sim, wild, tt.sim, tt.wild)
+		}
+	}
+}
+
+func TestMineTieBreak(t *testing.T) {
+	// "disk io sdc is full" matches both templates on 4 of 5 tokens; the
+	// one already generalised at the differing position wins, as in Drain.
+	m := NewMiner()
+	m.SimThreshold = 0.7
+	m.Add(Record{Message: "disk io sdc is gone"})
+	m.Add(Record{Message: "disk io sda is full"})
+	general := m.Add(Record{Message: "disk io sdb is full"})
+	if general.Text() != "disk io <*> is full" {
+		t.Fatalf("template %q, want %q", general.Text(), "disk io <*> is full")
+	}
+	if got := m.Add(Record{Message: "disk io sdc is full"}); got != general {
+		t.Fatalf("joined %q, want %q", got.Text(), general.Text())
+	}
+	if general.Count != 3 {
+		t.Errorf("Count = %d, want 3", general.Count)
+	}
+}
@@ -0,0 +1,145 @@
+package pcp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+// archiveInterval is the sampling step used for one-shot reports against an
+// archive. pmlogger's default logging interval is 60s, so sub-minute steps would
+// only show interpolated values.
+const archiveInterval = 60 * time.Second
+
+// SetArchive switches the client to replay a PCP archive instead of live pmcd.
+// path may be an archive file (.0/.meta/.index), its basename, or a pmlogger
+// directory (e.g. /var/log/pcp/pmlogger/node1). An empty path returns to live mode.
+func (c *Client) SetArchive(path string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		c.Archive = ""
+		c.Start, c.End = time.Time{}, time.Time{}
+		return nil
+	}
+	path = fsutil.ExpandHome(path)
+	if _, err := os.Stat(path); err != nil {
+		// basename form: foo.0 / foo.meta must exist
+		if _, err2 := os.Stat(path + ".meta"); err2 != nil {
+			if _, err3 := os.Stat(path + ".meta.xz"); err3 != nil {
+				return fmt.Errorf("archive not found: %s", path)
+			}
+		}
+	}
+	c.Archive = path
+	return nil
+}
+
+// SetRange bounds the archive window. Zero times mean "from the beginning" /
+// "to the end" of the archive.
+func (c *Client) SetRange(start, end time.Time) error {
+	if !start.IsZero() && !end.IsZero() && !end.After(start) {
+		return errors.New("end must be after start")
+	}
+	c.Start, c.End = start, end
+	return nil
+}
+
+// RangeDuration returns End-Start when both are set, else 0.
+func (c *Client) RangeDuration() time.Duration {
+	if c == nil || c.Start.IsZero() || c.End.IsZero() {
+		return 0
+	}
+	return c.End.Sub(c.Start)
+}
+
+// windowArgs returns pmrep/pmval time window options (-S/-T) for archive mode.
+func (c *Client) windowArgs() []string {
+	if c == nil || c.Archive == "" {
+		return nil
+	}
+	var args []string
+	if !c.Start.IsZero() {
+		args = append(args, "-S", pcpTime(c.Start))
+	}
+	if !c.End.IsZero() {
+		args = append(args, "-T", pcpTime(c.End))
+	}
+	return args
+}
+
+// pcpTime renders t in the "@ ctime" form accepted by PCP's -S/-T options.
+func pcpTime(t time.Time) string {
+	return "@" + t.Local().Format(time.ANSIC)
+}
+
+// snapInterval is the step used by one-shot reports (CPUOnce, RawOnce).
+func (c *Client) snapInterval() time.Duration {
+	if c != nil && c.Archive != "" {
+		return archiveInterval
+	}
+	return 1 * time.Second
+}
+
+// diagInterval picks a sampling step for a diagnosis window: 1s live, and about
+// ten steps (never below 1s) when replaying an archive.
+func (c *Client) diagInterval(window time.Duration) time.Duration {
+	step := 1 * time.Second
+	if c != nil && c.Archive != "" {
+		step = (window / 10).Truncate(time.Second)
+		if step < time.Second {
+			step = time.Second
+		}
+	}
+	if window < step {
+		step = window
+	}
+	return step
+}
+
+func (c *Client) sourceKind() string {
+	if c != nil && c.Archive != "" {
+		return "archive"
+	}
+	return "pmcd"
+}
+
+func (c *Client) archiveLabel() string {
+	label := "archive:" + filepath.Base(strings.TrimRight(c.Archive, "/"))
+	const f = "2006-01-02 15:04"
+	switch {
+	case !c.Start.IsZero() && !c.End.IsZero():
+		label += fmt.Sprintf(" [%s ~ %s]", c.Start.Format(f), c.End.Format(f))
+	case !c.Start.IsZero():
+		label += fmt.Sprintf(" [%s ~]", c.Start.Format(f))
+	case !c.End.IsZero():
+		label += fmt.Sprintf(" [~ %s]", c.End.Format(f))
+	}
+	return label
+}
+
+// archiveInfo returns the archive label (host, time span) via pmdumplog -L.
+func (c *Client) archiveInfo() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "archive:  %s\n", c.Archive)
+	if !commandExists("pmdumplog") {
+		return b.String()
+	}
+	out, err := run("pmdumplog", "-L", c.Archive)
+	if err != nil && out == "" {
+		fmt.Fprintf(&b, "label:    (unreadable: %s)\n", err)
+		return b.String()
+	}
+	for _, ln := range strings.Split(out, "\n") {
+		t := strings.TrimSpace(ln)
+		if strings.HasPrefix(t, "Performance metrics from host") ||
+			strings.HasPrefix(t, "commencing") || strings.HasPrefix(t, "ending") {
+			fmt.Fprintf(&b, "  %s\n", t)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,345 @@
+package pcp
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Snapshot is a compact, pre-digested view of a host over a short window.
+// It is meant to be handed to the LLM as context, so every field is already
+// converted to human units (percent, bytes/s, ...).
+type Snapshot struct {
+	Host   string
+	Window time.Duration
+	NCPU   int
+
+	CPU      map[string]float64 // percent per mode (user, sys, iowait, steal, idle)
+	Load     [3]float64         // 1/5/15 minute
+	Runnable float64
+
+	MemTotal     float64 // bytes
+	MemAvailable float64 // bytes
+	SwapTotal    float64 // bytes
+	SwapUsed     float64 // bytes
+	MajFaults    float64 // per second
+	SwapIn       float64 // pages per second
+	SwapOut      float64 // pages per second
+
+	Disks  []DevIO
+	Nets   []NetIO
+	TopCPU []ProcStat
+	TopMem []ProcStat
+
+	Notes []string // partial failures (e.g. proc metrics unavailable)
+}
+
+// DevIO is per block device throughput and utilization.
+type DevIO struct {
+	Name     string
+	ReadBps  float64
+	WriteBps float64
+	IOPS     float64
+	UtilPct  float64
+}
+
+// NetIO is per network interface throughput and error rates.
+type NetIO struct {
+	Name   string
+	InBps  float64
+	OutBps float64
+	Errs   float64 // per second (in+out)
+	Drops  float64 // per second (in+out)
+}
+
+// ProcStat is one process from proc.psinfo.*.
+type ProcStat struct {
+	PID    int
+	Cmd    string
+	CPUPct float64 // percent of one CPU
+	RSS    float64 // bytes
+}
+
+// diagMetrics is the curated host-level metric set used by Diagnose.
+var diagMetrics = []string{
+	"hinv.ncpu",
+	"kernel.all.cpu.user", "kernel.all.cpu.sys", "kernel.all.cpu.idle",
+	"kernel.all.cpu.wait.total", "kernel.all.cpu.steal",
+	"kernel.all.load", "kernel.all.runnable",
+	"mem.physmem", "mem.util.available", "mem.util.swapTotal", "mem.util.swapFree",
+	"mem.vmstat.pgmajfault", "swap.pagesin", "swap.pagesout",
+	"disk.dev.read_bytes", "disk.dev.write_bytes", "disk.dev.total", "disk.dev.avactive",
+	"network.interface.in.bytes", "network.interface.out.bytes",
+	"network.interface.in.errors", "network.interface.out.errors",
+	"network.interface.in.drops", "network.interface.out.drops",
+}
+
+var procMetrics = []string{"proc.psinfo.utime", "proc.psinfo.stime", "proc.psinfo.rss"}
+
+// DefaultDiagWindow is the sampling window used when the caller passes 0.
+const DefaultDiagWindow = 5 * time.Second
+
+// Diagnose samples the curated metric set over window and summarizes it.
+// Host metrics and per-process metrics are fetched concurrently; a failure of
+// the process part is recorded in Notes rather than failing the whole snapshot.
+func (c *Client) Diagnose(window time.Duration) (*Snapshot, error) {
+	if window <= 0 {
+		window = DefaultDiagWindow
+	}
+	interval := c.diagInterval(window)
+	samples := int(window/interval) + 1
+
+	var (
+		wg              sync.WaitGroup
+		hostRows        []Sample
+		procRows        []Sample
+		hostErr, prcErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		hostRows, hostErr = c.Fetch(diagMetrics, samples, interval, true)
+	}()
+	go func() {
+		defer wg.Done()
+		// Two samples spanning the whole window are enough for per-process rates.
+		procRows, prcErr = c.Fetch(procMetrics, 2, window, true)
+	}()
+	wg.Wait()
+
+	if hostErr != nil {
+		return nil, hostErr
+	}
+	if len(hostRows) < 2 {
+		return nil, errors.New("pmrep returned fewer than 2 samples")
+	}
+	first, last := hostRows[0], hostRows[len(hostRows)-1]
+	secs := float64(len(hostRows)-1) * interval.Seconds()
+
+	s := &Snapshot{Host: c.HostLabel(), Window: time.Duration(secs * float64(time.Second)), CPU: map[string]float64{}}
+	ncpu, _ := last.Get("hinv.ncpu", "")
+	if ncpu <= 0 {
+		ncpu = 1
+	}
+	s.NCPU = int(ncpu)
+
+	capacity := secs * 1000 * ncpu
+	for label, m := range map[string]string{
+		"user": "kernel.all.cpu.user", "sys": "kernel.all.cpu.sys", "idle": "kernel.all.cpu.idle",
+		"iowait": "kernel.all.cpu.wait.total", "steal": "kernel.all.cpu.steal",
+	} {
+		if d, ok := delta(first, last, m, ""); ok {
+			s.CPU[label] = clampPct(d / capacity * 100)
+		}
+	}
+	for i, w := range []string{"1 minute", "5 minute", "15 minute"} {
+		s.Load[i] = mean(hostRows, "kernel.all.load", w)
+	}
+	s.Runnable = mean(hostRows, "kernel.all.runnable", "")
+
+	kb := func(m string) float64 { v, _ := last.Get(m, ""); return v * 1024 }
+	s.MemTotal = kb("mem.physmem")
+	s.MemAvailable = kb("mem.util.available")
+	s.SwapTotal = kb("mem.util.swapTotal")
+	s.SwapUsed = s.SwapTotal - kb("mem.util.swapFree")
+	rate := func(m, inst string) float64 { d, _ := delta(first, last, m, inst); return d / secs }
+	s.MajFaults = rate("mem.vmstat.pgmajfault", "")
+	s.SwapIn = rate("swap.pagesin", "")
+	s.SwapOut = rate("swap.pagesout", "")
+
+	for _, dev := range last.Instances("disk.dev.total") {
+		d := DevIO{
+			Name:     dev,
+			ReadBps:  rate("disk.dev.read_bytes", dev) * 1024,
+			WriteBps: rate("disk.dev.write_bytes", dev) * 1024,
+			IOPS:     rate("disk.dev.total", dev),
+			UtilPct:  clampPct(rate("disk.dev.avactive", dev) / 10), // ms busy per second -> %
+		}
+		s.Disks = append(s.Disks, d)
+	}
+	sort.Slice(s.Disks, func(i, j int) bool { return s.Disks[i].UtilPct > s.Disks[j].UtilPct })
+
+	for _, ifc := range last.Instances("network.interface.in.bytes") {
+		if ifc == "lo" {
+			continue
+		}
+		n := NetIO{
+			Name:   ifc,
+			InBps:  rate("network.interface.in.bytes", ifc),
+			OutBps: rate("network.interface.out.bytes", ifc),
+			Errs:   rate("network.interface.in.errors", ifc) + rate("network.interface.out.errors", ifc),
+			Drops:  rate("network.interface.in.drops", ifc) + rate("network.interface.out.drops", ifc),
+		}
+		s.Nets = append(s.Nets, n)
+	}
+	sort.Slice(s.Nets, func(i, j int) bool { return s.Nets[i].InBps+s.Nets[i].OutBps > s.Nets[j].InBps+s.Nets[j].OutBps })
+
+	if prcErr != nil {
+		s.Notes = append(s.Notes, "process metrics unavailable: "+prcErr.Error())
+	} else if len(procRows) < 2 {
+		s.Notes = append(s.Notes, "process metrics unavailable: not enough samples")
+	} else {
+		s.TopCPU, s.TopMem = topProcs(procRows[0], procRows[len(procRows)-1], window.Seconds(), 5)
+	}
+	return s, nil
+}
+
+func topProcs(first, last Sample, secs float64, n int) ([]ProcStat, []ProcStat) {
+	var all []ProcStat
+	for _, inst := range last.Instances("proc.psinfo.rss") {
+		p := ProcStat{}
+		pid, cmd := splitProcInstance(inst)
+		p.PID, p.Cmd = pid, cmd
+		rss, _ := last.Get("proc.psinfo.rss", inst)
+		p.RSS = rss * 1024
+		du, ok1 := delta(first, last, "proc.psinfo.utime", inst)
+		ds, ok2 := delta(first, last, "proc.psinfo.stime", inst)
+		if ok1 && ok2 && secs > 0 {
+			p.CPUPct = (du + ds) / (secs * 1000) * 100
+		}
+		all = append(all, p)
+	}
+	byCPU := append([]ProcStat(nil), all...)
+	sort.Slice(byCPU, func(i, j int) bool { return byCPU[i].CPUPct > byCPU[j].CPUPct })
+	byMem := append([]ProcStat(nil), all...)
+	sort.Slice(byMem, func(i, j int) bool { return byMem[i].RSS > byMem[j].RSS })
+	if len(byCPU) > n {
+		byCPU = byCPU[:n]
+	}
+	if len(byMem) > n {
+		byMem = byMem[:n]
+	}
+	return byCPU, byMem
+}
+
+// splitProcInstance parses proc instance names like "001234 /usr/bin/foo --flag".
+func splitProcInstance(inst string) (int, string) {
+	f := strings.Fields(inst)
+	if len(f) == 0 {
+		return 0, inst
+	}
+	pid, _ := strconv.Atoi(f[0])
+	cmd := inst
+	if len(f) > 1 {
+		cmd = filepath.Base(f[1])
+	}
+	return pid, cmd
+}
+
+func delta(first, last Sample, metric, inst string) (float64, bool) {
+	a, ok1 := first.Get(metric, inst)
+	b, ok2 := last.Get(metric, inst)
+	if !ok1 || !ok2 || b < a { // counter wrap or reset: ignore
+		return 0, false
+	}
+	return b - a, true
+}
+
+func mean(rows []Sample, metric, inst string) float64 {
+	sum, n := 0.0, 0
+	for _, r := range rows {
+		if v, ok := r.Get(metric, inst); ok {
+			sum += v
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// Flags returns simple heuristic observations (saturation, pressure, errors)
+// so that small models do not have to derive them from raw numbers.
+func (s *Snapshot) Flags() []string {
+	var out []string
+	if idle, ok := s.CPU["idle"]; ok && idle < 10 {
+		out = append(out, fmt.Sprintf("cpu saturated (idle %.1f%%)", idle))
+	}
+	if w := s.CPU["iowait"]; w > 20 {
+		out = append(out, fmt.Sprintf("high iowait (%.1f%%)", w))
+	}
+	if st := s.CPU["steal"]; st > 5 {
+		out = append(out, fmt.Sprintf("cpu steal (%.1f%%): hypervisor contention", st))
+	}
+	if s.NCPU > 0 && s.Load[0]/float64(s.NCPU) > 1.0 {
+		out = append(out, fmt.Sprintf("load above cpu count (%.2f/cpu)", s.Load[0]/float64(s.NCPU)))
+	}
+	if s.MemTotal > 0 && s.MemAvailable/s.MemTotal < 0.1 {
+		out = append(out, fmt.Sprintf("memory pressure (available %.1f%%)", s.MemAvailable/s.MemTotal*100))
+	}
+	if s.SwapIn+s.SwapOut > 0 {
+		out = append(out, fmt.Sprintf("active swapping (in %.1f/s, out %.1f/s pages)", s.SwapIn, s.SwapOut))
+	}
+	for _, d := range s.Disks {
+		if d.UtilPct > 80 {
+			out = append(out, fmt.Sprintf("disk %s busy (%.0f%% util)", d.Name, d.UtilPct))
+		}
+	}
+	for _, n := range s.Nets {
+		if n.Errs > 0 || n.Drops > 0 {
+			out = append(out, fmt.Sprintf("net %s errors/drops (%.1f/%.1f per s)", n.Name, n.Errs, n.Drops))
+		}
+	}
+	return out
+}
+
+// Context renders the snapshot as a compact, line-oriented block for prompts.
+func (s *Snapshot) Context() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[PCP SNAPSHOT host=%s window=%s ncpu=%d]\n", s.Host, s.Window, s.NCPU)
+	fmt.Fprintf(&b, "cpu: user=%.1f%% sys=%.1f%% iowait=%.1f%% steal=%.1f%% idle=%.1f%%\n",
+		s.CPU["user"], s.CPU["sys"], s.CPU["iowait"], s.CPU["steal"], s.CPU["idle"])
+	per := 0.0
+	if s.NCPU > 0 {
+		per = s.Load[0] / float64(s.NCPU)
+	}
+	fmt.Fprintf(&b, "load: 1m=%.2f 5m=%.2f 15m=%.2f per-cpu=%.2f runnable=%.0f\n", s.Load[0], s.Load[1], s.Load[2], per, s.Runnable)
+	availPct := 0.0
+	if s.MemTotal > 0 {
+		availPct = s.MemAvailable / s.MemTotal * 100
+	}
+	fmt.Fprintf(&b, "mem: total=%s available=%s (%.0f%%) swap=%s/%s majflt=%.1f/s swapin=%.1f/s swapout=%.1f/s\n",
+		HumanBytes(s.MemTotal), HumanBytes(s.MemAvailable), availPct, HumanBytes(s.SwapUsed), HumanBytes(s.SwapTotal),
+		s.MajFaults, s.SwapIn, s.SwapOut)
+	for i, d := range s.Disks {
+		if i >= 5 {
+			break
+		}
+		fmt.Fprintf(&b, "disk %s: util=%.0f%% read=%s/s write=%s/s iops=%.0f\n", d.Name, d.UtilPct, HumanBytes(d.ReadBps), HumanBytes(d.WriteBps), d.IOPS)
+	}
+	for i, n := range s.Nets {
+		if i >= 5 {
+			break
+		}
+		fmt.Fprintf(&b, "net %s: in=%s/s out=%s/s errs=%.1f/s drops=%.1f/s\n", n.Name, HumanBytes(n.InBps), HumanBytes(n.OutBps), n.Errs, n.Drops)
+	}
+	if len(s.TopCPU) > 0 {
+		b.WriteString("top cpu:")
+		for _, p := range s.TopCPU {
+			fmt.Fprintf(&b, " %d %s %.1f%%;", p.PID, p.Cmd, p.CPUPct)
+		}
+		b.WriteString("\n")
+	}
+	if len(s.TopMem) > 0 {
+		b.WriteString("top mem:")
+		for _, p := range s.TopMem {
+			fmt.Fprintf(&b, " %d %s %s;", p.PID, p.Cmd, HumanBytes(p.RSS))
+		}
+		b.WriteString("\n")
+	}
+	if f := s.Flags(); len(f) > 0 {
+		fmt.Fprintf(&b, "flags: %s\n", strings.Join(f, "; "))
+	} else {
+		b.WriteString("flags: (none)\n")
+	}
+	for _, n := range s.Notes {
+		fmt.Fprintf(&b, "note: %s\n", n)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
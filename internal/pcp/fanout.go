@@ -0,0 +1,259 @@
+package pcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostOverview is a small per-host summary used for side-by-side comparison.
+type HostOverview struct {
+	Host   string
+	Err    error
+	Values map[string]float64 // keyed by compareCols[].Key
+}
+
+// compareCol describes one column of the comparison table.
+type compareCol struct {
+	Key    string
+	Title  string
+	Format func(float64) string
+}
+
+var compareCols = []compareCol{
+	{"cpu", "cpu%", func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"iowait", "iowait%", func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"load", "load/cpu", func(v float64) string { return fmt.Sprintf("%.2f", v) }},
+	{"memavail", "memavail%", func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"swap", "swap%", func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"disk", "disk%", func(v float64) string { return fmt.Sprintf("%.1f", v) }},
+	{"netin", "net-in/s", func(v float64) string { return HumanBytes(v) }},
+	{"netout", "net-out/s", func(v float64) string { return HumanBytes(v) }},
+}
+
+var overviewMetrics = []string{
+	"hinv.ncpu",
+	"kernel.all.cpu.idle", "kernel.all.cpu.wait.total",
+	"kernel.all.load",
+	"mem.physmem", "mem.util.available", "mem.util.swapTotal", "mem.util.swapFree",
+	"disk.all.avactive",
+	"network.interface.in.bytes", "network.interface.out.bytes",
+}
+
+// Overview samples overviewMetrics over interval (two raw samples) and
+// returns the comparison values for this client's host.
+func (c *Client) Overview(ctx context.Context, interval time.Duration) (map[string]float64, error) {
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+	rows, err := c.FetchContext(ctx, overviewMetrics, 2, interval, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("pmrep returned fewer than 2 samples")
+	}
+	first, last := rows[0], rows[len(rows)-1]
+	secs := interval.Seconds()
+	ncpu, _ := last.Get("hinv.ncpu", "")
+	if ncpu <= 0 {
+		ncpu = 1
+	}
+	v := map[string]float64{}
+	capacity := secs * 1000 * ncpu
+	if d, ok := delta(first, last, "kernel.all.cpu.idle", ""); ok {
+		v["cpu"] = clampPct(100 - d/capacity*100)
+	}
+	if d, ok := delta(first, last, "kernel.all.cpu.wait.total", ""); ok {
+		v["iowait"] = clampPct(d / capacity * 100)
+	}
+	if l, ok := last.Get("kernel.all.load", "1 minute"); ok {
+		v["load"] = l / ncpu
+	}
+	if total, ok := last.Get("mem.physmem", ""); ok && total > 0 {
+		if a, ok := last.Get("mem.util.available", ""); ok {
+			v["memavail"] = a / total * 100
+		}
+	}
+	if st, ok := last.Get("mem.util.swapTotal", ""); ok {
+		if st <= 0 {
+			v["swap"] = 0
+		} else if sf, ok := last.Get("mem.util.swapFree", ""); ok {
+			v["swap"] = (st - sf) / st * 100
+		}
+	}
+	if d, ok := delta(first, last, "disk.all.avactive", ""); ok {
+		v["disk"] = clampPct(d / secs / 10)
+	}
+	var in, out float64
+	for _, ifc := range last.Instances("network.interface.in.bytes") {
+		if ifc == "lo" {
+			continue
+		}
+		if d, ok := delta(first, last, "network.interface.in.bytes", ifc); ok {
+			in += d / secs
+		}
+		if d, ok := delta(first, last, "network.interface.out.bytes", ifc); ok {
+			out += d / secs
+		}
+	}
+	v["netin"], v["netout"] = in, out
+	return v, nil
+}
+
+// FanOut queries every host concurrently (at most parallel at a time), giving
+// each host its own timeout. Results are returned in input order.
+func FanOut(ctx context.Context, hosts []string, timeout time.Duration, parallel int) []HostOverview {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	if parallel <= 0 {
+		parallel = 16
+	}
+	res := make([]HostOverview, len(hosts))
+	sem := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, h := range hosts {
+		wg.Add(1)
+		go func(i int, h string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			hctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			v, err := New(h).Overview(hctx, 1*time.Second)
+			if err != nil && hctx.Err() == context.DeadlineExceeded {
+				err = fmt.Errorf("timeout after %s", timeout)
+			}
+			res[i] = HostOverview{Host: h, Err: err, Values: v}
+		}(i, h)
+	}
+	wg.Wait()
+	return res
+}
+
+// outlierZ is the robust z-score (median/MAD based) above which a value is flagged.
+const outlierZ = 3.5
+
+// Outliers returns, per column key, the hosts whose value deviates strongly from
+// the group median. Groups smaller than 3 hosts never have outliers.
+func Outliers(results []HostOverview) map[string]map[string]bool {
+	out := map[string]map[string]bool{}
+	for _, col := range compareCols {
+		var vals []float64
+		for _, r := range results {
+			if r.Err == nil {
+				if v, ok := r.Values[col.Key]; ok {
+					vals = append(vals, v)
+				}
+			}
+		}
+		if len(vals) < 3 {
+			continue
+		}
+		med, mad := medianMAD(vals)
+		for _, r := range results {
+			if r.Err != nil {
+				continue
+			}
+			v, ok := r.Values[col.Key]
+			if !ok {
+				continue
+			}
+			flag := false
+			if mad > 0 {
+				flag = 0.6745*math.Abs(v-med)/mad > outlierZ
+			} else {
+				// All peers identical: flag anything meaningfully different.
+				flag = math.Abs(v-med) > math.Max(1, 0.5*math.Abs(med))
+			}
+			if flag {
+				if out[col.Key] == nil {
+					out[col.Key] = map[string]bool{}
+				}
+				out[col.Key][r.Host] = true
+			}
+		}
+	}
+	return out
+}
+
+// FormatComparison renders the fan-out result as a table. Outlier cells are
+// marked with '*' and listed below the table with the group median.
+func FormatComparison(results []HostOverview) string {
+	outl := Outliers(results)
+	hostW := len("host")
+	for _, r := range results {
+		if len(r.Host) > hostW {
+			hostW = len(r.Host)
+		}
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-*s", hostW, "host")
+	for _, c := range compareCols {
+		fmt.Fprintf(&b, " %11s", c.Title)
+	}
+	b.WriteString("\n")
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintf(&b, "  %-*s", hostW, r.Host)
+		if r.Err != nil {
+			failed++
+			fmt.Fprintf(&b, " error: %s\n", r.Err)
+			continue
+		}
+		for _, c := range compareCols {
+			cell := "n/a"
+			if v, ok := r.Values[c.Key]; ok {
+				cell = c.Format(v)
+				if outl[c.Key][r.Host] {
+					cell = "*" + cell
+				}
+			}
+			fmt.Fprintf(&b, " %11s", cell)
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "hosts: %d ok, %d failed\n", len(results)-failed, failed)
+
+	var notes []string
+	for _, c := range compareCols {
+		hosts := outl[c.Key]
+		if len(hosts) == 0 {
+			continue
+		}
+		var vals []float64
+		for _, r := range results {
+			if r.Err == nil {
+				if v, ok := r.Values[c.Key]; ok {
+					vals = append(vals, v)
+				}
+			}
+		}
+		med, _ := medianMAD(vals)
+		names := make([]string, 0, len(hosts))
+		for h := range hosts {
+			names = append(names, h)
+		}
+		sort.Strings(names)
+		for _, h := range names {
+			for _, r := range results {
+				if r.Host == h {
+					notes = append(notes, fmt.Sprintf("  * %s: %s=%s (group median %s)", h, c.Title, c.Format(r.Values[c.Key]), c.Format(med)))
+				}
+			}
+		}
+	}
+	if len(notes) > 0 {
+		b.WriteString("outliers:\n")
+		b.WriteString(strings.Join(notes, "\n"))
+	} else {
+		b.WriteString("outliers: (none)")
+	}
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package pcp
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is one pmrep output row. Values are keyed by metric name and then by
+// instance name; singular metrics (no instance domain) use the empty instance "".
+type Sample struct {
+	Time   string
+	Values map[string]map[string]float64
+}
+
+// Get returns the value of metric/instance in this sample.
+func (s Sample) Get(metric, inst string) (float64, bool) {
+	m, ok := s.Values[metric]
+	if !ok {
+		return 0, false
+	}
+	v, ok := m[inst]
+	return v, ok
+}
+
+// Instances returns the instance names seen for metric (unsorted).
+func (s Sample) Instances(metric string) []string {
+	m := s.Values[metric]
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
+
+// Desc is the subset of a PCP metric descriptor we care about (from pminfo -d).
+type Desc struct {
+	Type      string // e.g. "64-bit unsigned int", "float", "string"
+	Semantics string // "counter" | "instant" | "discrete"
+	Units     string // e.g. "Kbyte", "millisec", "count / sec", "none"
+}
+
+// IsCounter reports whether values must be converted to rates to be meaningful.
+func (d Desc) IsCounter() bool { return d.Semantics == "counter" }
+
+// Fetch runs pmrep in CSV mode and returns the parsed rows.
+// When raw is true counters are returned as-is (no rate conversion), which lets
+// callers compute deltas themselves over a known interval.
+func (c *Client) Fetch(metrics []string, samples int, interval time.Duration, raw bool) ([]Sample, error) {
+	return c.FetchContext(context.Background(), metrics, samples, interval, raw)
+}
+
+// FetchContext is Fetch with cancellation; pmrep is killed when ctx is done.
+func (c *Client) FetchContext(ctx context.Context, metrics []string, samples int, interval time.Duration, raw bool) ([]Sample, error) {
+	if len(metrics) == 0 {
+		return nil, errors.New("no metrics")
+	}
+	if !commandExists("pmrep") {
+		return nil, errors.New("pmrep not found (install pcp package)")
+	}
+	if samples <= 0 {
+		samples = 1
+	}
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+	args := []string{}
+	args = append(args, c.baseArgs()...)
+	args = append(args, c.windowArgs()...)
+	args = append(args,
+		"-o", "csv",
+		"-s", strconv.Itoa(samples),
+		"-t", formatInterval(interval),
+	)
+	if raw {
+		args = append(args, "-r")
+	}
+	args = append(args, metrics...)
+	out, err := runContext(ctx, "pmrep", args...)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return parseCSV(out)
+}
+
+// formatInterval renders a duration the way PCP tools accept it (e.g. "1s", "500msec").
+func formatInterval(d time.Duration) string {
+	if d%time.Second == 0 {
+		return fmt.Sprintf("%ds", int(d/time.Second))
+	}
+	return fmt.Sprintf("%dmsec", d.Milliseconds())
+}
+
+// parseCSV parses `pmrep -o csv` output.
+//
+// Header columns look like: Time,"kernel.all.load-1 minute","mem.util.used"
+// PCP metric names never contain '-', so the first '-' separates metric and instance.
+func parseCSV(out string) ([]Sample, error) {
+	r := csv.NewReader(strings.NewReader(out))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("pmrep csv: %w", err)
+	}
+	// Skip anything pmrep printed before the header.
+	start := -1
+	for i, row := range rows {
+		if len(row) > 0 && strings.EqualFold(strings.TrimSpace(row[0]), "Time") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil, errors.New("pmrep csv: header not found")
+	}
+	header := rows[start]
+	type col struct{ metric, inst string }
+	cols := make([]col, len(header))
+	for i := 1; i < len(header); i++ {
+		name := strings.TrimSpace(header[i])
+		if idx := strings.Index(name, "-"); idx > 0 {
+			cols[i] = col{metric: name[:idx], inst: name[idx+1:]}
+		} else {
+			cols[i] = col{metric: name}
+		}
+	}
+
+	samples := make([]Sample, 0, len(rows)-start-1)
+	for _, row := range rows[start+1:] {
+		if len(row) == 0 {
+			continue
+		}
+		s := Sample{Time: strings.TrimSpace(row[0]), Values: map[string]map[string]float64{}}
+		for i := 1; i < len(row) && i < len(cols); i++ {
+			v, err := strconv.ParseFloat(strings.TrimSpace(row[i]), 64)
+			if err != nil {
+				continue // empty, N/A or string-valued metric
+			}
+			c := cols[i]
+			if s.Values[c.metric] == nil {
+				s.Values[c.metric] = map[string]float64{}
+			}
+			s.Values[c.metric][c.inst] = v
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// Describe returns descriptors for the given metrics using `pminfo -d`.
+// Unknown metrics are simply absent from the result.
+func (c *Client) Describe(metrics []string) (map[string]Desc, error) {
+	if !commandExists("pminfo") {
+		return nil, errors.New("pminfo not found (install pcp package)")
+	}
+	args := []string{}
+	args = append(args, c.baseArgs()...)
+	args = append(args, "-d")
+	args = append(args, metrics...)
+	out, err := run("pminfo", args...)
+	if out == "" && err != nil {
+		return nil, err
+	}
+	return parseDescs(out), nil
+}
+
+// parseDescs parses pminfo -d output:
+//
+//	mem.util.used
+//	    Data Type: 64-bit unsigned int  InDom: PM_INDOM_NULL 0xffffffff
+//	    Semantics: instant  Units: Kbyte
+func parseDescs(out string) map[string]Desc {
+	res := map[string]Desc{}
+	cur := ""
+	for _, ln := range strings.Split(out, "\n") {
+		if strings.TrimSpace(ln) == "" {
+			cur = ""
+			continue
+		}
+		if !strings.HasPrefix(ln, " ") && !strings.HasPrefix(ln, "\t") {
+			cur = strings.TrimSpace(ln)
+			continue
+		}
+		if cur == "" {
+			continue
+		}
+		d := res[cur]
+		t := strings.TrimSpace(ln)
+		if strings.HasPrefix(t, "Data Type:") {
+			v := strings.TrimSpace(strings.TrimPrefix(t, "Data Type:"))
+			if i := strings.Index(v, "InDom:"); i >= 0 {
+				v = strings.TrimSpace(v[:i])
+			}
+			d.Type = v
+		}
+		if strings.HasPrefix(t, "Semantics:") {
+			v := strings.TrimSpace(strings.TrimPrefix(t, "Semantics:"))
+			if i := strings.Index(v, "Units:"); i >= 0 {
+				d.Units = strings.TrimSpace(v[i+len("Units:"):])
+				v = strings.TrimSpace(v[:i])
+			}
+			d.Semantics = v
+		}
+		res[cur] = d
+	}
+	return res
+}
+
+// probe returns singular metric values via `pmprobe -v`, as strings.
+// Output lines look like: `hinv.ncpu 1 4` or `kernel.uname.release 1 "6.1.0"`.
+// A negative count means the metric is unavailable (the rest is an error message).
+func (c *Client) probe(metrics []string) (map[string]string, error) {
+	if !commandExists("pmprobe") {
+		return nil, errors.New("pmprobe not found (install pcp package)")
+	}
+	args := []string{}
+	args = append(args, c.baseArgs()...)
+	args = append(args, "-v")
+	args = append(args, metrics...)
+	out, err := run("pmprobe", args...)
+	if out == "" && err != nil {
+		return nil, err
+	}
+	res := map[string]string{}
+	for _, ln := range strings.Split(out, "\n") {
+		f := strings.SplitN(strings.TrimSpace(ln), " ", 3)
+		if len(f) < 3 {
+			continue
+		}
+		n, e := strconv.Atoi(f[1])
+		if e != nil || n < 1 {
+			continue
+		}
+		v := strings.TrimSpace(f[2])
+		if n == 1 {
+			if uq, e := strconv.Unquote(v); e == nil {
+				v = uq
+			}
+		}
+		res[f[0]] = v
+	}
+	return res, nil
+}
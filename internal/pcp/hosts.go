@@ -0,0 +1,164 @@
+package pcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ExpandHosts expands a host list in Ansible inventory style:
+//
+//	"node1,node2"            -> node1, node2
+//	"kube-worker[1:5]"       -> kube-worker1 ... kube-worker5
+//	"web[01:10:2].lab"       -> web01.lab, web03.lab, ... (zero padding kept, optional step)
+//	"rack[a:c]-sw"           -> racka-sw, rackb-sw, rackc-sw
+//
+// Entries may also be separated by whitespace; duplicates are removed (first wins).
+func ExpandHosts(spec string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, term := range splitHostSpec(spec) {
+		hosts, err := expandTerm(term)
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hosts {
+			if !seen[h] {
+				seen[h] = true
+				out = append(out, h)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("empty host list: %q", spec)
+	}
+	return out, nil
+}
+
+// splitHostSpec splits on commas/whitespace that are not inside [...].
+func splitHostSpec(spec string) []string {
+	var terms []string
+	var cur strings.Builder
+	depth := 0
+	flush := func() {
+		if t := strings.TrimSpace(cur.String()); t != "" {
+			terms = append(terms, t)
+		}
+		cur.Reset()
+	}
+	for _, r := range spec {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			if depth > 0 {
+				depth--
+			}
+		case depth == 0 && (r == ',' || r == ' ' || r == '\t' || r == '\n'):
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return terms
+}
+
+func expandTerm(term string) ([]string, error) {
+	open := strings.Index(term, "[")
+	if open < 0 {
+		return []string{term}, nil
+	}
+	closeIdx := strings.Index(term[open:], "]")
+	if closeIdx < 0 {
+		return nil, fmt.Errorf("unbalanced '[' in %q", term)
+	}
+	closeIdx += open
+	prefix, rng, suffix := term[:open], term[open+1:closeIdx], term[closeIdx+1:]
+
+	items, err := expandRange(rng)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", term, err)
+	}
+	// The suffix may contain further ranges.
+	tails, err := expandTerm(suffix)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(items)*len(tails))
+	for _, it := range items {
+		for _, t := range tails {
+			out = append(out, prefix+it+t)
+		}
+	}
+	return out, nil
+}
+
+// expandRange expands "1:5", "01:10:2" or "a:f".
+func expandRange(rng string) ([]string, error) {
+	parts := strings.Split(rng, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return nil, fmt.Errorf("invalid range [%s]", rng)
+	}
+	lo, hi := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+	step := 1
+	if len(parts) == 3 {
+		n, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid step in [%s]", rng)
+		}
+		step = n
+	}
+
+	// alphabetic: [a:f]
+	if len(lo) == 1 && len(hi) == 1 && isLetter(lo[0]) && isLetter(hi[0]) {
+		if lo[0] > hi[0] {
+			return nil, fmt.Errorf("invalid range [%s]", rng)
+		}
+		var out []string
+		for c := lo[0]; c <= hi[0]; c += byte(step) {
+			out = append(out, string(c))
+			if int(c)+step > 255 {
+				break
+			}
+		}
+		return out, nil
+	}
+
+	a, err1 := strconv.Atoi(lo)
+	b, err2 := strconv.Atoi(hi)
+	if err1 != nil || err2 != nil || a > b {
+		return nil, fmt.Errorf("invalid range [%s]", rng)
+	}
+	if (b-a)/step > 4096 {
+		return nil, fmt.Errorf("range [%s] too large", rng)
+	}
+	width := 0
+	if len(lo) > 1 && lo[0] == '0' {
+		width = len(lo)
+	}
+	var out []string
+	for i := a; i <= b; i += step {
+		out = append(out, fmt.Sprintf("%0*d", width, i))
+	}
+	return out, nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+
+// ParseGroups parses "name=spec;name2=spec2" (as used by KIKI_PCP_GROUPS).
+// Specs are kept unexpanded; use ExpandHosts when querying.
+func ParseGroups(s string) map[string]string {
+	out := map[string]string{}
+	for _, part := range strings.Split(s, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		k, v := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if k != "" && v != "" {
+			out[k] = v
+		}
+	}
+	return out
+}
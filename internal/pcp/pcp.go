@@ -2,6 +2,7 @@ package pcp
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
@@ -16,6 +17,12 @@ import (
 
 type Client struct {
 	Host string // "local" or hostname/IP
+
+	// Archive, when set, replaces live pmcd with a PCP archive (file, basename
+	// or pmlogger directory). Start/End optionally bound the replayed window.
+	Archive string
+	Start   time.Time
+	End     time.Time
 }
 
 func New(host string) *Client {
@@ -26,18 +33,24 @@ func New(host string) *Client {
 	return &Client{Host: host}
 }
 
+// SetHost targets live pmcd on host (leaving archive mode, if active).
 func (c *Client) SetHost(host string) {
 	host = strings.TrimSpace(host)
 	if host == "" {
 		host = "local"
 	}
 	c.Host = host
+	c.Archive = ""
+	c.Start, c.End = time.Time{}, time.Time{}
 }
 
 func (c *Client) HostLabel() string {
 	if c == nil {
 		return "local"
 	}
+	if c.Archive != "" {
+		return c.archiveLabel()
+	}
 	if strings.TrimSpace(c.Host) == "" {
 		return "local"
 	}
@@ -48,10 +61,13 @@ func (c *Client) HostLabel() string {
 func (c *Client) Display() string { return c.HostLabel() }
 
 func (c *Client) baseArgs() []string {
-	// PCP tools typically accept -h <host> to query remote.
+	// PCP tools typically accept -h <host> to query remote, or -a <archive>.
 	if c == nil {
 		return nil
 	}
+	if c.Archive != "" {
+		return []string{"-a", c.Archive}
+	}
 	h := strings.TrimSpace(c.Host)
 	if h == "" || strings.EqualFold(h, "local") || h == "127.0.0.1" || h == "localhost" {
 		return nil
@@ -65,7 +81,12 @@ func commandExists(name string) bool {
 }
 
 func run(name string, args ...string) (string, error) {
-	cmd := exec.Command(name, args...)
+	return runContext(context.Background(), name, args...)
+}
+
+// runContext is run with cancellation (used by long sampling windows).
+func runContext(ctx context.Context, name string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, name, args...)
 	var out bytes.Buffer
 	var errb bytes.Buffer
 	cmd.Stdout = &out
@@ -96,11 +117,13 @@ func (c *Client) Raw(metrics []string, samples int, interval time.Duration) (str
 	}
 	args := []string{}
 	args = append(args, c.baseArgs()...)
+	args = append(args, c.windowArgs()...)
 	args = append(args,
 		"-s", fmt.Sprintf("%d", samples),
-		"-t", fmt.Sprintf("%ds", int(interval.Seconds())),
-		"-o", strings.Join(metrics, ","),
+		"-t", formatInterval(interval),
 	)
+	// metrics are positional; -o selects the output target (stdout/csv/archive).
+	args = append(args, metrics...)
 	return run("pmrep", args...)
 }
 
@@ -126,6 +149,7 @@ func (c *Client) Quick() (string, error) {
 		// fallback to pmval for load if pmrep fails
 		if commandExists("pmval") {
 			args := append([]string{}, c.baseArgs()...)
+			args = append(args, c.windowArgs()...)
 			args = append(args, "-s", "1", "kernel.all.load")
 			v, e2 := run("pmval", args...)
 			if e2 == nil {
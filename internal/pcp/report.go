@@ -0,0 +1,340 @@
+package pcp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cpuModes are the kernel.all.cpu.* counters (milliseconds, summed over all CPUs)
+// reported by CPUOnce, in display order.
+var cpuModes = []struct{ Label, Metric string }{
+	{"user", "kernel.all.cpu.user"},
+	{"nice", "kernel.all.cpu.nice"},
+	{"sys", "kernel.all.cpu.sys"},
+	{"iowait", "kernel.all.cpu.wait.total"},
+	{"irq", "kernel.all.cpu.irq.hard"},
+	{"softirq", "kernel.all.cpu.irq.soft"},
+	{"steal", "kernel.all.cpu.steal"},
+	{"idle", "kernel.all.cpu.idle"},
+}
+
+// Show reports the target host, tool availability and whether pmcd answers.
+func (c *Client) Show() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pcp host: %s\n", c.HostLabel())
+	if c.Archive != "" {
+		b.WriteString(c.archiveInfo())
+	}
+
+	tools := []string{"pmrep", "pmval", "pminfo", "pmprobe"}
+	avail := make([]string, 0, len(tools))
+	for _, t := range tools {
+		mark := "missing"
+		if commandExists(t) {
+			mark = "ok"
+		}
+		avail = append(avail, t+"="+mark)
+	}
+	fmt.Fprintf(&b, "tools:    %s\n", strings.Join(avail, " "))
+
+	vals, err := c.probe([]string{"pmcd.version", "kernel.uname.nodename", "kernel.uname.release", "hinv.ncpu", "mem.physmem"})
+	if err != nil || len(vals) == 0 {
+		msg := "no response"
+		if err != nil {
+			msg = err.Error()
+		}
+		fmt.Fprintf(&b, "%-9s unavailable (%s)\n", c.sourceKind()+":", msg)
+		if c.Archive == "" && c.baseArgs() != nil {
+			b.WriteString("hint:     remote needs pmcd running on the target and 44321/tcp reachable")
+		}
+		return strings.TrimRight(b.String(), "\n")
+	}
+	fmt.Fprintf(&b, "%-9s available (pmcd version %s)\n", c.sourceKind()+":", firstNonEmpty(vals["pmcd.version"], "?"))
+	if v := vals["kernel.uname.nodename"]; v != "" {
+		fmt.Fprintf(&b, "nodename: %s\n", v)
+	}
+	if v := vals["kernel.uname.release"]; v != "" {
+		fmt.Fprintf(&b, "kernel:   %s\n", v)
+	}
+	if v := vals["hinv.ncpu"]; v != "" {
+		fmt.Fprintf(&b, "cpus:     %s\n", v)
+	}
+	if v := vals["mem.physmem"]; v != "" {
+		var kb float64
+		if _, e := fmt.Sscan(v, &kb); e == nil {
+			fmt.Fprintf(&b, "memory:   %s\n", HumanBytes(kb*1024))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// CPUOnce samples the CPU counters over a short interval (see snapInterval)
+// and reports percent per mode.
+// Percentages are relative to total capacity (100% = all CPUs fully busy).
+func (c *Client) CPUOnce() (string, error) {
+	interval := c.snapInterval()
+	metrics := []string{"hinv.ncpu"}
+	for _, m := range cpuModes {
+		metrics = append(metrics, m.Metric)
+	}
+	rows, err := c.Fetch(metrics, 2, interval, true)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) < 2 {
+		return "", errors.New("pmrep returned fewer than 2 samples")
+	}
+	prev, last := rows[len(rows)-2], rows[len(rows)-1]
+	ncpu, ok := last.Get("hinv.ncpu", "")
+	if !ok || ncpu <= 0 {
+		ncpu = 1
+	}
+	capacity := interval.Seconds() * 1000 * ncpu // ms of CPU time available in the window
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CPU @ %s (%d cpus, %s window)\n", c.HostLabel(), int(ncpu), interval)
+	idle := -1.0
+	for _, m := range cpuModes {
+		v1, ok1 := prev.Get(m.Metric, "")
+		v2, ok2 := last.Get(m.Metric, "")
+		if !ok1 || !ok2 {
+			fmt.Fprintf(&b, "  %-8s %7s\n", m.Label, "n/a")
+			continue
+		}
+		pct := clampPct((v2 - v1) / capacity * 100)
+		if m.Label == "idle" {
+			idle = pct
+		}
+		fmt.Fprintf(&b, "  %-8s %6.1f%%\n", m.Label, pct)
+	}
+	if idle >= 0 {
+		fmt.Fprintf(&b, "  %-8s %6.1f%%", "busy", clampPct(100-idle))
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// MemOnce reports memory usage in human units with percent of physical memory.
+func (c *Client) MemOnce() (string, error) {
+	metrics := []string{
+		"mem.physmem",
+		"mem.util.used",
+		"mem.util.free",
+		"mem.util.available",
+		"mem.util.cached",
+		"mem.util.bufmem",
+		"mem.util.swapTotal",
+		"mem.util.swapFree",
+	}
+	rows, err := c.Fetch(metrics, 1, time.Second, true)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", errors.New("pmrep returned no samples")
+	}
+	s := rows[len(rows)-1]
+	kb := func(m string) (float64, bool) { return s.Get(m, "") }
+
+	total, ok := kb("mem.physmem")
+	if !ok || total <= 0 {
+		return "", errors.New("mem.physmem not available")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Memory @ %s (total %s)\n", c.HostLabel(), HumanBytes(total*1024))
+	line := func(label, metric string) {
+		v, ok := kb(metric)
+		if !ok {
+			fmt.Fprintf(&b, "  %-10s %10s\n", label, "n/a")
+			return
+		}
+		fmt.Fprintf(&b, "  %-10s %10s  %5.1f%%\n", label, HumanBytes(v*1024), v/total*100)
+	}
+	line("used", "mem.util.used")
+	line("free", "mem.util.free")
+	line("available", "mem.util.available")
+	line("cached", "mem.util.cached")
+	line("buffers", "mem.util.bufmem")
+
+	if st, ok := kb("mem.util.swapTotal"); ok {
+		if st <= 0 {
+			b.WriteString("  swap       (none)")
+		} else if sf, ok := kb("mem.util.swapFree"); ok {
+			used := st - sf
+			fmt.Fprintf(&b, "  swap       %10s / %s  %5.1f%%", HumanBytes(used*1024), HumanBytes(st*1024), used/st*100)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// LoadOnce reports 1/5/15 minute load averages, normalized per CPU.
+// A per-CPU load above 1.0 means runnable tasks are queueing.
+func (c *Client) LoadOnce() (string, error) {
+	rows, err := c.Fetch([]string{"kernel.all.load", "hinv.ncpu"}, 1, time.Second, true)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", errors.New("pmrep returned no samples")
+	}
+	s := rows[len(rows)-1]
+	ncpu, ok := s.Get("hinv.ncpu", "")
+	if !ok || ncpu <= 0 {
+		ncpu = 1
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Load @ %s (%d cpus)\n", c.HostLabel(), int(ncpu))
+	for _, w := range []string{"1", "5", "15"} {
+		v, ok := s.Get("kernel.all.load", w+" minute")
+		if !ok {
+			fmt.Fprintf(&b, "  %3sm  %6s\n", w, "n/a")
+			continue
+		}
+		per := v / ncpu
+		note := ""
+		if per >= 1.0 {
+			note = "  (saturated)"
+		} else if per >= 0.7 {
+			note = "  (busy)"
+		}
+		fmt.Fprintf(&b, "  %3sm  %6.2f  %5.2f/cpu%s\n", w, v, per, note)
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// RawOnce samples arbitrary metrics and prints one line per metric/instance.
+// Counters are converted to per-second rates; byte-based units are humanized.
+func (c *Client) RawOnce(metrics []string) (string, error) {
+	interval := c.snapInterval()
+	descs, _ := c.Describe(metrics) // best-effort: without descriptors values print as-is
+	rows, err := c.Fetch(metrics, 2, interval, true)
+	if err != nil {
+		return "", err
+	}
+	if len(rows) == 0 {
+		return "", errors.New("pmrep returned no samples")
+	}
+	last := rows[len(rows)-1]
+	var prev *Sample
+	if len(rows) >= 2 {
+		prev = &rows[len(rows)-2]
+	}
+
+	names := make([]string, 0, len(last.Values))
+	for m := range last.Values {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "@ %s\n", c.HostLabel())
+	for _, m := range names {
+		d := descs[m]
+		insts := last.Instances(m)
+		sort.Strings(insts)
+		for _, inst := range insts {
+			label := m
+			if inst != "" {
+				label = fmt.Sprintf("%s[%s]", m, inst)
+			}
+			v, _ := last.Get(m, inst)
+			if d.IsCounter() {
+				if prev == nil {
+					fmt.Fprintf(&b, "  %-48s %s\n", label, "n/a (need 2 samples)")
+					continue
+				}
+				p, ok := prev.Get(m, inst)
+				if !ok {
+					continue
+				}
+				fmt.Fprintf(&b, "  %-48s %s\n", label, FormatValue((v-p)/interval.Seconds(), d.Units, true))
+				continue
+			}
+			fmt.Fprintf(&b, "  %-48s %s\n", label, FormatValue(v, d.Units, false))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// FormatValue renders v with its PCP units. Byte units are humanized;
+// rate appends "/s".
+func FormatValue(v float64, units string, rate bool) string {
+	suffix := ""
+	if rate {
+		suffix = "/s"
+	}
+	u := strings.TrimSpace(units)
+	if mult, ok := byteScale(u); ok {
+		return HumanBytes(v*mult) + suffix
+	}
+	if u == "" || u == "none" {
+		return trimFloat(v) + suffix
+	}
+	return trimFloat(v) + " " + u + suffix
+}
+
+// byteScale returns the byte multiplier for simple PCP space units.
+func byteScale(u string) (float64, bool) {
+	switch u {
+	case "byte":
+		return 1, true
+	case "Kbyte":
+		return 1 << 10, true
+	case "Mbyte":
+		return 1 << 20, true
+	case "Gbyte":
+		return 1 << 30, true
+	case "Tbyte":
+		return 1 << 40, true
+	}
+	return 0, false
+}
+
+// HumanBytes formats a byte count using binary units (KiB, MiB, ...).
+func HumanBytes(b float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	neg := b < 0
+	if neg {
+		b = -b
+	}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	s := fmt.Sprintf("%.1f %s", b, units[i])
+	if i == 0 {
+		s = fmt.Sprintf("%.0f %s", b, units[i])
+	}
+	if neg {
+		return "-" + s
+	}
+	return s
+}
+
+func trimFloat(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+		return fmt.Sprintf("%.0f", v)
+	}
+	return fmt.Sprintf("%.3f", v)
+}
+
+func clampPct(p float64) float64 {
+	if p < 0 {
+		return 0
+	}
+	if p > 100 {
+		return 100
+	}
+	return p
+}
+
+func firstNonEmpty(a, b string) string {
+	if strings.TrimSpace(a) != "" {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,344 @@
+package pcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Series is the value history of one metric instance over a sampling window.
+// For counters, Values holds per-second rates between consecutive samples.
+type Series struct {
+	Metric string
+	Inst   string
+	Units  string
+	Rate   bool
+	Values []float64
+}
+
+// Label returns "metric" or "metric[inst]".
+func (s Series) Label() string {
+	if s.Inst == "" {
+		return s.Metric
+	}
+	return fmt.Sprintf("%s[%s]", s.Metric, s.Inst)
+}
+
+// Stats is the statistical summary of a Series.
+type Stats struct {
+	Series
+	N         int
+	Min       float64
+	Max       float64
+	Mean      float64
+	P95       float64
+	Slope     float64 // change per second (least squares)
+	Trend     string  // "rising" | "falling" | "flat"
+	Anomalies []string
+}
+
+// Window is the result of SampleWindow.
+type Window struct {
+	Host     string
+	Interval time.Duration
+	NCPU     float64
+	Series   []Series
+}
+
+// SampleWindow collects samples of metrics every interval for the given duration.
+// Counters (per pminfo descriptors) are turned into per-second rates.
+func (c *Client) SampleWindow(ctx context.Context, metrics []string, window, interval time.Duration) (*Window, error) {
+	if len(metrics) == 0 {
+		return nil, errors.New("no metrics")
+	}
+	if interval <= 0 {
+		interval = 1 * time.Second
+	}
+	if window < interval {
+		window = interval
+	}
+	samples := int(window/interval) + 1
+
+	descs, _ := c.Describe(metrics) // best-effort
+	fetch := append([]string{}, metrics...)
+	if !contains(metrics, "hinv.ncpu") {
+		fetch = append(fetch, "hinv.ncpu")
+	}
+	rows, err := c.FetchContext(ctx, fetch, samples, interval, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("pmrep returned no samples")
+	}
+	return buildWindow(c.HostLabel(), rows, descs, metrics, interval), nil
+}
+
+func buildWindow(host string, rows []Sample, descs map[string]Desc, metrics []string, interval time.Duration) *Window {
+	w := &Window{Host: host, Interval: interval}
+	w.NCPU, _ = rows[len(rows)-1].Get("hinv.ncpu", "")
+
+	// Collect metric/instance keys in a stable order.
+	type key struct{ m, i string }
+	seen := map[key]bool{}
+	var keys []key
+	for _, r := range rows {
+		for m, insts := range r.Values {
+			if m == "hinv.ncpu" && !contains(metrics, m) {
+				continue
+			}
+			for i := range insts {
+				k := key{m, i}
+				if !seen[k] {
+					seen[k] = true
+					keys = append(keys, k)
+				}
+			}
+		}
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].m != keys[b].m {
+			return keys[a].m < keys[b].m
+		}
+		return keys[a].i < keys[b].i
+	})
+
+	for _, k := range keys {
+		d := descs[k.m]
+		s := Series{Metric: k.m, Inst: k.i, Units: d.Units, Rate: d.IsCounter()}
+		if s.Rate {
+			for j := 1; j < len(rows); j++ {
+				if dv, ok := delta(rows[j-1], rows[j], k.m, k.i); ok {
+					s.Values = append(s.Values, dv/interval.Seconds())
+				}
+			}
+		} else {
+			for _, r := range rows {
+				if v, ok := r.Get(k.m, k.i); ok {
+					s.Values = append(s.Values, v)
+				}
+			}
+		}
+		if len(s.Values) > 0 {
+			w.Series = append(w.Series, s)
+		}
+	}
+	return w
+}
+
+// Summarize computes min/max/mean/p95/trend for every series and flags anomalies.
+func (w *Window) Summarize() []Stats {
+	out := make([]Stats, 0, len(w.Series))
+	for _, s := range w.Series {
+		st := summarize(s, w.Interval)
+		st.Anomalies = detectAnomalies(s, w.NCPU)
+		out = append(out, st)
+	}
+	return out
+}
+
+func summarize(s Series, interval time.Duration) Stats {
+	st := Stats{Series: s, N: len(s.Values)}
+	if st.N == 0 {
+		return st
+	}
+	sorted := append([]float64(nil), s.Values...)
+	sort.Float64s(sorted)
+	st.Min = sorted[0]
+	st.Max = sorted[len(sorted)-1]
+	sum := 0.0
+	for _, v := range s.Values {
+		sum += v
+	}
+	st.Mean = sum / float64(st.N)
+	st.P95 = percentile(sorted, 95)
+	st.Slope = slope(s.Values, interval.Seconds())
+	st.Trend = trend(st.Slope, st.Mean, float64(st.N-1)*interval.Seconds())
+	return st
+}
+
+// percentile uses nearest-rank on already sorted values.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// slope returns the least squares slope of values sampled every step seconds.
+func slope(values []float64, step float64) float64 {
+	n := float64(len(values))
+	if n < 2 || step <= 0 {
+		return 0
+	}
+	var sx, sy, sxx, sxy float64
+	for i, v := range values {
+		x := float64(i) * step
+		sx += x
+		sy += v
+		sxx += x * x
+		sxy += x * v
+	}
+	den := n*sxx - sx*sx
+	if den == 0 {
+		return 0
+	}
+	return (n*sxy - sx*sy) / den
+}
+
+// trend classifies a slope: the fitted change over the window must exceed 10%
+// of the mean to count (when the mean is ~0, any non-zero change counts).
+func trend(slope, mean, span float64) string {
+	change := slope * span
+	base := math.Abs(mean)
+	if base < 1e-9 {
+		if math.Abs(change) < 1e-9 {
+			return "flat"
+		}
+	} else if math.Abs(change) < 0.1*base {
+		return "flat"
+	}
+	if change > 0 {
+		return "rising"
+	}
+	return "falling"
+}
+
+// saturation rules for well-known metrics: value -> saturated?
+// Rates are per second (e.g. ms of busy time per second for counters in ms).
+var saturationRules = map[string]struct {
+	desc string
+	hit  func(v, ncpu float64) bool
+}{
+	"kernel.all.load":           {"load >= ncpu", func(v, n float64) bool { return n > 0 && v >= n }},
+	"kernel.all.runnable":       {"runnable > 2x ncpu", func(v, n float64) bool { return n > 0 && v > 2*n }},
+	"kernel.all.cpu.idle":       {"cpu idle < 10%", func(v, n float64) bool { return n > 0 && v < 0.1*1000*n }},
+	"kernel.all.cpu.wait.total": {"iowait > 20%", func(v, n float64) bool { return n > 0 && v > 0.2*1000*n }},
+	"disk.dev.avactive":         {"device busy >= 90%", func(v, _ float64) bool { return v >= 900 }},
+	"disk.all.avactive":         {"disks busy >= 90%", func(v, _ float64) bool { return v >= 900 }},
+}
+
+func detectAnomalies(s Series, ncpu float64) []string {
+	var out []string
+	if r, ok := saturationRules[s.Metric]; ok {
+		run, best := 0, 0
+		for _, v := range s.Values {
+			if r.hit(v, ncpu) {
+				run++
+				if run > best {
+					best = run
+				}
+			} else {
+				run = 0
+			}
+		}
+		need := len(s.Values) / 2
+		if need < 3 {
+			need = 3
+		}
+		if best >= need {
+			out = append(out, fmt.Sprintf("sustained saturation: %s for %d/%d samples", r.desc, best, len(s.Values)))
+		}
+	}
+	if len(s.Values) >= 5 {
+		med, mad := medianMAD(s.Values)
+		spikes := 0
+		for i, v := range s.Values {
+			spike := false
+			if mad > 0 {
+				spike = (v-med)/mad > 6
+			} else {
+				spike = med > 0 && v > 2*med || med == 0 && v > 0 && countNonZero(s.Values) == 1
+			}
+			if !spike {
+				continue
+			}
+			spikes++
+			if spikes <= 3 {
+				out = append(out, fmt.Sprintf("spike at sample %d: %s (median %s)", i+1, FormatValue(v, s.Units, s.Rate), FormatValue(med, s.Units, s.Rate)))
+			}
+		}
+		if spikes > 3 {
+			out = append(out, fmt.Sprintf("(+%d more spikes)", spikes-3))
+		}
+	}
+	return out
+}
+
+func medianMAD(values []float64) (float64, float64) {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	med := median(sorted)
+	dev := make([]float64, len(values))
+	for i, v := range values {
+		dev[i] = math.Abs(v - med)
+	}
+	sort.Float64s(dev)
+	return med, median(dev)
+}
+
+func median(sorted []float64) float64 {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+func countNonZero(values []float64) int {
+	n := 0
+	for _, v := range values {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatStats renders summaries as a table followed by the anomaly list.
+func FormatStats(host string, window time.Duration, stats []Stats) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@ %s (%s window)\n", host, window)
+	fmt.Fprintf(&b, "  %-40s %4s %12s %12s %12s %12s  %s\n", "metric", "n", "min", "mean", "p95", "max", "trend")
+	for _, s := range stats {
+		f := func(v float64) string { return FormatValue(v, s.Units, s.Rate) }
+		fmt.Fprintf(&b, "  %-40s %4d %12s %12s %12s %12s  %s\n", s.Label(), s.N, f(s.Min), f(s.Mean), f(s.P95), f(s.Max), s.Trend)
+	}
+	n := 0
+	for _, s := range stats {
+		for _, a := range s.Anomalies {
+			if n == 0 {
+				b.WriteString("anomalies:\n")
+			}
+			fmt.Fprintf(&b, "  ! %s: %s\n", s.Label(), a)
+			n++
+		}
+	}
+	if n == 0 {
+		b.WriteString("anomalies: (none)\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
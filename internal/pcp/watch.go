@@ -0,0 +1,395 @@
+package pcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rule is a threshold condition on one metric, e.g.
+//
+//	kernel.all.load > 8 for 2m
+//	kernel.all.load[1 minute] >= 4
+//	disk.dev.avactive[sda] > 900 for 30s
+//
+// Counters are compared as per-second rates. Without an instance, the rule
+// matches if any instance of the metric satisfies the condition.
+type Rule struct {
+	ID     int
+	Expr   string
+	Metric string
+	Inst   string
+	Op     string
+	Value  float64
+	For    time.Duration
+
+	client *Client
+	rate   bool
+	units  string
+
+	// evaluation state
+	pendingSince time.Time
+	firing       bool
+	firedAt      time.Time
+	lastValue    float64
+	lastInst     string
+	lastErr      error
+}
+
+// Host returns the host (or archive label) the rule is evaluated against.
+func (r *Rule) Host() string { return r.client.HostLabel() }
+
+var reRule = regexp.MustCompile(`^\s*([A-Za-z0-9_.]+)(?:\[([^\]]*)\])?\s*(>=|<=|==|!=|>|<)\s*([-+0-9.eE]+)\s*(?:for\s+(\S+))?\s*$`)
+
+// ParseRule parses "<metric>[inst] <op> <value> [for <duration>]".
+func ParseRule(expr string) (*Rule, error) {
+	expr = strings.Trim(strings.TrimSpace(expr), `"'`)
+	m := reRule.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid rule %q (expected: <metric>[inst] <op> <value> [for <dur>])", expr)
+	}
+	v, err := strconv.ParseFloat(m[4], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid threshold %q", m[4])
+	}
+	r := &Rule{Expr: expr, Metric: m[1], Inst: m[2], Op: m[3], Value: v}
+	if m[5] != "" {
+		d, err := time.ParseDuration(m[5])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid duration %q", m[5])
+		}
+		r.For = d
+	}
+	return r, nil
+}
+
+func (r *Rule) match(v float64) bool {
+	switch r.Op {
+	case ">":
+		return v > r.Value
+	case ">=":
+		return v >= r.Value
+	case "<":
+		return v < r.Value
+	case "<=":
+		return v <= r.Value
+	case "==":
+		return v == r.Value
+	case "!=":
+		return v != r.Value
+	}
+	return false
+}
+
+// Event is a rule state transition reported by the watcher.
+type Event struct {
+	Time   time.Time
+	RuleID int
+	Expr   string
+	Host   string
+	State  string // "firing" | "resolved"
+	Inst   string
+	Value  string // formatted with units
+}
+
+func (e Event) String() string {
+	inst := ""
+	if e.Inst != "" {
+		inst = "[" + e.Inst + "]"
+	}
+	return fmt.Sprintf("%s [%s] #%d %s @ %s (value%s=%s)", e.Time.Format("15:04:05"), strings.ToUpper(e.State), e.RuleID, e.Expr, e.Host, inst, e.Value)
+}
+
+// Watcher evaluates rules periodically in the background. Transitions are
+// queued and picked up by the REPL via Drain, so nothing is printed while the
+// user is typing.
+type Watcher struct {
+	mu       sync.Mutex
+	rules    []*Rule
+	nextID   int
+	interval time.Duration
+	events   []Event
+	cancel   context.CancelFunc
+	wake     chan struct{}
+}
+
+// NewWatcher returns a stopped watcher with the given evaluation interval.
+func NewWatcher(interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	return &Watcher{interval: interval, nextID: 1, wake: make(chan struct{}, 1)}
+}
+
+// Add binds rule to (a copy of) client and starts the loop if needed.
+func (w *Watcher) Add(r *Rule, client *Client) (*Rule, error) {
+	if client == nil {
+		return nil, errors.New("no pcp client")
+	}
+	if client.Archive != "" {
+		return nil, errors.New("watchers need live pmcd (leave archive mode with :pcp archive off)")
+	}
+	cl := *client
+	r.client = &cl
+	if descs, err := cl.Describe([]string{r.Metric}); err == nil {
+		d, ok := descs[r.Metric]
+		if !ok {
+			return nil, fmt.Errorf("unknown metric: %s", r.Metric)
+		}
+		r.rate, r.units = d.IsCounter(), d.Units
+	}
+
+	w.mu.Lock()
+	r.ID = w.nextID
+	w.nextID++
+	w.rules = append(w.rules, r)
+	start := w.cancel == nil
+	w.mu.Unlock()
+
+	if start {
+		w.start()
+	} else {
+		w.poke()
+	}
+	return r, nil
+}
+
+// Remove deletes a rule by ID. It stops the loop when no rules remain.
+func (w *Watcher) Remove(id int) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, r := range w.rules {
+		if r.ID == id {
+			w.rules = append(w.rules[:i], w.rules[i+1:]...)
+			if len(w.rules) == 0 {
+				w.stopLocked()
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Clear removes all rules and stops the loop.
+func (w *Watcher) Clear() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rules = nil
+	w.stopLocked()
+}
+
+// SetInterval changes the evaluation interval (applies from the next tick).
+func (w *Watcher) SetInterval(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	w.mu.Lock()
+	w.interval = d
+	w.mu.Unlock()
+	w.poke()
+}
+
+// Interval returns the evaluation interval.
+func (w *Watcher) Interval() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.interval
+}
+
+// Rules returns a snapshot of the configured rules with their state.
+func (w *Watcher) Rules() []Rule {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	out := make([]Rule, 0, len(w.rules))
+	for _, r := range w.rules {
+		out = append(out, *r)
+	}
+	return out
+}
+
+// Firing returns the rules currently firing.
+func (w *Watcher) Firing() []Rule {
+	var out []Rule
+	for _, r := range w.Rules() {
+		if r.firing {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Drain returns and clears queued events.
+func (w *Watcher) Drain() []Event {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ev := w.events
+	w.events = nil
+	return ev
+}
+
+// Status describes a rule for listing.
+func (r Rule) Status() string {
+	switch {
+	case r.lastErr != nil:
+		return "error: " + r.lastErr.Error()
+	case r.firing:
+		return fmt.Sprintf("FIRING since %s (value=%s)", r.firedAt.Format("15:04:05"), FormatValue(r.lastValue, r.units, r.rate))
+	case !r.pendingSince.IsZero():
+		return fmt.Sprintf("pending %s/%s (value=%s)", time.Since(r.pendingSince).Truncate(time.Second), r.For, FormatValue(r.lastValue, r.units, r.rate))
+	default:
+		return "ok"
+	}
+}
+
+func (w *Watcher) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.mu.Lock()
+	w.cancel = cancel
+	w.mu.Unlock()
+	go w.loop(ctx)
+}
+
+func (w *Watcher) stopLocked() {
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+}
+
+func (w *Watcher) poke() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *Watcher) loop(ctx context.Context) {
+	for {
+		w.evaluate(ctx)
+		timer := time.NewTimer(w.Interval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-w.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (w *Watcher) evaluate(ctx context.Context) {
+	w.mu.Lock()
+	rules := append([]*Rule(nil), w.rules...)
+	w.mu.Unlock()
+
+	for _, r := range rules {
+		if ctx.Err() != nil {
+			return
+		}
+		v, inst, hit, err := r.sample(ctx)
+		now := time.Now()
+
+		w.mu.Lock()
+		r.lastErr = err
+		if err == nil {
+			r.lastValue, r.lastInst = v, inst
+			switch {
+			case hit && r.pendingSince.IsZero():
+				r.pendingSince = now
+			case !hit:
+				r.pendingSince = time.Time{}
+			}
+			shouldFire := hit && now.Sub(r.pendingSince) >= r.For
+			if shouldFire && !r.firing {
+				r.firing, r.firedAt = true, now
+				w.events = append(w.events, r.event(now, "firing"))
+			} else if !hit && r.firing {
+				r.firing = false
+				w.events = append(w.events, r.event(now, "resolved"))
+			}
+		}
+		w.mu.Unlock()
+	}
+}
+
+func (r *Rule) event(now time.Time, state string) Event {
+	return Event{
+		Time: now, RuleID: r.ID, Expr: r.Expr, Host: r.Host(), State: state,
+		Inst: r.lastInst, Value: FormatValue(r.lastValue, r.units, r.rate),
+	}
+}
+
+// sample fetches the rule's metric and returns the worst matching value
+// (or the first value when nothing matches), its instance and whether it hit.
+func (r *Rule) sample(ctx context.Context) (float64, string, bool, error) {
+	n := 1
+	if r.rate {
+		n = 2
+	}
+	interval := 1 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, interval*time.Duration(n)+10*time.Second)
+	defer cancel()
+	rows, err := r.client.FetchContext(ctx, []string{r.Metric}, n, interval, true)
+	if err != nil {
+		return 0, "", false, err
+	}
+	if len(rows) < n {
+		return 0, "", false, errors.New("not enough samples")
+	}
+	last := rows[len(rows)-1]
+	insts := last.Instances(r.Metric)
+	if r.Inst != "" {
+		insts = []string{r.Inst}
+	}
+	found := false
+	var firstV float64
+	var firstI string
+	for _, inst := range insts {
+		v, ok := last.Get(r.Metric, inst)
+		if !ok {
+			continue
+		}
+		if r.rate {
+			d, ok := delta(rows[len(rows)-2], last, r.Metric, inst)
+			if !ok {
+				continue
+			}
+			v = d / interval.Seconds()
+		}
+		if !found {
+			found, firstV, firstI = true, v, inst
+		}
+		if r.match(v) {
+			return v, inst, true, nil
+		}
+	}
+	if !found {
+		return 0, "", false, fmt.Errorf("no value for %s", r.Metric)
+	}
+	return firstV, firstI, false, nil
+}
+
+// HeaderSummary is a short "ALERT" text for the UI header ("" when quiet).
+func (w *Watcher) HeaderSummary() string {
+	if w == nil {
+		return ""
+	}
+	firing := w.Firing()
+	if len(firing) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(firing))
+	for _, r := range firing {
+		parts = append(parts, fmt.Sprintf("#%d %s", r.ID, r.Expr))
+	}
+	return fmt.Sprintf("%d firing: %s", len(firing), strings.Join(parts, ", "))
+}
@@ -0,0 +1,226 @@
+// Package secret resolves credential references (env:VAR, file:PATH,
+// keyring:NAME) and keeps the keyring: values in a small encrypted store
+// under ~/.kiki.
+//
+// The store is secrets.enc, AES-256-GCM sealed with a random key kept next
+// to it in secrets.key (both 0600). It keeps tokens out of config files,
+// dotfile repos and backups of ~/.kiki/config.yaml; it does not protect
+// against someone who can read the key file as you.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kiki-ai-shell/internal/fsutil"
+)
+
+// Schemes are the accepted reference prefixes.
+var Schemes = []string{"env:", "file:", "keyring:"}
+
+// IsRef reports whether s is a secret reference rather than a literal.
+func IsRef(s string) bool {
+	for _, p := range Schemes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns the secret a reference points to. Values are trimmed of
+// surrounding whitespace (a trailing newline in a token file is common).
+func Resolve(ref string) (string, error) {
+	scheme, name, _ := strings.Cut(ref, ":")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("secret reference %q has no name", ref)
+	}
+	var v string
+	switch scheme {
+	case "env":
+		var ok bool
+		v, ok = os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", ref, name)
+		}
+	case "file":
+		b, err := os.ReadFile(fsutil.ExpandHome(name))
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %w", ref, err)
+		}
+		v = string(b)
+	case "keyring":
+		var err error
+		if v, err = Get(name); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("secret reference %q: use %s", ref, strings.Join(Schemes, "NAME, ")+"NAME")
+	}
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "", fmt.Errorf("secret %s is empty", ref)
+	}
+	return v, nil
+}
+
+// Dir is where the store lives (~/.kiki).
+func Dir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kiki")
+}
+
+func storePath() string { return filepath.Join(Dir(), "secrets.enc") }
+func keyPath() string   { return filepath.Join(Dir(), "secrets.key") }
+
+// Get returns the keyring value stored under name.
+func Get(name string) (string, error) {
+	m, err := load()
+	if err != nil {
+		return "", err
+	}
+	v, ok := m[name]
+	if !ok {
+		return "", fmt.Errorf("secret keyring:%s not found (add it with :secret set %s)", name, name)
+	}
+	return v, nil
+}
+
+// Set stores value under name, creating the store on first use.
+func Set(name, value string) error {
+	if !validName(name) {
+		return fmt.Errorf("secret name %q: use letters, digits, ., - and _", name)
+	}
+	m, err := load()
+	if err != nil {
+		return err
+	}
+	m[name] = value
+	return save(m)
+}
+
+// Delete removes name from the store.
+func Delete(name string) error {
+	m, err := load()
+	if err != nil {
+		return err
+	}
+	if _, ok := m[name]; !ok {
+		return fmt.Errorf("secret keyring:%s not found", name)
+	}
+	delete(m, name)
+	return save(m)
+}
+
+// Names lists the stored secret names (never the values).
+func Names() ([]string, error) {
+	m, err := load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(m))
+	for name := range m {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// load decrypts the store; a missing store is empty.
+func load() (map[string]string, error) {
+	m := map[string]string{}
+	data, err := os.ReadFile(storePath())
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := fsutil.CheckPrivate(storePath()); err != nil {
+		return nil, err
+	}
+	gcm, err := cipherFor(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%s: truncated", storePath())
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: cannot decrypt (was secrets.key replaced?)", storePath())
+	}
+	if err := json.Unmarshal(plain, &m); err != nil {
+		return nil, fmt.Errorf("%s: %w", storePath(), err)
+	}
+	return m, nil
+}
+
+func save(m map[string]string) error {
+	plain, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipherFor(true)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	return fsutil.WritePrivate(storePath(), gcm.Seal(nonce, nonce, plain, nil))
+}
+
+// cipherFor opens the store key, generating it when create is set and it
+// does not exist yet.
+func cipherFor(create bool) (cipher.AEAD, error) {
+	key, err := os.ReadFile(keyPath())
+	switch {
+	case os.IsNotExist(err) && create:
+		key = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return nil, err
+		}
+		if err := fsutil.WritePrivate(keyPath(), key); err != nil {
+			return nil, err
+		}
+	case os.IsNotExist(err):
+		return nil, fmt.Errorf("%s is missing; the secret store cannot be read", keyPath())
+	case err != nil:
+		return nil, err
+	default:
+		if err := fsutil.CheckPrivate(keyPath()); err != nil {
+			return nil, err
+		}
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("%s: expected a 32-byte key", keyPath())
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
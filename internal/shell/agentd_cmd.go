@@ -0,0 +1,121 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"kiki-ai-shell/internal/agentd"
+	"kiki-ai-shell/internal/config"
+)
+
+const agentdUsage = "usage: :agentd status | login [--base-url URL] [--username U] | register [--base-url URL] [--username U] | logout"
+
+// RunAgentd handles `kiki-ai-shell login|register|logout|whoami` and
+// `:agentd ...`. The password is prompted for without echo (or read from
+// stdin when it is not a terminal); --password works but ends up in shell
+// history and ps output.
+func RunAgentd(cfg *config.Config, cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	baseURL := fs.String("base-url", "", "agentd URL (default: agentd_url, then the last login, then "+agentd.DefaultBaseURL+")")
+	username := fs.String("username", "", "account name")
+	password := fs.String("password", "", "password (prefer the prompt)")
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 && *username == "" {
+		*username, rest = rest[0], rest[1:]
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	url := agentdURL(cfg, *baseURL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
+	defer cancel()
+
+	switch cmd {
+	case "status", "whoami":
+		return agentdStatus(url)
+	case "logout":
+		c, _ := agentd.NewClient(url)
+		if err := c.Logout(ctx); err != nil {
+			return err
+		}
+		fmt.Println("logged out (token removed from", agentd.SessionPath()+")")
+		return nil
+	case "login", "register":
+		if *username == "" {
+			if !stdinInteractive() {
+				return fmt.Errorf("--username is required")
+			}
+			*username = readLine("username:")
+		}
+		if *password == "" {
+			if *password, err = readSecretValue("password for " + *username + " @ " + url + ":"); err != nil {
+				return err
+			}
+		}
+		if *username == "" || *password == "" {
+			return fmt.Errorf("username and password are required")
+		}
+		c, _ := agentd.NewClient(url)
+		if cmd == "register" {
+			if err := c.Register(ctx, *username, *password); err != nil {
+				return err
+			}
+			fmt.Printf("registered %s at %s\n", *username, url)
+		}
+		s, err := c.Login(ctx, *username, *password)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("logged in to %s as %s%s; token saved to %s\n", s.BaseURL, s.Username, expiryNote(s), agentd.SessionPath())
+		return nil
+	}
+	return fmt.Errorf("%s", agentdUsage)
+}
+
+// agentdURL picks the server: --base-url, then agentd_url from the config,
+// then the server of the stored login, then the pod default.
+func agentdURL(cfg *config.Config, flagURL string) string {
+	if strings.TrimSpace(flagURL) != "" {
+		return agentd.NormalizeURL(flagURL)
+	}
+	if cfg.AgentdURL != "" {
+		return agentd.NormalizeURL(cfg.AgentdURL)
+	}
+	if s, err := agentd.LoadSession(); s != nil && (err == nil || errors.Is(err, agentd.ErrExpired)) {
+		return s.BaseURL
+	}
+	return agentd.DefaultBaseURL
+}
+
+func agentdStatus(url string) error {
+	s, err := agentd.LoadSession()
+	switch {
+	case errors.Is(err, agentd.ErrExpired):
+		fmt.Printf("agentd: %s as %s: token EXPIRED at %s\n", s.BaseURL, s.Username, s.ExpiresAt.Local().Format(time.RFC3339))
+		return err
+	case err != nil:
+		fmt.Println("agentd:", url)
+		return err
+	}
+	fmt.Printf("agentd: %s as %s%s\n", s.BaseURL, s.Username, expiryNote(s))
+	if s.BaseURL != url {
+		fmt.Printf("note: agentd_url is %s; the stored token (for %s) is not sent there\n", url, s.BaseURL)
+	}
+	return nil
+}
+
+func expiryNote(s *agentd.Session) string {
+	if s.ExpiresAt.IsZero() {
+		return " (no expiry given)"
+	}
+	return fmt.Sprintf(" (expires %s, in %s)", s.ExpiresAt.Local().Format("2006-01-02 15:04"), time.Until(s.ExpiresAt).Round(time.Minute))
+}
@@ -16,11 +16,13 @@ import (
 	"kiki-ai-shell/internal/usage"
 )
 
-func buildEndpoint(cfg *config.Config) string {
-	if strings.TrimSpace(cfg.BaseURL) != "" {
-		return strings.TrimRight(cfg.BaseURL, "/") + "/v1/chat/completions"
-	}
-	return fmt.Sprintf("http://%s:%d/v1/chat/completions", cfg.Host, cfg.Port)
+// buildEndpoint returns the chat completions URL of the current server with
+// its auth headers and TLS settings (registry entry, or LLM_API_KEY for a
+// bare base URL). It fails when a referenced secret cannot be read.
+func buildEndpoint(cfg *config.Config) (llm.Endpoint, error) {
+	m, _ := config.Endpoints()
+	ep := currentEndpoint(cfg, m)
+	return llmEndpoint(ep, chatURL(ep.URL))
 }
 
 func systemPromptWithCtx(cfg *config.Config, st *State, overrideSystem string) string {
@@ -57,7 +59,11 @@ func systemPromptWithCtx(cfg *config.Config, st *State, overrideSystem string) s
 }
 
 func Ask(cfg *config.Config, st *State, prompt string, overrideSystem string) {
-	endpoint := buildEndpoint(cfg)
+	endpoint, err := buildEndpoint(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "llm auth error:", err)
+		return
+	}
 
 	userContent, usedFiles, hashes, err := buildUserContent(prompt, st.Files, cfg, st)
 	if err != nil {
@@ -128,8 +134,8 @@ func Ask(cfg *config.Config, st *State, prompt string, overrideSystem string) {
 				_ = st.RAG.AddText("usage:"+now+":ask", "[ask] "+prompt, 8000)
 			}
 			if cfg.HistoryEnabled {
-				history.Append(cfg.HistoryPath, history.Record{
-					Time: now, Endpoint: endpoint, Profile: st.Profile, Model: cfg.Model,
+				appendHistory(cfg, history.Record{
+					Time: now, Endpoint: endpoint.URL, Profile: st.Profile, Model: cfg.Model,
 					Temperature: cfg.Temp, MaxTokens: cfg.MaxTokens, Stream: false,
 					SystemPrompt: sys, Ctx: st.Ctx, Prompt: prompt, Files: usedFiles,
 					FileHashes: hashes, Cwd: cwd, ResponsePrev: truncateRunes(out, cfg.HistoryPreview),
@@ -170,8 +176,8 @@ func Ask(cfg *config.Config, st *State, prompt string, overrideSystem string) {
 			_ = st.RAG.AddText("usage:"+now+":ask", "[ask] "+prompt, 8000)
 		}
 		if cfg.HistoryEnabled {
-			history.Append(cfg.HistoryPath, history.Record{
-				Time: now, Endpoint: endpoint, Profile: st.Profile, Model: cfg.Model,
+			appendHistory(cfg, history.Record{
+				Time: now, Endpoint: endpoint.URL, Profile: st.Profile, Model: cfg.Model,
 				Temperature: cfg.Temp, MaxTokens: cfg.MaxTokens, Stream: true,
 				SystemPrompt: sys, Ctx: st.Ctx, Prompt: prompt, Files: usedFiles,
 				FileHashes: hashes, Cwd: cwd, ResponsePrev: truncateRunes(captured, cfg.HistoryPreview),
@@ -202,8 +208,8 @@ func Ask(cfg *config.Config, st *State, prompt string, overrideSystem string) {
 		_ = st.RAG.AddText("usage:"+now+":ask", "[ask] "+prompt, 8000)
 	}
 	if cfg.HistoryEnabled {
-		history.Append(cfg.HistoryPath, history.Record{
-			Time: now, Endpoint: endpoint, Profile: st.Profile, Model: cfg.Model,
+		appendHistory(cfg, history.Record{
+			Time: now, Endpoint: endpoint.URL, Profile: st.Profile, Model: cfg.Model,
 			Temperature: cfg.Temp, MaxTokens: cfg.MaxTokens, Stream: false,
 			SystemPrompt: sys, Ctx: st.Ctx, Prompt: prompt, Files: usedFiles,
 			FileHashes: hashes, Cwd: cwd, ResponsePrev: truncateRunes(out, cfg.HistoryPreview),
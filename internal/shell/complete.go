@@ -5,6 +5,10 @@ import (
     "path/filepath"
     "sort"
     "strings"
+
+    "kiki-ai-shell/internal/config"
+    "kiki-ai-shell/internal/gen"
+    "kiki-ai-shell/internal/secret"
 )
 
 // completeLine returns candidates for TAB completion.
@@ -44,22 +48,46 @@ func completeLine(line string) []string {
         // tokenization
         parts := strings.Fields(strings.TrimPrefix(s, ":"))
         if len(parts) == 0 {
-            return prefixMatches(s, []string{":help", ":profile", ":stream", ":ui", ":file", ":ctx", ":ctx-size", ":llm", ":gen", ":bash", ":exit", ":quit"})
+            return prefixMatches(s, []string{":help", ":profile", ":config", ":set", ":get", ":secret", ":agentd", ":history", ":stream", ":ui", ":file", ":ctx", ":ctx-size", ":llm", ":gen", ":scaffold", ":tpl", ":pcp", ":watch", ":log-ai", ":bash", ":exit", ":quit"})
         }
         cmd := strings.ToLower(parts[0])
         // completing the command itself
         if len(parts) == 1 && !strings.HasSuffix(s, " ") {
-            return prefixMatches(":"+parts[0], []string{":help", ":profile", ":stream", ":ui", ":file", ":ctx", ":ctx-size", ":llm", ":gen", ":bash", ":exit", ":quit"})
+            return prefixMatches(":"+parts[0], []string{":help", ":profile", ":config", ":set", ":get", ":secret", ":agentd", ":history", ":stream", ":ui", ":file", ":ctx", ":ctx-size", ":llm", ":gen", ":scaffold", ":tpl", ":pcp", ":watch", ":log-ai", ":bash", ":exit", ":quit"})
         }
 
         // completing subcommands/args
         switch cmd {
         case "help":
-            topics := []string{"shell", "llm", "file", "ctx", "ui", "env", "gen", "llmset"}
+            topics := []string{"shell", "llm", "file", "ctx", "ui", "env", "gen", "tpl", "profile", "config", "agentd", "history", "llmset", "pcp", "watch", "log", "health"}
             return completeSecondToken(s, ":help", topics)
         case "profile":
-            vals := []string{"none", "fast", "deep"}
+            vals := append([]string{"list", "show"}, config.ProfileNames()...)
+            if len(parts) >= 2 && parts[1] == "show" {
+                return completeSecondToken(s, ":profile show", config.ProfileNames())
+            }
             return completeSecondToken(s, ":profile", vals)
+        case "config":
+            vals := []string{"show", "paths", "check", "save"}
+            if len(parts) >= 2 && parts[1] == "save" {
+                return completeSecondToken(s, ":config save", config.FieldKeys())
+            }
+            return completeSecondToken(s, ":config", vals)
+        case "agentd":
+            return completeSecondToken(s, ":agentd", []string{"status", "login", "register", "logout"})
+        case "history":
+            if len(parts) >= 2 && parts[1] == "sync" {
+                return completeSecondToken(s, ":history sync", []string{"status"})
+            }
+            return completeSecondToken(s, ":history", []string{"show", "search", "path", "sync"})
+        case "secret":
+            if len(parts) >= 2 && parts[1] == "rm" {
+                names, _ := secret.Names()
+                return completeSecondToken(s, ":secret rm", names)
+            }
+            return completeSecondToken(s, ":secret", []string{"list", "set", "rm"})
+        case "set", "get":
+            return setCompletions(s, ":"+cmd, parts)
         case "stream":
             vals := []string{"on", "off"}
             return completeSecondToken(s, ":stream", vals)
@@ -73,11 +101,27 @@ func completeLine(line string) []string {
             vals := []string{"set", "show", "clear"}
             return completeSecondToken(s, ":ctx", vals)
         case "llm":
-            vals := []string{"set", "show", "clear"}
+            if len(parts) >= 2 && (parts[1] == "use" || parts[1] == "test" || parts[1] == "rm") {
+                return completeSecondToken(s, ":llm "+parts[1], config.EndpointNames())
+            }
+            vals := []string{"set", "show", "clear", "list", "use", "add", "rm", "test"}
             return completeSecondToken(s, ":llm", vals)
         case "gen":
-            vals := []string{"sh", "yaml", "ansible", "tf", "k8s"}
-            return completeSecondToken(s, ":gen", vals)
+            return completeSecondToken(s, ":gen", gen.Names())
+        case "scaffold":
+            return completeSecondToken(s, ":scaffold", gen.ScaffoldNames())
+        case "tpl":
+            if len(parts) >= 2 && (parts[1] == "run" || parts[1] == "show") {
+                return completeSecondToken(s, ":tpl "+parts[1], tplNames())
+            }
+            vals := []string{"list", "show", "run", "new", "path"}
+            return completeSecondToken(s, ":tpl", vals)
+        case "watch":
+            vals := []string{"add", "list", "rm", "clear", "interval", "explain"}
+            return completeSecondToken(s, ":watch", vals)
+        case "pcp":
+            vals := []string{"show", "host", "cpu", "mem", "load", "raw", "ask", "watch", "group", "compare", "archive", "range"}
+            return completeSecondToken(s, ":pcp", vals)
         }
         return nil
     }
@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+
+	"kiki-ai-shell/internal/config"
+)
+
+const configUsage = "usage: :config show [filter] | :config paths | :config check | :config save [key...]"
+
+// RunConfig handles `:config ...` and `kiki-ai-shell config ...`.
+func RunConfig(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", configUsage)
+	}
+	switch strings.ToLower(args[0]) {
+	case "show":
+		filter := ""
+		if len(args) > 1 {
+			filter = strings.ToLower(args[1])
+		}
+		configShow(cfg, filter)
+		return nil
+	case "paths":
+		for _, f := range config.ConfigFiles() {
+			state := "missing"
+			if f.Exists {
+				state = "loaded"
+			}
+			fmt.Printf("%-8s %s\n", state, f.Path)
+		}
+		fmt.Println("(later files win; env LLM_*/KIKI_* and flags override files)")
+		return nil
+	case "save":
+		keys := args[1:]
+		if len(keys) == 0 {
+			keys = cfg.RuntimeKeys()
+		}
+		if len(keys) == 0 {
+			fmt.Println("nothing to save (no values changed with :set in this session; name keys to save them anyway)")
+			return nil
+		}
+		path := config.UserConfigPath()
+		if err := cfg.Save(path, keys); err != nil {
+			return err
+		}
+		fmt.Printf("saved to %s: %s\n", path, strings.Join(keys, ", "))
+		return nil
+	case "check":
+		errs := config.Check()
+		if len(errs) == 0 {
+			fmt.Println("config ok")
+			return nil
+		}
+		for _, err := range errs {
+			fmt.Println("  -", err)
+		}
+		return fmt.Errorf("%d problem(s) found", len(errs))
+	}
+	return fmt.Errorf("%s", configUsage)
+}
+
+func configShow(cfg *config.Config, filter string) {
+	fmt.Printf("%-18s %-40s %s\n", "KEY", "VALUE", "ORIGIN")
+	for _, e := range cfg.Entries() {
+		if filter != "" && !strings.Contains(e.Key, filter) && !strings.Contains(strings.ToLower(e.Env), filter) {
+			continue
+		}
+		v := strings.ReplaceAll(e.Value, "\n", `\n`)
+		if v == "" {
+			v = `""`
+		}
+		fmt.Printf("%-18s %-40s %s\n", e.Key, truncateRunes(v, 40), e.Origin)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/logs"
 )
 
 func normalizePath(p string) string {
@@ -58,7 +62,99 @@ func readAndFormatFile(path string, maxBytes, maxChars int) (string, string, str
 	return p, origHash, block, nil
 }
 
-func buildUserContent(prompt string, files []string, cfg *config.Config, st *State) (string, []string, []string, error) {
+// readSlicedFile is readAndFormatFile for attachments with a filter (:file add
+// --since/--tail/...). The selected lines are newest-last, so truncation keeps
+// the end of the slice instead of the beginning.
+func readSlicedFile(path string, sl logs.Slice, maxBytes, maxChars int) (string, string, string, error) {
+	p := normalizePath(path)
+	f, err := os.Open(p)
+	if err != nil {
+		return "", "", "", err
+	}
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	f.Close()
+	if err != nil {
+		return "", "", "", err
+	}
+	origHash := hex.EncodeToString(h.Sum(nil))
+
+	txt, scanned, err := logs.ReadSlice(p, sl)
+	if err != nil {
+		return "", "", "", err
+	}
+	if maxBytes > 0 && len(txt) > maxBytes {
+		txt = strings.ToValidUTF8(txt[len(txt)-maxBytes:], "")
+	}
+	if maxChars > 0 && len([]rune(txt)) > maxChars {
+		r := []rune(txt)
+		txt = string(r[len(r)-maxChars:])
+	}
+	if txt == "" {
+		txt = "(no lines matched)"
+	}
+	block := fmt.Sprintf("### FILE: %s (sha256:%s) [%s, %d lines scanned]\n```\n%s\n```\n", p, origHash, sl.Describe(), scanned, txt)
+	return p, origHash, block, nil
+}
+
+// parseFileAddArgs parses `:file add <path> [--since T] [--until T] [--tail N]
+// [--grep RE] [--level err] [--unit U]`.
+func parseFileAddArgs(args []string) (string, logs.Slice, error) {
+	fs := flag.NewFlagSet("file add", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	level := fs.String("level", "", "keep records at this severity or worse")
+	since := fs.String("since", "", "keep records at/after this time")
+	until := fs.String("until", "", "keep records at/before this time")
+	grep := fs.String("grep", "", "keep lines matching this regex")
+	unit := fs.String("unit", "", "keep records whose unit contains this")
+	tail := fs.String("tail", "", "keep the last N lines")
+
+	rest, err := parseInterleaved(fs, joinTimeArgs(args, "since", "until"))
+	if err != nil {
+		return "", logs.Slice{}, err
+	}
+	p := normalizePath(strings.Join(rest, " "))
+	if p == "" {
+		return "", logs.Slice{}, errors.New("path is required")
+	}
+	var sl logs.Slice
+	if sl.Filter, err = buildLogFilter(*level, *since, *until, *grep, *unit); err != nil {
+		return p, sl, err
+	}
+	if *tail != "" {
+		n, err := strconv.Atoi(*tail)
+		if err != nil || n < 0 {
+			return p, sl, fmt.Errorf("invalid --tail: %s", *tail)
+		}
+		sl.Tail = n
+	}
+	return p, sl, nil
+}
+
+// Attachment is one attached file and the part of it that is sent.
+type Attachment struct {
+	Path  string
+	Slice logs.Slice // :file add --since/--tail/...; empty sends the whole file
+}
+
+// Label is how an attachment appears in :file list and history.Record.Files.
+func (a Attachment) Label() string {
+	if !a.Slice.Empty() {
+		return a.Path + " [" + a.Slice.Describe() + "]"
+	}
+	return a.Path
+}
+
+// attachmentPaths returns the attached paths, in order.
+func attachmentPaths(files []Attachment) []string {
+	out := make([]string, len(files))
+	for i, a := range files {
+		out[i] = a.Path
+	}
+	return out
+}
+
+func buildUserContent(prompt string, files []Attachment, cfg *config.Config, st *State) (string, []string, []string, error) {
 	var buf strings.Builder
 	buf.WriteString(strings.TrimSpace(prompt))
 
@@ -79,11 +175,17 @@ func buildUserContent(prompt string, files []string, cfg *config.Config, st *Sta
 	if len(files) > 0 {
 		buf.WriteString("\n\n---\n아래는 첨부 파일 내용입니다. 파일 내용을 근거로 분석/답변하세요.\n\n")
 		for _, f := range files {
-			p, h, block, err := readAndFormatFile(f, cfg.FileMaxBytes, cfg.FileMaxChars)
+			var p, h, block string
+			var err error
+			if !f.Slice.Empty() {
+				p, h, block, err = readSlicedFile(f.Path, f.Slice, cfg.FileMaxBytes, cfg.FileMaxChars)
+			} else {
+				p, h, block, err = readAndFormatFile(f.Path, cfg.FileMaxBytes, cfg.FileMaxChars)
+			}
 			if err != nil {
-				return "", nil, nil, fmt.Errorf("파일 읽기 실패 (%s): %w", f, err)
+				return "", nil, nil, fmt.Errorf("파일 읽기 실패 (%s): %w", f.Path, err)
 			}
-			used = append(used, p)
+			used = append(used, Attachment{Path: p, Slice: f.Slice}.Label())
 			hashes = append(hashes, h)
 			buf.WriteString(block)
 			buf.WriteString("\n")
@@ -3,40 +3,105 @@ package shell
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/gen"
+	"kiki-ai-shell/internal/health"
 	"kiki-ai-shell/internal/llm"
+	"kiki-ai-shell/internal/textdiff"
 )
 
+// GenOptions selects a generation target and its inputs.
+type GenOptions struct {
+	Target  string // "" = plain code generation
+	Gen     gen.Options
+	Confirm bool   // save without asking when validation passes
+	Repair  int    // attempts to fix validation errors with the model (0 = none)
+	Extra   string // extra system-prompt rules appended after the target's (templates)
+	BaseURL string // --base-url, for this command only
+}
+
 // Gen runs the "gen" workflow:
 //  1) Ask the LLM for CODE ONLY output (no explanations)
 //  2) Prompt the user before saving to file
 //  3) Store generated code into local RAG (so later you can ask "what did I generate?")
 func Gen(cfg *config.Config, st *State, outPath, prompt string) error {
+	return GenTarget(cfg, st, outPath, prompt, GenOptions{})
+}
+
+// GenTarget is Gen with a target (ansible, ...): the target supplies the system
+// prompt and validates the output before it is offered for saving.
+// With a target, an empty outPath only prints the result.
+func GenTarget(cfg *config.Config, st *State, outPath, prompt string, o GenOptions) error {
+	cfg, err := withBaseURL(cfg, o.BaseURL)
+	if err != nil {
+		return err
+	}
 	outPath = strings.TrimSpace(outPath)
-	if outPath == "" {
+	if outPath == "" && o.Target == "" {
 		return fmt.Errorf("gen: output path is empty")
 	}
 	prompt = strings.TrimSpace(prompt)
 	if prompt == "" {
 		return fmt.Errorf("gen: prompt is empty")
 	}
+	var t *gen.Target
+	if o.Target != "" {
+		if t = gen.Lookup(o.Target); t == nil {
+			return fmt.Errorf("gen: unknown target %q (%s)", o.Target, strings.Join(gen.Names(), "|"))
+		}
+	}
 
-	code, err := genOnce(cfg, st, prompt)
+	// Patch mode: an existing output file is sent along and the answer is
+	// applied as a diff instead of overwriting it.
+	existing, patch, err := readExisting(cfg, outPath)
 	if err != nil {
 		return err
 	}
+	if patch {
+		prompt = patchPrompt(outPath, existing, prompt)
+	}
+
+	sys := strings.TrimSpace(cfg.GenSystemPrompt)
+	if t != nil {
+		sys = t.System(o.Gen)
+	}
+	sys = appendSystem(sys, o.Extra)
+	code, err := genOnce(cfg, st, sys, prompt)
+	if err != nil {
+		return err
+	}
+	valid := true
+	if t != nil {
+		code, valid = validateAndRepair(cfg, st, t, sys, prompt, code, o)
+	}
+
+	if patch {
+		return savePatch(cfg, st, t, outPath, existing, code, valid, o)
+	}
 
 	// Make it visible immediately.
 	fmt.Println(code)
+	if outPath == "" {
+		return nil
+	}
 
 	// Confirm save.
-	if !confirmSave(outPath) {
+	switch {
+	case !valid:
+		if !stdinInteractive() || !confirm(fmt.Sprintf("validation failed. save to %s anyway?", outPath)) {
+			fmt.Println("(not saved)")
+			return nil
+		}
+	case o.Confirm:
+	case !confirmSave(outPath):
 		fmt.Println("(cancelled)")
 		return nil
 	}
@@ -54,11 +119,79 @@ func Gen(cfg *config.Config, st *State, outPath, prompt string) error {
 	return nil
 }
 
-func genOnce(cfg *config.Config, st *State, prompt string) (string, error) {
-	endpoint := buildEndpoint(cfg)
+// validateAndRepair validates code for t and, while it fails, sends the
+// errors back to the model for up to o.Repair attempts, printing what changed
+// between attempts. It returns the last (possibly normalized) content.
+func validateAndRepair(cfg *config.Config, st *State, t *gen.Target, system, prompt, code string, o GenOptions) (string, bool) {
+	// Targets are machine-checked formats; fences are never part of them.
+	code = StripMarkdownFences(code)
+	if t.Validate == nil {
+		return code, true
+	}
+	res := t.Validate(code, o.Gen)
+	printValidation(t.Name, res)
+	for attempt := 1; !res.OK() && attempt <= o.Repair; attempt++ {
+		fmt.Fprintf(os.Stderr, "[repair %d/%d] sending %d error(s) back to the model\n", attempt, o.Repair, len(res.Errors()))
+		fixed, err := genRepair(cfg, st, system, prompt, res)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "[repair] failed:", err)
+			break
+		}
+		next := t.Validate(StripMarkdownFences(fixed), o.Gen)
+		d := textdiff.Unified(fmt.Sprintf("attempt %d", attempt), fmt.Sprintf("attempt %d", attempt+1), res.Content, next.Content, 3, colorEnabled(os.Stderr))
+		if d == "" {
+			fmt.Fprintln(os.Stderr, "[repair] the model returned the same output; giving up")
+			break
+		}
+		fmt.Fprint(os.Stderr, d)
+		printValidation(t.Name, next)
+		res = next
+	}
+	return res.Content, res.OK()
+}
+
+// genRepair asks the model to fix its previous answer given the validation errors.
+func genRepair(cfg *config.Config, st *State, system, prompt string, res gen.Result) (string, error) {
+	var b strings.Builder
+	b.WriteString("방금 출력한 결과가 검증에 실패했습니다. 아래 오류를 모두 고친 전체 결과를 다시 출력하세요.\n")
+	b.WriteString("요청 의도는 유지하고, 오류와 관계없는 부분은 바꾸지 마세요. 설명 없이 결과만 출력하세요.\n\n[Validation errors]\n")
+	for _, i := range res.Errors() {
+		b.WriteString("- " + i.String() + "\n")
+	}
+	return genChat(cfg, st, system, []llm.ChatMessage{
+		{Role: "user", Content: prompt},
+		{Role: "assistant", Content: res.Content},
+		{Role: "user", Content: b.String()},
+	})
+}
+
+// printValidation reports a validation result on stderr.
+func printValidation(target string, res gen.Result) {
+	nerr := len(res.Errors())
+	nwarn := len(res.Issues) - nerr
+	if res.OK() {
+		fmt.Fprintf(os.Stderr, "[validate:%s] OK (%d warning(s))\n", target, nwarn)
+	} else {
+		fmt.Fprintf(os.Stderr, "[validate:%s] FAILED (%d error(s), %d warning(s))\n", target, nerr, nwarn)
+	}
+	for _, i := range res.Issues {
+		fmt.Fprintln(os.Stderr, "  -", i.String())
+	}
+}
+
+func genOnce(cfg *config.Config, st *State, system, prompt string) (string, error) {
+	return genChat(cfg, st, system, []llm.ChatMessage{{Role: "user", Content: prompt}})
+}
+
+// genChat sends msgs after the code-only system prompt and returns the answer.
+func genChat(cfg *config.Config, st *State, system string, msgs []llm.ChatMessage) (string, error) {
+	endpoint, err := buildEndpoint(cfg)
+	if err != nil {
+		return "", err
+	}
 
 	// Strong guardrail: code only.
-	overrideSystem := strings.TrimSpace(cfg.GenSystemPrompt)
+	overrideSystem := strings.TrimSpace(system)
 	if overrideSystem == "" {
 		overrideSystem = "당신은 코드 생성기입니다. 사용자의 요구에 맞는 코드만 출력하세요. 설명/해설/주석 외 문장 금지. 마크다운 코드블록도 금지. 오직 원문 코드만 출력."
 	}
@@ -71,10 +204,7 @@ func genOnce(cfg *config.Config, st *State, prompt string) (string, error) {
 		Temperature: cfg.Temp,
 		MaxTokens:   cfg.MaxTokens,
 		Stream:      false,
-		Messages: []llm.ChatMessage{
-			{Role: "system", Content: sys},
-			{Role: "user", Content: prompt},
-		},
+		Messages:    append([]llm.ChatMessage{{Role: "system", Content: sys}}, msgs...),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
@@ -100,18 +230,56 @@ func genOnce(cfg *config.Config, st *State, prompt string) (string, error) {
 func confirmSave(path string) bool {
 	// Non-interactive: refuse to save without explicit confirmation.
 	// (prevents accidental overwrites when piped)
-	fi, _ := os.Stdin.Stat()
-	interactive := (fi.Mode() & os.ModeCharDevice) != 0
-	if !interactive {
+	if !stdinInteractive() {
 		fmt.Fprintln(os.Stderr, "gen: non-interactive stdin. not saving without confirmation")
 		return false
 	}
+	return confirm(fmt.Sprintf("save to %s ?", path))
+}
+
+func stdinInteractive() bool {
+	fi, _ := os.Stdin.Stat()
+	return (fi.Mode() & os.ModeCharDevice) != 0
+}
+
+// colorEnabled reports whether ANSI colors should be written to f.
+func colorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && (fi.Mode()&os.ModeCharDevice) != 0
+}
 
+// confirm asks a y/N question on stdin; anything but y/yes is "no".
+func confirm(question string) bool {
+	ans := ask(question + " [y/N]")
+	return ans == "y" || ans == "yes"
+}
+
+// ask prints question and returns the answer line, trimmed and lowercased.
+func ask(question string) string {
+	return strings.ToLower(readLine(question))
+}
+
+// readLine prints question and returns the answer line, trimmed.
+func readLine(question string) string {
 	r := bufio.NewReader(os.Stdin)
-	fmt.Printf("save to %s ? [y/N] ", path)
+	fmt.Printf("%s ", question)
 	ans, _ := r.ReadString('\n')
-	ans = strings.ToLower(strings.TrimSpace(ans))
-	return ans == "y" || ans == "yes"
+	return strings.TrimSpace(ans)
+}
+
+// appendSystem adds extra rules to a system prompt.
+func appendSystem(sys, extra string) string {
+	extra = strings.TrimSpace(extra)
+	if extra == "" {
+		return sys
+	}
+	if strings.TrimSpace(sys) == "" {
+		return extra
+	}
+	return sys + "\n\n[추가 지침]\n" + extra
 }
 
 func writeFile(path, content string) error {
@@ -119,10 +287,7 @@ func writeFile(path, content string) error {
 	if path == "" {
 		return fmt.Errorf("empty output path")
 	}
-	if strings.HasPrefix(path, "~") {
-		h, _ := os.UserHomeDir()
-		path = filepath.Join(h, strings.TrimPrefix(path, "~"))
-	}
+	path = normalizePath(path)
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
@@ -132,3 +297,70 @@ func writeFile(path, content string) error {
 func RunGen(cfg *config.Config, st *State, outPath, prompt string) error {
 	return Gen(cfg, st, outPath, prompt)
 }
+
+// ParseAnsibleAIArgs parses `kiki-ai-shell ansible-ai "<prompt>" [--target ansible]
+// [--inventory hosts] [--verify syntax|none] [--out path] [--confirm]`.
+func ParseAnsibleAIArgs(cfg *config.Config, args []string) (string, string, GenOptions, error) {
+	return parseGenAIArgs(cfg, "ansible-ai", "ansible", args)
+}
+
+// ParseK8sAIArgs parses `kiki-ai-shell ansible-k8s "<prompt>" [--namespace ns]
+// [--out path] [--confirm]`; it is ansible-ai with the k8s target.
+func ParseK8sAIArgs(cfg *config.Config, args []string) (string, string, GenOptions, error) {
+	return parseGenAIArgs(cfg, "ansible-k8s", "k8s", args)
+}
+
+func parseGenAIArgs(cfg *config.Config, name, defaultTarget string, args []string) (string, string, GenOptions, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	target := fs.String("target", defaultTarget, "generation target: "+strings.Join(gen.Names(), "|"))
+	inventory := fs.String("inventory", "", "inventory file or host spec (node1,node2 | kube-worker[1:5])")
+	namespace := fs.String("namespace", "", "default namespace for k8s objects (injected when missing)")
+	fs.StringVar(namespace, "n", "", "alias of --namespace")
+	verify := fs.String("verify", "syntax", "external check: syntax (ansible-playbook --syntax-check when installed) | none")
+	out := fs.String("out", "", "save to this path (print only when empty)")
+	confirm := fs.Bool("confirm", false, "save without asking when validation passes")
+	repair := fs.Int("repair", cfg.GenRepairMax, "max attempts to fix validation errors with the model (0 = off)")
+	baseURL := fs.String("base-url", "", "LLM base URL (overrides LLM_BASE_URL)")
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return "", "", GenOptions{}, err
+	}
+	prompt := strings.TrimSpace(strings.Join(rest, " "))
+	if prompt == "" {
+		return "", "", GenOptions{}, fmt.Errorf("%s: prompt is empty", name)
+	}
+	o := GenOptions{Target: *target, Confirm: *confirm, Repair: *repair}
+	o.Gen.Namespace = strings.TrimSpace(*namespace)
+	switch strings.ToLower(*verify) {
+	case "syntax":
+		o.Gen.SyntaxCheck = true
+	case "none", "":
+	default:
+		return "", "", o, fmt.Errorf("invalid --verify: %s (syntax|none)", *verify)
+	}
+	if strings.TrimSpace(*inventory) != "" {
+		if o.Gen.Inventory, err = health.LoadInventory(*inventory); err != nil {
+			return "", "", o, err
+		}
+	}
+	if _, err := withBaseURL(cfg, *baseURL); err != nil {
+		return "", "", o, err
+	}
+	o.BaseURL = *baseURL
+	return normalizePath(*out), prompt, o, nil
+}
+
+// replGenOptions builds target options for :gen from the shell context
+// (:ctx set inventory=... / ns=...).
+func replGenOptions(cfg *config.Config, st *State, target string) GenOptions {
+	o := GenOptions{Target: target, Gen: gen.Options{SyntaxCheck: true}, Repair: cfg.GenRepairMax}
+	o.Gen.Namespace = strings.TrimSpace(st.Ctx["ns"])
+	if inv := strings.TrimSpace(st.Ctx["inventory"]); inv != "" {
+		if hosts, err := health.LoadInventory(inv); err == nil {
+			o.Gen.Inventory = hosts
+		}
+	}
+	return o
+}
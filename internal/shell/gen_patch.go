@@ -0,0 +1,186 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/gen"
+	"kiki-ai-shell/internal/textdiff"
+)
+
+// readExisting returns the content of an existing regular file at path for
+// patch mode. ok is false when the file does not exist.
+func readExisting(cfg *config.Config, path string) (content string, ok bool, err error) {
+	if path == "" {
+		return "", false, nil
+	}
+	fi, err := os.Stat(normalizePath(path))
+	if os.IsNotExist(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !fi.Mode().IsRegular() {
+		return "", false, fmt.Errorf("gen: %s is not a regular file", path)
+	}
+	b, err := os.ReadFile(normalizePath(path))
+	if err != nil {
+		return "", false, err
+	}
+	if cfg.FileMaxChars > 0 && len([]rune(string(b))) > cfg.FileMaxChars {
+		return "", false, fmt.Errorf("gen: %s is too large to edit (%d chars > LLM_FILE_MAX_CHARS=%d)", path, len([]rune(string(b))), cfg.FileMaxChars)
+	}
+	return string(b), true, nil
+}
+
+// patchPrompt wraps the user request with the current file content so the
+// model returns a modified version instead of a new file.
+func patchPrompt(path, existing, prompt string) string {
+	var b strings.Builder
+	b.WriteString("아래는 기존 파일의 현재 내용입니다. 요청에 맞게 수정한 '파일 전체'를 출력하세요.\n")
+	b.WriteString("요청과 관계없는 부분(형식, 주석, 순서)은 그대로 유지하세요.\n\n")
+	b.WriteString("[EXISTING FILE: " + path + "]\n")
+	b.WriteString(strings.TrimRight(existing, "\n"))
+	b.WriteString("\n[END FILE]\n\n[요청]\n")
+	b.WriteString(prompt)
+	return b.String()
+}
+
+// savePatch shows the diff between the existing file and the generated
+// content, lets the user pick hunks, backs the file up and writes the result.
+func savePatch(cfg *config.Config, st *State, t *gen.Target, path, existing, code string, valid bool, o GenOptions) error {
+	a := textdiff.SplitLines(existing)
+	ops := textdiff.Lines(a, textdiff.SplitLines(code))
+	hunks := textdiff.Hunks(ops, 3)
+	if len(hunks) == 0 {
+		fmt.Println("(no changes)", path)
+		return nil
+	}
+	added, deleted := textdiff.Stats(ops)
+	color := colorEnabled(os.Stdout)
+	fmt.Print(textdiff.Unified(path, path+" (generated)", existing, code, 3, color))
+	fmt.Printf("%d hunk(s), +%d -%d\n", len(hunks), added, deleted)
+
+	if !valid && (!stdinInteractive() || !confirm(fmt.Sprintf("validation failed. apply to %s anyway?", path))) {
+		fmt.Println("(not saved)")
+		return nil
+	}
+	accept, ok := selectHunks(hunks, o.Confirm, color)
+	if !ok {
+		fmt.Println("(cancelled)")
+		return nil
+	}
+	merged := strings.Join(textdiff.Apply(a, hunks, accept), "\n")
+	if merged != "" {
+		merged += "\n"
+	}
+	partial := false
+	for _, v := range accept {
+		partial = partial || !v
+	}
+	if partial && t != nil && t.Validate != nil {
+		// A subset of hunks is new content nobody has checked yet.
+		res := t.Validate(merged, o.Gen)
+		merged = res.Content
+		printValidation(t.Name, res)
+		if !res.OK() && !confirm(fmt.Sprintf("partial result fails validation. save to %s anyway?", path)) {
+			fmt.Println("(not saved)")
+			return nil
+		}
+	}
+
+	bak, err := backupFile(path)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(path, merged); err != nil {
+		return err
+	}
+	fmt.Printf("saved: %s (backup: %s)\n", path, bak)
+	if st != nil && st.RAG != nil {
+		_ = st.RAG.AddText("gen:"+path, merged, cfg.RAGMaxChars)
+	}
+	return nil
+}
+
+// selectHunks decides which hunks to apply. assumeYes accepts all without
+// asking; otherwise stdin must be a terminal. ok is false when nothing is
+// to be written.
+func selectHunks(hunks []textdiff.Hunk, assumeYes, color bool) (accept []bool, ok bool) {
+	accept = make([]bool, len(hunks))
+	all := func(v bool) {
+		for i := range accept {
+			accept[i] = v
+		}
+	}
+	if assumeYes {
+		all(true)
+		return accept, true
+	}
+	if !stdinInteractive() {
+		fmt.Fprintln(os.Stderr, "gen: non-interactive stdin. not saving without confirmation")
+		return nil, false
+	}
+	switch ask("apply changes? [y]es all / [n]o / [p]er hunk") {
+	case "y", "yes":
+		all(true)
+		return accept, true
+	case "p", "per", "per-hunk":
+	default:
+		return nil, false
+	}
+	for i, h := range hunks {
+		fmt.Printf("\n(%d/%d)\n%s", i+1, len(hunks), h.Render(color))
+		switch ask("apply this hunk? [y]es / [n]o / [a]ll remaining / [d]rop remaining / [q]uit") {
+		case "y", "yes":
+			accept[i] = true
+		case "a":
+			for j := i; j < len(accept); j++ {
+				accept[j] = true
+			}
+			return accept, true
+		case "d":
+			return accept, anyTrue(accept)
+		case "q":
+			return nil, false
+		}
+	}
+	return accept, anyTrue(accept)
+}
+
+func anyTrue(v []bool) bool {
+	for _, b := range v {
+		if b {
+			return true
+		}
+	}
+	return false
+}
+
+// backupFile copies path to path.bak, or to path.bak.N (first free N) when
+// an older backup exists, and returns the backup name.
+func backupFile(path string) (string, error) {
+	src := normalizePath(path)
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return "", err
+	}
+	fi, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+	bak := src + ".bak"
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(bak); os.IsNotExist(err) {
+			break
+		}
+		bak = fmt.Sprintf("%s.bak.%d", src, n)
+	}
+	if err := os.WriteFile(bak, b, fi.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("backup %s: %w", path, err)
+	}
+	return bak, nil
+}
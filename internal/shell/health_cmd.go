@@ -0,0 +1,251 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/health"
+)
+
+// HealthCollectOptions controls RunHealthCollect.
+type HealthCollectOptions struct {
+	Collect health.Options
+	Out     string
+	Confirm bool // skip the interactive confirmation
+}
+
+// ParseHealthCollectArgs parses `kiki-ai-shell health-collect` flags.
+func ParseHealthCollectArgs(args []string) (HealthCollectOptions, error) {
+	fs := flag.NewFlagSet("health-collect", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	inventory := fs.String("inventory", "", "hosts: inventory file or spec (node1,node2 | kube-worker[1:5]); empty = local")
+	out := fs.String("out", "", "output tarball (default ./health-<timestamp>.tgz)")
+	confirm := fs.Bool("confirm", false, "run without asking")
+	user := fs.String("ssh-user", "", "ssh user")
+	sshOpts := fs.String("ssh-opts", "", `extra ssh options (e.g. "-i ~/.ssh/id_ed25519 -p 2222")`)
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout per artifact")
+	parallel := fs.Int("parallel", 8, "hosts collected concurrently")
+	lines := fs.Int("journal-lines", 500, "journal/dmesg tail length")
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return HealthCollectOptions{}, err
+	}
+	if len(rest) > 0 {
+		return HealthCollectOptions{}, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	hosts, err := health.LoadInventory(*inventory)
+	if err != nil {
+		return HealthCollectOptions{}, err
+	}
+	o := HealthCollectOptions{Out: normalizePath(*out), Confirm: *confirm}
+	if o.Out == "" {
+		o.Out = "health-" + time.Now().Format("20060102-150405") + ".tgz"
+	}
+	o.Collect = health.Options{
+		Hosts:     hosts,
+		Artifacts: health.DefaultArtifacts(*lines),
+		SSHUser:   *user,
+		SSHOpts:   strings.Fields(*sshOpts),
+		Timeout:   *timeout,
+		Parallel:  *parallel,
+	}
+	return o, nil
+}
+
+// RunHealthCollect gathers the evidence bundle and writes it to o.Out.
+func RunHealthCollect(o HealthCollectOptions) error {
+	fmt.Printf("health-collect: %d host(s): %s\n", len(o.Collect.Hosts), strings.Join(o.Collect.Hosts, ","))
+	names := make([]string, 0, len(o.Collect.Artifacts))
+	for _, a := range o.Collect.Artifacts {
+		names = append(names, a.Name)
+	}
+	fmt.Println("artifacts:", strings.Join(names, ","))
+	fmt.Println("output:", o.Out)
+	if !o.Confirm {
+		if !stdinInteractive() {
+			return fmt.Errorf("non-interactive stdin: pass --confirm to run")
+		}
+		if !confirm("run these read-only commands on the hosts above?") {
+			fmt.Println("(cancelled)")
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	o.Collect.Progress = func(host, msg string) {
+		fmt.Fprintf(os.Stderr, "  [%s] %s\n", host, msg)
+	}
+	m := health.Collect(ctx, o.Collect)
+	if ctx.Err() != nil {
+		return fmt.Errorf("interrupted")
+	}
+	if err := health.WriteBundle(o.Out, m); err != nil {
+		return err
+	}
+	fmt.Println(m.Summary())
+	fmt.Println("saved:", o.Out)
+	return nil
+}
+
+// HealthAIOptions controls RunHealthAI.
+type HealthAIOptions struct {
+	File     string
+	Format   string // both|md|json
+	Out      string
+	Domains  []string
+	MaxChars int
+	BaseURL  string // --base-url, for this command only
+}
+
+// ParseHealthAIArgs parses `kiki-ai-shell health-ai` flags.
+func ParseHealthAIArgs(cfg *config.Config, args []string) (HealthAIOptions, error) {
+	fs := flag.NewFlagSet("health-ai", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("file", "", "bundle written by health-collect")
+	format := fs.String("format", "both", "report format: both|md|json")
+	out := fs.String("out", "", "write the report to this file (stdout otherwise); with both, X.md and X.json")
+	domains := fs.String("domains", "", "comma separated subset of disk,memory,services,kernel")
+	maxChars := fs.Int("max-chars", 12000, "max characters per artifact sent to the LLM")
+	baseURL := fs.String("base-url", "", "LLM base URL (overrides LLM_BASE_URL)")
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return HealthAIOptions{}, err
+	}
+	o := HealthAIOptions{File: strings.TrimSpace(*file), Format: strings.ToLower(*format), Out: normalizePath(*out), MaxChars: *maxChars}
+	if o.File == "" && len(rest) == 1 {
+		o.File = rest[0]
+	} else if len(rest) > 0 {
+		return o, fmt.Errorf("unexpected arguments: %s", strings.Join(rest, " "))
+	}
+	if o.File == "" {
+		return o, fmt.Errorf("health-ai: --file is required")
+	}
+	o.File = normalizePath(o.File)
+	if o.Format != "both" && o.Format != "md" && o.Format != "json" {
+		return o, fmt.Errorf("invalid --format: %s (both|md|json)", *format)
+	}
+	for _, d := range strings.Split(*domains, ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		known := false
+		for _, x := range health.Domains {
+			known = known || x.Name == d
+		}
+		if !known {
+			return o, fmt.Errorf("unknown domain: %s", d)
+		}
+		o.Domains = append(o.Domains, d)
+	}
+	if _, err := withBaseURL(cfg, *baseURL); err != nil {
+		return o, err
+	}
+	o.BaseURL = *baseURL
+	return o, nil
+}
+
+// writeHealthReport prints the report or writes it to out. Format both writes
+// the Markdown report to out (.md added when missing) and the JSON next to
+// it with a .json extension, or prints one after the other.
+func writeHealthReport(r *health.Report, format, out string) error {
+	j, err := r.JSON()
+	if err != nil {
+		return err
+	}
+	reports := []struct{ ext, text string }{{".md", r.Markdown()}, {".json", string(j) + "\n"}}
+	switch format {
+	case "md":
+		reports = reports[:1]
+	case "json":
+		reports = reports[1:]
+	}
+	if out == "" {
+		for i, rep := range reports {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(rep.text)
+		}
+		return nil
+	}
+	base := out
+	if format == "both" {
+		if ext := filepath.Ext(out); ext == ".md" || ext == ".json" {
+			base = strings.TrimSuffix(out, ext)
+		}
+	}
+	for _, rep := range reports {
+		p := out
+		if format == "both" {
+			p = base + rep.ext
+		}
+		if err := writeFile(p, rep.text); err != nil {
+			return err
+		}
+		fmt.Println("saved:", p)
+	}
+	return nil
+}
+
+// RunHealthAI analyzes a health bundle domain by domain and prints/writes the
+// ranked findings report.
+func RunHealthAI(cfg *config.Config, st *State, o HealthAIOptions) error {
+	cfg, err := withBaseURL(cfg, o.BaseURL)
+	if err != nil {
+		return err
+	}
+	b, err := health.OpenBundle(o.File)
+	if err != nil {
+		return err
+	}
+	tasks := b.Tasks(o.Domains, o.MaxChars)
+	fmt.Fprintf(os.Stderr, "(health-ai: %d host(s), %d analysis pass(es))\n", len(b.Manifest.Hosts), len(tasks))
+	// AskWithAutoChunk sends only the content when it fits, so the question
+	// leads it (as in log-ai). Each domain has its own system prompt; all of
+	// them go into the history record.
+	var sysUsed []string
+	ask := func(sys, q, content string) (string, error) {
+		sys = systemPromptWithCtx(cfg, st, sys)
+		if !slices.Contains(sysUsed, sys) {
+			sysUsed = append(sysUsed, sys)
+		}
+		return askCondensed(cfg, st, sys, q, q+"\n\n"+content)
+	}
+	progress := func(t health.Task) {
+		fmt.Fprintf(os.Stderr, "  [%s] %s (%s)\n", t.Host, t.Domain.Name, strings.Join(t.Sources, ","))
+	}
+	r := b.Analyze(o.File, tasks, ask, progress)
+
+	if err := writeHealthReport(r, o.Format, o.Out); err != nil {
+		return err
+	}
+	saveAnswer(cfg, st, strings.Join(sysUsed, "\n\n---\n\n"), "health-ai "+o.File, []string{o.File}, nil, r.Markdown())
+	if len(r.Errors) > 0 && len(r.Errors) == len(tasks) {
+		return fmt.Errorf("all %d analysis passes failed (first: %s)", len(tasks), r.Errors[0])
+	}
+	return nil
+}
@@ -10,7 +10,7 @@ func PrintHelp(topic string) {
 	}
 	switch topic {
 	case "shell", "repl":
-		fmt.Println(`
+		fmt.Print(`
 [help:shell]
   - 인터랙티브 모드: kiki-ai-shell
   - 프롬프트:
@@ -19,7 +19,7 @@ func PrintHelp(topic string) {
   - 일반 명령 입력은 /bin/bash -lc 로 실행됩니다.
 `)
 	case "llm":
-		fmt.Println(`
+		fmt.Print(`
 [help:llm]
   - OpenAI 호환 /v1/chat/completions 엔드포인트를 호출합니다.
   - 환경변수:
@@ -30,6 +30,53 @@ func PrintHelp(topic string) {
       :llm set http://10.0.2.253:8080
       :llm clear
 
+  - 이름 붙인 엔드포인트 레지스트리(설정 파일의 endpoints:):
+      :llm add gpu1 http://10.0.2.253:8080 --provider llamacpp --model qwen2.5-32b --ctx 32768 --tags gpu,a100
+      :llm add openai https://api.openai.com/v1 --model gpt-4o-mini --api-key env:OPENAI_API_KEY
+      :llm list [tag]                목록(* = 사용 중), tag로 필터(gpu, cpu ...)
+      :llm use gpu1                  base_url/model/ctx 전환
+      :llm test [name...|--tag gpu|--all]  ping + 짧은 생성으로 지연시간, tok/s 측정
+      :llm rm gpu1
+    add/rm 은 ~/.kiki/config.yaml 을 고칩니다(--project: ./.kiki.yaml).
+    provider: openai(기본)|llamacpp|vllm|ollama
+    인증(게이트웨이 등): 비밀 값은 참조로만 저장합니다(env:VAR | file:PATH | keyring:NAME).
+      --api-key keyring:gw                      Authorization: Bearer <값>
+      --basic-user svc --basic-password env:GW_PW   Basic 인증
+      --header X-Tenant=sre --header X-Token=file:~/.config/gw.token   (반복 가능)
+    keyring: 은 ~/.kiki/secrets.enc 암호화 저장소(키: ~/.kiki/secrets.key, 0600)
+      :secret set gw       값 입력(화면에 표시 안 함; 원샷: kiki-ai-shell secret set gw < token.txt)
+      :secret list | :secret rm gw
+    레지스트리 밖의 LLM_BASE_URL 에는 LLM_API_KEY 환경변수가 Bearer 로 붙습니다.
+    TLS/mTLS(https:// 엔드포인트):
+      --ca ~/.kiki/certs/ca.pem      사내 CA 추가 신뢰(시스템 루트 + 이 번들)
+      --cert client.pem --key client-key.pem   클라이언트 인증서(mTLS ingress)
+      --server-name infer.internal   SNI/검증할 이름이 URL 호스트와 다를 때
+      --tls-min 1.3                  최소 TLS 버전(1.0|1.1|1.2|1.3)
+      --insecure                     인증서 검증 끔(경고 출력, 테스트용으로만)
+    비밀 값과 인증 헤더는 history 에 기록되지 않습니다(URL의 user:pass@ 도 제거).
+    시작 시 선택: 설정 파일 llm_endpoint: gpu1 또는 KIKI_LLM_ENDPOINT=gpu1
+      (LLM_BASE_URL/LLM_MODEL 환경변수가 있으면 그 값이 우선)
+
+      endpoints:
+        gpu1:
+          url: http://10.0.2.253:8080
+          provider: llamacpp
+          model: qwen2.5-32b
+          ctx_size: 32768
+          tags: [gpu, a100]
+        gateway:
+          url: https://llm-gw.internal
+          api_key: keyring:gw
+          headers:
+            X-Tenant: sre
+        ingress:
+          url: https://infer.k8s.internal
+          tls:
+            ca_file: ~/.kiki/certs/ca.pem
+            cert_file: ~/.kiki/certs/client.pem
+            key_file: ~/.kiki/certs/client-key.pem
+            min_version: "1.3"
+
       LLM_MODEL (default llama)
       LLM_TEMP
       LLM_MAX_TOKENS
@@ -42,22 +89,32 @@ func PrintHelp(topic string) {
       LLM_CTX_OBSERVED (관측값 강제)
 `)
 	case "file":
-		fmt.Println(`
+		fmt.Print(`
 [help:file]
   - 원샷 첨부:
       ./kiki-ai-shell -f /var/log/messages ask "이 로그 분석"
   - 쉘 첨부:
       :file add /var/log/messages
+      :file add /var/log/messages --since -2h --level err
+      :file add /var/log/messages --tail 500 --grep "kubelet|crio"
       :file list
       :file rm 1
       :file clear
 
+  - 첨부 옵션(로그 파일용): 필터를 주면 조건에 맞는 라인만, 한도 초과 시 파일 "끝" 기준으로 첨부
+      --since T / --until T   시간 범위 (2024-05-01 10:00 | 10:00 | -2h)
+      --tail N                마지막 N 라인
+      --grep <정규식>          메시지/유닛 필터
+      --level err             이 심각도 이상만 (err|warning|notice|info)
+      --unit kubelet          유닛/식별자 필터
+    적용된 필터는 :file list 와 history 의 Files 항목에 함께 기록됩니다.
+
   - 제한:
       LLM_FILE_MAX_BYTES (default 256KB)
       LLM_FILE_MAX_CHARS (default 20000)
 `)
 	case "ctx":
-		fmt.Println(`
+		fmt.Print(`
 [help:ctx]
   - 컨텍스트는 시스템 프롬프트 뒤에 [Context] 섹션으로 붙습니다.
       :ctx set cluster=prod
@@ -66,7 +123,7 @@ func PrintHelp(topic string) {
       :ctx clear
 `)
 	case "ctx-size":
-		fmt.Println(`
+		fmt.Print(`
 [help:ctx-size]
   - ctx-size는 LLM의 컨텍스트 윈도우(토큰 수)입니다.
   - kiki-ai-shell은 "목표값"을 저장/표시/가이드할 수 있지만,
@@ -80,7 +137,7 @@ func PrintHelp(topic string) {
       LLM_CTX_OBSERVED=8192   (선택: 관측값 강제)
 `)
 	case "ui":
-		fmt.Println(`
+		fmt.Print(`
 [help:ui]
   - 헤더 고정(권장):
       KIKI_UI_FIXED=1
@@ -89,50 +146,330 @@ func PrintHelp(topic string) {
       :ui header on|off
       :ui clear on|off   (레거시: 전체 clear)
 		:nofence on|off     LLM 출력에서 마크다운 코드펜스(three backticks) 제거
+`)
+	case "gen":
+		fmt.Print(`
+[help:gen]
+  - 코드만 생성해 파일로 저장합니다(저장 전 확인).
+      :gen <path> <prompt...>
+      gen <path> <prompt...>            (REPL 단축형)
+
+  - 타깃 지정 시 전용 시스템 프롬프트 + 결과 검증 후 저장:
+      :gen ansible site.yml nginx 설치하고 서비스 시작
+        YAML 파싱, play/task 구조(hosts, task당 모듈 1개, block/rescue), 번들 모듈 카탈로그,
+        ansible-playbook 설치 시 --syntax-check
+        인벤토리: :ctx set inventory=kube-worker[1:5]  (또는 인벤토리 파일 경로)
+      :gen k8s web.yaml nginx Deployment 3개 + Service(ClusterIP 80)
+        멀티 문서 YAML 분리, 번들 OpenAPI 스키마(Deployment/StatefulSet/DaemonSet/Job/CronJob/Pod,
+        Service/Ingress/ConfigMap/Secret/PVC/ServiceAccount/Namespace/HPA)로 필드/타입/필수값 검사,
+        selector-라벨 일치, volumeMount-volume 참조. 클러스터 접속 없이 동작합니다.
+        네임스페이스: :ctx set ns=prod  → metadata.namespace 가 없으면 주입
+      :gen bash backup.sh /etc 를 날짜별로 tar.gz 백업
+        bash -n 문법 검사, shellcheck 설치 시 lint(error 만 실패로 처리)
+
+  - 자동 수리(repair): 검증이 실패하면 오류 목록을 모델에 돌려보내 다시 생성합니다.
+      최대 시도 횟수: KIKI_GEN_REPAIR (기본 3, 0 = 끔), 원샷은 --repair N
+      시도마다 이전 결과와의 diff 를 보여주고, 같은 결과가 나오면 중단합니다.
+      검증을 통과해야 저장 확인을 묻고, 끝내 실패하면 "그래도 저장할지" 확인합니다(비대화형은 저장 안 함).
+
+  - 수정(patch) 모드: <path> 가 이미 있으면 현재 내용을 함께 보내 "수정된 전체 파일"을 받습니다.
+      기존 파일과의 컬러 unified diff 를 보여준 뒤 [y]전체 적용 / [n]취소 / [p]hunk 별 선택.
+      일부 hunk 만 적용하면 합친 결과를 다시 검증합니다.
+      저장 전 기존 파일을 <path>.bak 으로 백업합니다(이미 있으면 <path>.bak.1, .2 ...).
+      --confirm 은 모든 hunk 를 묻지 않고 적용합니다.
+
+  - 여러 파일 스캐폴드: 모델이 "=== FILE: <경로> ===" 봉투로 파일 묶음을 돌려주면
+    트리로 미리 보여주고, 파일 종류별로 검증한 뒤 대상 디렉터리에 한 번에 씁니다.
+      :scaffold role roles/nginx nginx 설치/설정/서비스 기동 롤
+        tasks/handlers = task 리스트 검증, defaults/vars/meta = YAML 매핑, tasks/main.yml 필수
+      :scaffold k8s deploy/web nginx Deployment + Service + Ingress
+        매니페스트 = 번들 스키마 검증(ns 주입), kustomization.yaml resources 가 번들 파일과 일치하는지
+      :scaffold files tools/ 백업 스크립트와 설정 파일
+        확장자별 검증(.sh = bash -n/shellcheck, .yml/.yaml, .json)
+      새 디렉터리는 임시 디렉터리에 만든 뒤 rename 으로 한 번에 생성합니다.
+      기존 디렉터리에 쓸 때 덮어쓰는 파일은 .bak 으로 백업하고, 중간 실패 시 모두 되돌립니다.
+
+  - 원샷:
+      kiki-ai-shell ansible-ai "HTTPD 설치하고 서비스 시작" --inventory "node1,node2" \
+          --verify syntax --repair 3 --out playbooks/httpd.yml --confirm
+      kiki-ai-shell ansible-k8s "redis StatefulSet 1개와 headless Service" -n cache --out redis.yaml
+      kiki-ai-shell scaffold "nginx 롤" --kind role --out roles/nginx --confirm
+`)
+	case "tpl", "template":
+		fmt.Print(`
+[help:tpl]
+  - 자주 쓰는 프롬프트를 템플릿 파일로 저장해 두고 변수만 바꿔 실행합니다.
+  - 위치(같은 이름이면 프로젝트 우선):
+      프로젝트: 현재 디렉터리 또는 상위의 .kiki/templates/
+      사용자:   ~/.kiki/templates/
+      파일: <이름>.tpl | .md | .txt (하위 디렉터리 = 이름의 일부, 예: ansible/nginx-role)
+
+  - 형식(front matter 는 선택):
+      ---
+      description: nginx 롤 생성
+      mode: scaffold        # ask(기본) | gen | scaffold
+      target: role          # gen: ansible|k8s|bash, scaffold: role|k8s|files
+      out: roles/{{name}}   # gen/scaffold 저장 위치(변수 사용 가능)
+      system: 사내 표준 포트는 8080   # 시스템 프롬프트에 덧붙일 지침
+      vars:
+        host: 대상 호스트                          # 설명만 = 필수
+        name: {default: nginx, description: 롤 이름}
+      ---
+      {{host}} 에 {{name}} 을 설치하는 롤을 만들어 주세요.
+
+  - 변수 값 우선순위: key=value / --key value  >  :ctx set 값  >  default
+      남은 필수 변수는 터미널이면 물어보고, 비대화형이면 오류로 끝납니다.
+
+  - 명령:
+      :tpl list                       템플릿 목록(이름/위치/모드/설명)
+      :tpl show nginx-role            변수(필수/기본값/ctx 값)와 본문
+      :tpl run nginx-role host=web1   실행 (나머지 단어는 [추가 요청]으로 덧붙임)
+          --out path   out 덮어쓰기     --confirm   검증 통과 시 묻지 않고 저장
+          --repair N   수리 횟수        --dry-run   보낼 프롬프트만 출력
+      :tpl new my/tpl [--project]     예시 템플릿 생성
+      :tpl path                       검색 디렉터리
+
+  - 원샷:
+      kiki-ai-shell tpl run nginx-role host=web1 --confirm
+`)
+	case "agentd", "login":
+		fmt.Print(`
+[help:agentd]
+  - KIKI agentd(:8082 /api/v1/*) 계정/토큰. 토큰은 ~/.kiki/config.json (0600)에 저장됩니다.
+      kiki-ai-shell register --base-url http://127.0.0.1:8082 --username test
+      kiki-ai-shell login --base-url http://127.0.0.1:8082 --username test
+      kiki-ai-shell whoami
+      kiki-ai-shell logout
+    비밀번호는 프롬프트로 입력(화면에 표시 안 함, 파이프면 stdin). --password 도 되지만
+    쉘 히스토리/ps 에 남습니다.
+  - 쉘: :agentd status | :agentd login [user] | :agentd register [user] | :agentd logout
+  - 서버 선택: --base-url > agentd_url(KIKI_AGENTD_URL) > 마지막 로그인 서버 > http://127.0.0.1:8082
+  - 토큰은 발급한 서버로의 요청에만 자동으로 붙고(Authorization: Bearer),
+    만료되었거나(expires_in/expires_at/JWT exp) config.json 권한이 0600 보다 넓거나
+    다른 사용자 소유면 사용하지 않습니다.
+`)
+	case "config":
+		fmt.Print(`
+[help:config]
+  - 설정은 아래 순서로 겹쳐 적용됩니다(뒤가 우선):
+      기본값 -> /etc/kiki/config.yaml -> ~/.kiki/config.yaml -> ./.kiki.yaml -> 환경변수 -> 실행 플래그
+  - 파일 형식: 평평한 key: value (키 목록은 :config show). 예:
+      base_url: http://10.0.2.253:8080    # host:port 만 써도 됨
+      model: qwen2.5-14b
+      temperature: 0.2
+      max_tokens: 1024
+      timeout: 120
+      history_path: ~/.kiki/history.jsonl
+      pcp_groups: web=web[1:4];db=db1,db2
+      profiles: { ... }                  # :help profile
+  - 잘못된 키/값은 "파일:줄: 이유"로 알려 주고 그 값만 무시합니다(이전 단계 값 유지).
+  - 명령:
+      :config show [filter]     값과 출처(default / 파일 경로 / env X / flag / profile / :명령)
+      :config paths             읽는 파일과 존재 여부
+      :config check             모든 파일/환경변수/프로파일 검증(원샷: kiki-ai-shell config check, 문제 있으면 exit 1)
+      :config save [key...]     이번 세션에서 바꾼 값(또는 지정한 키)을 ~/.kiki/config.yaml 에 저장
+                                (다른 키/profiles/주석은 유지, 파일 권한 0600)
+
+  - 실행 중 변경:
+      :set <key> <value>        타입 검사 후 즉시 적용 (예: :set max_tokens 2048, :set stream on)
+      :set system_prompt 당신은 DBA 입니다.     (나머지 전체가 값)
+      :get [key...]             값, 타입, 출처, 대응 환경변수 (키 없으면 :config show)
+      키는 TAB 으로 완성되고, env 이름(LLM_MODEL)으로도 지정할 수 있습니다.
+`)
+	case "profile", "profiles":
+		fmt.Print(`
+[help:profile]
+  - 프로파일 = 모델/temperature/max_tokens/시스템 프롬프트/RAG/엔드포인트/nofence 묶음.
+  - 기본 제공: none, fast, deep, k8s-sre, ansible-writer, log-triage
+  - 설정 파일의 profiles: 에서 추가/덮어쓰기(뒤에 읽은 파일이 우선):
+      /etc/kiki/config.yaml  ->  ~/.kiki/config.yaml  ->  ./.kiki.yaml
+
+      profiles:
+        db-sre:
+          description: DB 장애 대응
+          extends: deep            # 부모 프로파일을 먼저 적용
+          model: qwen2.5-32b
+          temperature: 0.2
+          max_tokens: 1536
+          system_prompt: 당신은 PostgreSQL 운영 전문가입니다.
+          rag: true
+          rag_topk: 5
+          rag_max_chars: 4000
+          endpoint: http://10.0.2.254:8080   # 또는 레지스트리 이름(:llm list)
+          nofence: true
+
+  - 명령:
+      :profile k8s-sre               전환(이전 프로파일이 바꾼 값은 되돌린 뒤 적용)
+      :profile list                  목록(* = 현재)
+      :profile show [name]           상속 체인과 설정 값
+      kiki-ai-shell -profile log-triage log-ai --file /var/log/messages
 `)
 	case "history":
-		fmt.Println(`
+		fmt.Print(`
 [help:history]
-  - 저장된 사용 이력(명령/질문)을 조회/요약합니다.
+  - LLM 질문/답변 이력(history_path, 기본 ~/.kiki/history.jsonl)을 조회합니다.
   - 명령:
-      :history tail [N]
-      :history search "keyword"
-      :history summarize [days]
+      :history show [N]               최근 N개 (host= 는 기록한 머신)
+      :history search <regex> [N]     질문/답변 미리보기에서 검색
+      :history path                   파일 경로
+      :history sync [status]          agentd 와 지금 동기화 / 대기열·마지막 오류
+  - 여러 서버(bastion)에서 이력 공유: kiki-ai-shell login 후
+      :set history_sync true          (또는 KIKI_HISTORY_SYNC=1, :config save history_sync)
+    새 기록은 백그라운드로 agentd(/api/v1/history)에 올리고, 실패하면
+    <history_path>.queue 에 남겨 backoff 재시도/다음 실행/:history sync 때 다시 보냅니다.
+    쉘 시작 시 다른 머신의 기록을 받아 시간순으로 합치며, 내용 해시(id)로 중복을 걸러냅니다.
+    원샷(cron 등): kiki-ai-shell history sync
 `)
 	case "pcp":
-		fmt.Println(`
+		fmt.Print(`
 [help:pcp]
   - PCP(Performance Co-Pilot)로 시스템 지표를 조회합니다.
   - 로컬은 pcp 패키지(특히 pmrep)가 설치되어 있어야 합니다.
   - 원격은 대상 서버에 pmcd가 실행 중이어야 하며 방화벽에서 44321/tcp 접근이 가능해야 합니다.
 
   명령:
-    :pcp show
+    :pcp show                      대상 호스트, pcp 도구 설치 여부, pmcd 응답 여부
     :pcp host local|<hostname|ip>
-    :pcp cpu
-    :pcp mem
-    :pcp load
-    :pcp raw <metric...>
+    :pcp cpu                       1초 구간 모드별 CPU 사용률(%)
+    :pcp mem                       메모리 사용량(KiB/MiB/GiB, 전체 대비 %)
+    :pcp load                      1/5/15분 load 및 CPU당 정규화 값
+    :pcp raw <metric...>           임의 지표 (counter는 초당 값으로 변환)
+    :pcp ask [-w 10s] <질문>       CPU/메모리/디스크/네트워크/상위 프로세스 지표를 구간 샘플링해
+                                   요약 컨텍스트로 첨부한 뒤 LLM에 질문 (기본 5s)
+    :pcp watch [-i 2s] <metric...> <기간>
+                                   기간 동안 샘플링 후 metric/instance별 min/mean/p95/max,
+                                   추세(rising/falling/flat), 지속 포화/급등(spike) 탐지
+    :pcp group add <이름> <호스트>  호스트 그룹 등록 (node1,node2 또는 kube-worker[1:5])
+    :pcp group list | rm <이름>
+    :pcp compare [-t 10s] <그룹|호스트>
+                                   여러 호스트를 동시에 조회해 비교 표 출력, 이상치(*) 표시
+    :pcp archive <경로> [--from T] [--to T]
+                                   live pmcd 대신 PCP 아카이브(pmlogger 디렉터리 포함)를 조회
+                                   이후 :pcp cpu|mem|load|raw|watch|ask 가 아카이브 구간에 대해 동작
+    :pcp archive off               live 모드로 복귀
+    :pcp range <from> [<to>]       아카이브 조회 구간 변경 (:pcp range clear)
+                                   T 형식: 2024-05-01 10:00 | 2024-05-01T10:00:00 | 10:00 | -3h
 
   예:
     :pcp host 192.168.10.20
     :pcp cpu
     :pcp raw kernel.all.load mem.util.used
+    :pcp ask 이 노드가 왜 느려?
+    :pcp watch kernel.all.load disk.dev.avactive 2m
+    :pcp group add workers kube-worker[1:5]
+    :pcp compare workers
+    :pcp archive /var/log/pcp/pmlogger/node1 --from 2024-05-01 09:50 --to 2024-05-01 10:20
+    :pcp ask 이 구간에 무슨 일이 있었어?
+
+  환경변수:
+    KIKI_PCP_HOST     기본 호스트 (default local)
+    KIKI_PCP_GROUPS   그룹 정의 (예: "workers=kube-worker[1:5];masters=kube-master[1:3]")
+    KIKI_PCP_TIMEOUT  호스트별 타임아웃 초 (default 10)
+`)
+	case "watch":
+		fmt.Print(`
+[help:watch]
+  - PCP 지표에 임계값 규칙을 걸어 백그라운드에서 주기적으로 평가합니다.
+  - 발생(firing)한 경보는 상단 헤더의 ALERT 에 표시되고, 다음 프롬프트 전에 알림이 출력됩니다.
+  - 규칙은 추가 시점의 :pcp host 를 대상으로 합니다 (아카이브 모드에서는 불가).
+
+  규칙 형식:
+    <metric>[instance] <op> <value> [for <기간>]     op: > >= < <= == !=
+    counter 지표는 초당 값으로 비교합니다. instance 생략 시 어느 instance 라도 만족하면 발생.
+
+  명령:
+    :watch add "kernel.all.load > 8 for 2m"
+    :watch add "disk.dev.avactive[sda] > 900 for 30s"
+    :watch list
+    :watch rm N
+    :watch clear
+    :watch interval 30s           평가 주기 (KIKI_WATCH_INTERVAL, default 15초)
+    :watch explain on|off         경보 발생 시 PCP 스냅샷과 함께 LLM 자동 설명 (KIKI_WATCH_EXPLAIN)
+`)
+	case "log", "log-ai":
+		fmt.Print(`
+[help:log]
+  - 로그 파일을 레코드(시간/호스트/유닛/심각도/메시지)로 파싱한 뒤
+    오류/경고 위주로 걸러낸 요약만 LLM에 전달합니다. (원문 전체를 보내지 않음)
+  - 반복 라인은 Drain 방식 템플릿으로 묶어 보냅니다.
+      #3 ERR x4120 kubelet 05-01 10:00~05-01 10:59 | failed to sync pod <*> | $5=a,b,c
+    (횟수, 처음/마지막 시각, 가변 위치 예시 값 포함 → 대용량 로그도 한 번의 프롬프트에 수용)
+  - 지원 형식: syslog(RFC3164/5424, rsyslog ISO), journald JSON(journalctl -o json), klog
+    (--format auto 는 줄 단위로 자동 판별, journald/syslog 안의 klog 메시지도 인식)
+
+  실행:
+    kiki-ai-shell log-ai --file /var/log/messages [--base-url URL] [질문...]
+    :log-ai /var/log/messages [옵션] [질문...]
+
+  옵션:
+    --format auto|syslog|journal|klog
+    --level warning               이 심각도 이상만 (err|warning|notice|info|all, default warning)
+    --since T / --until T         시간 범위 (2024-05-01 10:00 | 10:00 | -2h)
+    --grep <정규식>                메시지/유닛 필터
+    --unit kubelet,crio           유닛/식별자 필터
+    --max-templates 200           LLM에 보낼 최대 템플릿 수 (심각도/빈도 순)
+    --raw                         템플릿 대신 원본 레코드 전송
+    --max-lines 400               --raw 일 때 보낼 최대 레코드 수 (최근 기준)
+    --max-bytes N                 파일 끝에서 읽을 최대 바이트 (default 32MB)
+    --system "..."                시스템 프롬프트 교체
+
+  예:
+    journalctl -u kubelet -o json --since -1h > /tmp/kubelet.json
+    kiki-ai-shell log-ai --file /tmp/kubelet.json --level err 왜 노드가 NotReady 가 됐어?
+`)
+	case "health":
+		fmt.Print(`
+[help:health]
+  - 장애 분석용 증거 번들(tar.gz)을 수집합니다. 읽기 전용 명령만 실행합니다.
+  - 수집 항목: uname, os-release, uptime, df(-i), lsblk, free, meminfo, ps(CPU/메모리 상위),
+    dmesg(warn 이상), systemctl --failed, journal(warning 이상), PCP(pmrep 5초),
+    kubelet/ovs 상태(설치된 경우만; 없으면 n/a 로 기록)
+  - 번들 구조:
+      manifest.json              호스트별 항목/명령/종료코드/크기/sha256/소요시간
+      <host>/<항목>.txt
+
+  실행:
+    kiki-ai-shell health-collect                                   로컬
+    kiki-ai-shell health-collect --inventory "node1,node2" --out /tmp/health.tgz --confirm
+    kiki-ai-shell health-collect --inventory ./inventory.ini --ssh-user root --parallel 4
+
+  옵션:
+    --inventory <spec|file>   node1,node2 | kube-worker[1:5] | Ansible INI 인벤토리 파일
+    --ssh-user U / --ssh-opts "-i key -p 2222"    (BatchMode: 키 기반 접속 필요)
+    --timeout 30s             항목별 타임아웃
+    --parallel 8              동시 수집 호스트 수
+    --journal-lines 500       journal/dmesg 줄 수
+    --confirm                 확인 없이 실행 (비대화형 실행 시 필수)
+
+  분석 (health-ai):
+    kiki-ai-shell health-ai --file /tmp/health.tgz [--format both|md|json] [--out report.md]
+    - 호스트별로 아티팩트를 disk / memory(+ps,pcp) / services(+journal,kubelet,ovs) / kernel(dmesg)
+      전용 프롬프트로 나눠 분석하고, 심각도(critical>high>medium>low>info) 순으로 정렬한
+      findings 보고서를 출력합니다. 각 finding 에는 근거 파일(<host>/<항목>.txt)이 포함됩니다.
+    --format both             Markdown 과 JSON 을 함께 출력 (기본값; md/json 은 한 가지만)
+                              --out report.md 이면 report.md 와 report.json 을 저장
+    --domains disk,kernel     일부 관점만 분석
+    --max-chars 12000         아티팩트당 LLM 에 보낼 최대 글자 수 (초과 시 끝부분 유지)
+    --base-url URL            LLM 엔드포인트
 `)
 	default:
 		printHelpAll()
-		fmt.Println("topics: shell | llm | file | ctx | ctx-size | ui | history | pcp")
+		fmt.Println("topics: shell | llm | file | ctx | ctx-size | ui | gen | history | pcp | watch | log | health")
 	}
 }
 
 func printHelpAll() {
-	fmt.Println(`
+	fmt.Print(`
 kiki-ai-shell
 
 === 실행 방식 ===
   kiki-ai-shell                  인터랙티브 쉘
   kiki-ai-shell ask "질문"       단일 질문(원샷)
   kiki-ai-shell "질문"           ask 단축형
+  kiki-ai-shell log-ai --file F  로그 파싱/필터 후 LLM 분석(:help log)
+  kiki-ai-shell tpl run <name>   프롬프트 템플릿 실행(:help tpl)
+  kiki-ai-shell health-collect   로컬/SSH 증거 번들(tar.gz) 수집(:help health)
+  kiki-ai-shell health-ai --file 번들을 관점별로 LLM 분석해 findings 보고서(Markdown/JSON)
   kiki-ai-shell --help           도움말(전체)
 
 === LLM 질문(대화) ===
@@ -141,7 +478,11 @@ kiki-ai-shell
 
 === 내부 명령(:로 시작) ===
   :help [topic]                   도움말 (topic: shell|llm|file|ctx|ctx-size|ui|history)
-  :profile fast|deep|none         프로파일 변경
+  :profile <name>|list|show       프로파일 변경/목록/설정 보기(:help profile)
+  :config show|paths|check|save   설정 값과 출처, 설정 파일 검증/저장(:help config)
+  :set <key> <value> | :get [key] 설정 값 변경/조회(TAB 으로 키 완성)
+  :secret list|set|rm <name>      LLM 인증용 비밀 값 암호화 저장(keyring:<name>, :help llm)
+  :agentd status|login|register|logout  KIKI agentd 로그인/토큰 관리(:help agentd)
   :stream on|off                  스트리밍 출력 on/off
   :nofence on|off                 LLM 출력에서 코드펜스(three backticks, yaml fence 포함) 제거
   :ui header on|off               상단 헤더 표시 on/off
@@ -150,6 +491,7 @@ kiki-ai-shell
   :llm show                       현재 LLM_BASE_URL 표시
   :llm set <base_url>             실행 중 LLM_BASE_URL 변경
   :llm clear                      LLM_BASE_URL 초기화(Host:Port로 fallback)
+  :llm list|use|add|rm|test       이름 붙인 엔드포인트 레지스트리(:help llm)
 
   :ctx set key=value              컨텍스트 설정 (예: cluster, ns)
   :ctx show                       컨텍스트 표시
@@ -158,16 +500,21 @@ kiki-ai-shell
   :ctx-size N                     목표 ctx-size 설정(서버 재시작 필요)
 
   :gen <path> <prompt...>         코드만 생성 후 파일로 저장(저장 전 확인)
+  :gen ansible|k8s|bash <path> <prompt...> 타깃별 프롬프트 + 검증 후 저장(:help gen)
+  :scaffold role|k8s|files <dir> <prompt...> 여러 파일(롤/번들) 생성 + 검증 후 일괄 저장
+  :tpl list|show|run|new ...      프롬프트 템플릿 실행 (예: :tpl run nginx-role host=web1, :help tpl)
 
-  :file add /path                 파일 첨부
+  :file add /path [옵션]          파일 첨부 (--since/--until/--tail/--grep/--level, :help file)
   :file list                      첨부 목록
   :file rm N                      N번째 제거
   :file clear                     전체 제거
 
   gen <path> <prompt...>          (REPL) 코드만 생성 후 파일로 저장
 
-  :history ...                    사용 이력 조회/요약
+  :history show|search|path|sync  질문 이력 조회, agentd 로 여러 서버 이력 동기화(:help history)
   :pcp ...                        PCP 기반 시스템 지표 조회(:help pcp)
+  :watch ...                      PCP 지표 임계값 백그라운드 감시(:help watch)
+  :log-ai <file> [질문]           로그 파싱/오류 필터 후 LLM 분석(:help log)
   :bash                           PTY 기반 bash 진입 (exit로 복귀)
   :exit | :quit                   종료
 
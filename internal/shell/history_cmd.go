@@ -12,9 +12,9 @@ import (
 )
 
 func handleHistory(cfg *config.Config, st *State, args []string) bool {
-	// :history show [N] | :history search <regex> [N] | :history path
+	// :history show [N] | :history search <regex> [N] | :history path | :history sync [status]
 	if len(args) < 1 {
-		fmt.Println("usage: :history show [N] | :history search <regex> [N] | :history path")
+		fmt.Println("usage: :history show [N] | :history search <regex> [N] | :history path | :history sync [status]")
 		return true
 	}
 	sub := strings.ToLower(args[0])
@@ -22,6 +22,11 @@ func handleHistory(cfg *config.Config, st *State, args []string) bool {
 	case "path":
 		fmt.Println(cfg.HistoryPath)
 		return true
+	case "sync":
+		if err := RunHistorySync(cfg, args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, "history sync:", err)
+		}
+		return true
 	case "show":
 		limit := 20
 		if len(args) >= 2 {
@@ -45,7 +50,7 @@ func handleHistory(cfg *config.Config, st *State, args []string) bool {
 		for i := start; i < len(recs); i++ {
 			r := recs[i]
 			idx := i + 1
-			fmt.Printf("#%d %s | profile=%s | stream=%v | prompt=%s\n", idx, r.Time, r.Profile, r.Stream, truncateRunes(r.Prompt, 120))
+			fmt.Printf("#%d %s | host=%s | profile=%s | stream=%v | prompt=%s\n", idx, r.Time, originHost(r), r.Profile, r.Stream, truncateRunes(r.Prompt, 120))
 			if r.ResponsePrev != "" {
 				fmt.Printf("    ↳ %s\n", truncateRunes(r.ResponsePrev, 160))
 			}
@@ -78,7 +83,7 @@ func handleHistory(cfg *config.Config, st *State, args []string) bool {
 			r := recs[i]
 			if re.MatchString(r.Prompt) || re.MatchString(r.ResponsePrev) {
 				idx := i + 1
-				fmt.Printf("#%d %s | profile=%s | prompt=%s\n", idx, r.Time, r.Profile, truncateRunes(r.Prompt, 120))
+				fmt.Printf("#%d %s | host=%s | profile=%s | prompt=%s\n", idx, r.Time, originHost(r), r.Profile, truncateRunes(r.Prompt, 120))
 				if r.ResponsePrev != "" {
 					fmt.Printf("    ↳ %s\n", truncateRunes(r.ResponsePrev, 160))
 				}
@@ -93,7 +98,7 @@ func handleHistory(cfg *config.Config, st *State, args []string) bool {
 		}
 		return true
 	default:
-		fmt.Println("usage: :history show [N] | :history search <regex> [N] | :history path")
+		fmt.Println("usage: :history show [N] | :history search <regex> [N] | :history path | :history sync [status]")
 		return true
 	}
 }
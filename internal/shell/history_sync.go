@@ -0,0 +1,263 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"kiki-ai-shell/internal/agentd"
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/history"
+)
+
+const (
+	historyPushBatch = 100
+	historyPullLimit = 500
+	historyRetryMax  = 5 * time.Minute
+)
+
+// histSync is the state of the background pusher (one per process).
+var histSync struct {
+	push     sync.Mutex // held by pushHistory, so a batch is never sent twice
+	mu       sync.Mutex
+	running  bool
+	tried    chan struct{} // closed after the first attempt of a push run
+	lastPush time.Time
+	lastErr  error
+}
+
+// appendHistory writes rec to the local history and, with history_sync on,
+// queues it for agentd and starts a background push. A failed push stays
+// queued (<history_path>.queue) and is retried with backoff, at the next
+// start, or by :history sync.
+func appendHistory(cfg *config.Config, rec history.Record) {
+	rec, err := history.Append(cfg.HistoryPath, rec)
+	if err != nil || !cfg.HistorySync {
+		return
+	}
+	if err := history.Enqueue(cfg.HistoryPath, rec); err != nil {
+		setHistorySyncErr(err)
+		return
+	}
+	startHistoryPush(cfg)
+}
+
+func startHistoryPush(cfg *config.Config) {
+	histSync.mu.Lock()
+	if histSync.running {
+		histSync.mu.Unlock()
+		return
+	}
+	histSync.running = true
+	histSync.tried = make(chan struct{})
+	tried := histSync.tried
+	histSync.mu.Unlock()
+
+	go func() {
+		defer func() {
+			histSync.mu.Lock()
+			histSync.running = false
+			histSync.mu.Unlock()
+		}()
+		backoff := 5 * time.Second
+		for first := true; ; first = false {
+			_, err := pushHistory(cfg)
+			if first {
+				close(tried)
+			}
+			if err == nil || needsLogin(err) {
+				// Without a usable token retrying cannot help; the queue
+				// waits for the next login and start.
+				return
+			}
+			time.Sleep(backoff)
+			if backoff *= 2; backoff > historyRetryMax {
+				backoff = historyRetryMax
+			}
+		}
+	}()
+}
+
+// WaitHistoryPush gives the background push up to d for its first attempt,
+// so a one-shot command does not exit before its record is sent. Whatever
+// is not sent stays queued; retries are left to the next run.
+func WaitHistoryPush(d time.Duration) {
+	histSync.mu.Lock()
+	tried := histSync.tried
+	histSync.mu.Unlock()
+	if tried == nil {
+		return
+	}
+	select {
+	case <-tried:
+	case <-time.After(d):
+	}
+}
+
+// StartHistorySync pushes the queue and pulls other machines' records in
+// the background; RunREPL calls it at start when history_sync is on.
+func StartHistorySync(cfg *config.Config) {
+	if !cfg.HistorySync {
+		return
+	}
+	startHistoryPush(cfg)
+	go func() {
+		if _, err := pullHistory(cfg); err != nil && !needsLogin(err) {
+			setHistorySyncErr(err)
+		}
+	}()
+}
+
+// pushHistory sends the queue in batches until it is empty. The background
+// pusher and :history sync take turns: both read Pending, so running them
+// at once would send the same batch twice.
+func pushHistory(cfg *config.Config) (int, error) {
+	histSync.push.Lock()
+	defer histSync.push.Unlock()
+	c, err := historyClient(cfg)
+	if err != nil {
+		setHistorySyncErr(err)
+		return 0, err
+	}
+	sent := 0
+	for {
+		recs, err := history.Pending(cfg.HistoryPath)
+		if err != nil || len(recs) == 0 {
+			setHistorySyncErr(err)
+			return sent, err
+		}
+		if len(recs) > historyPushBatch {
+			recs = recs[:historyPushBatch]
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
+		err = c.PushHistory(ctx, recs)
+		cancel()
+		if err == nil {
+			err = history.Dequeue(cfg.HistoryPath, recs)
+		}
+		if err != nil {
+			setHistorySyncErr(err)
+			return sent, err
+		}
+		sent += len(recs)
+		histSync.mu.Lock()
+		histSync.lastPush = time.Now()
+		histSync.mu.Unlock()
+	}
+}
+
+// pullHistory merges records from agentd into the local history.
+func pullHistory(cfg *config.Config) ([]history.Record, error) {
+	c, err := historyClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
+	defer cancel()
+	recs, err := c.PullHistory(ctx, historyPullLimit)
+	if err != nil {
+		return nil, err
+	}
+	return history.Merge(cfg.HistoryPath, recs)
+}
+
+func historyClient(cfg *config.Config) (*agentd.Client, error) {
+	c, err := agentd.NewClient(agentdURL(cfg, ""))
+	if err != nil {
+		return nil, err
+	}
+	if c.Session == nil {
+		return nil, fmt.Errorf("%s: %w", c.BaseURL, agentd.ErrNotLoggedIn)
+	}
+	return c, nil
+}
+
+func needsLogin(err error) bool {
+	return errors.Is(err, agentd.ErrNotLoggedIn) || errors.Is(err, agentd.ErrExpired) || errors.Is(err, agentd.ErrUnauthorized)
+}
+
+func setHistorySyncErr(err error) {
+	histSync.mu.Lock()
+	histSync.lastErr = err
+	histSync.mu.Unlock()
+}
+
+// RunHistorySync handles `:history sync [status]` and
+// `kiki-ai-shell history sync [status]`.
+func RunHistorySync(cfg *config.Config, args []string) error {
+	if len(args) > 0 && args[0] == "status" {
+		return historySyncStatus(cfg)
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("usage: :history sync [status]")
+	}
+	if !cfg.HistorySync {
+		fmt.Println("note: history_sync is off; syncing once (:set history_sync true to keep it on)")
+	}
+	sent, err := pushHistory(cfg)
+	if err != nil {
+		return fmt.Errorf("push: %w (records stay queued)", err)
+	}
+	added, err := pullHistory(cfg)
+	if err != nil {
+		return fmt.Errorf("pull: %w", err)
+	}
+	fmt.Printf("pushed %d, pulled %d new record(s)%s\n", sent, len(added), hostSummary(added))
+	return nil
+}
+
+func historySyncStatus(cfg *config.Config) error {
+	queued, err := history.Pending(cfg.HistoryPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("history_sync: %v  host: %s  agentd: %s\n", cfg.HistorySync, history.Hostname(), agentdURL(cfg, ""))
+	fmt.Printf("queued: %d (%s)\n", len(queued), history.QueuePath(cfg.HistoryPath))
+	histSync.mu.Lock()
+	defer histSync.mu.Unlock()
+	if !histSync.lastPush.IsZero() {
+		fmt.Println("last push:", histSync.lastPush.Format("2006-01-02 15:04:05"))
+	}
+	if histSync.running {
+		fmt.Println("push: running in the background (retries with backoff on errors)")
+	}
+	if histSync.lastErr != nil {
+		fmt.Fprintln(os.Stderr, "last error:", histSync.lastErr)
+	}
+	return nil
+}
+
+// hostSummary lists where pulled records came from: " from web1 (3), db2 (1)".
+func hostSummary(recs []history.Record) string {
+	if len(recs) == 0 {
+		return ""
+	}
+	counts := map[string]int{}
+	for _, r := range recs {
+		counts[originHost(r)]++
+	}
+	hosts := make([]string, 0, len(counts))
+	for h := range counts {
+		hosts = append(hosts, h)
+	}
+	sort.Strings(hosts)
+	parts := make([]string, len(hosts))
+	for i, h := range hosts {
+		parts[i] = fmt.Sprintf("%s (%d)", h, counts[h])
+	}
+	return " from " + strings.Join(parts, ", ")
+}
+
+// originHost is the host a record was made on; "-" for records written
+// before hosts were recorded.
+func originHost(r history.Record) string {
+	if r.Host == "" {
+		return "-"
+	}
+	return r.Host
+}
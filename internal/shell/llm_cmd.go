@@ -0,0 +1,533 @@
+package shell
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kiki-ai-shell/internal/agent"
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/llm"
+	"kiki-ai-shell/internal/secret"
+)
+
+const llmUsage = "usage: :llm show | set <base_url> | clear | list [tag] | use <name> | add <name> <url> [--provider p] [--model m] [--api-key REF] [--header Name=value] [--ctx N] [--tags a,b] [--desc text] [--project] | rm <name> [--project] | test [name...|--tag t|--all]"
+
+// RunLLM handles the endpoint registry part of `:llm` (list, use, add, rm,
+// test) and `kiki-ai-shell llm ...`.
+func RunLLM(cfg *config.Config, st *State, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", llmUsage)
+	}
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		tag := ""
+		if len(args) > 1 {
+			tag = args[1]
+		}
+		llmList(cfg, tag)
+		return nil
+	case "use":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :llm use <name>   (names: :llm list)")
+		}
+		return llmUse(cfg, st, args[1])
+	case "add":
+		return llmAdd(args[1:])
+	case "rm", "remove":
+		return llmRemove(args[1:])
+	case "test":
+		return llmTest(cfg, args[1:])
+	}
+	return fmt.Errorf("%s", llmUsage)
+}
+
+func llmList(cfg *config.Config, tag string) {
+	m, errs := config.Endpoints()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "llm:", err)
+	}
+	if len(m) == 0 {
+		fmt.Println("no endpoints registered (e.g. :llm add gpu1 http://10.0.2.253:8080 --provider llamacpp --ctx 32768 --tags gpu)")
+		return
+	}
+	fmt.Printf("  %-12s %-9s %-32s %-20s %6s  %s\n", "NAME", "PROVIDER", "URL", "MODEL", "CTX", "TAGS")
+	for _, name := range config.EndpointNames() {
+		ep := m[name]
+		if tag != "" && !ep.HasTag(tag) {
+			continue
+		}
+		mark := " "
+		if name == cfg.LLMEndpoint && ep.URL == cfg.BaseURL {
+			mark = "*"
+		}
+		model, ctx := ep.Model, "-"
+		if model == "" {
+			model = "-"
+		}
+		if ep.CtxSize > 0 {
+			ctx = strconv.Itoa(ep.CtxSize)
+		}
+		tags := strings.Join(ep.Tags, ",")
+		if ep.Insecure() {
+			tags = strings.TrimSpace(tags + "  [INSECURE TLS]")
+		}
+		fmt.Printf("%s %-12s %-9s %-32s %-20s %6s  %s\n", mark, name, ep.Provider, truncateRunes(ep.URL, 32), truncateRunes(model, 20), ctx, tags)
+	}
+}
+
+func llmUse(cfg *config.Config, st *State, name string) error {
+	ep, err := config.LookupEndpoint(name)
+	if err != nil {
+		return err
+	}
+	cfg.UseEndpoint(ep, ":llm use", nil)
+	if st != nil {
+		st.CtxSizeObserved = cfg.CtxSizeObserved
+	}
+	fmt.Printf("llm: %s -> %s (provider=%s model=%s ctx=%d)\n", ep.Name, ep.URL, ep.Provider, cfg.Model, ep.CtxSize)
+	return nil
+}
+
+// clearLLMEndpoint forgets the selected registry entry after the base URL
+// was set by hand.
+func clearLLMEndpoint(cfg *config.Config, origin string) {
+	if cfg.LLMEndpoint != "" {
+		cfg.LLMEndpoint = ""
+		cfg.SetOrigin("llm_endpoint", origin)
+	}
+}
+
+// withBaseURL returns the config for a command given --base-url: a copy with
+// base_url set (normalized and validated like :set base_url) and the
+// registry endpoint cleared, so the REPL's own settings stay as they were.
+// An empty raw returns cfg itself.
+func withBaseURL(cfg *config.Config, raw string) (*config.Config, error) {
+	if strings.TrimSpace(raw) == "" {
+		return cfg, nil
+	}
+	c := cfg.Clone()
+	if err := c.Set("base_url", raw, "flag --base-url"); err != nil {
+		return nil, fmt.Errorf("--base-url: %w", err)
+	}
+	clearLLMEndpoint(c, "flag --base-url")
+	return c, nil
+}
+
+// parseRegistryFlags splits `--key value` / `--key=value` options from
+// positional words; --project, --all and --insecure are bare switches and --header may
+// repeat (values joined by newlines).
+func parseRegistryFlags(args []string) (pos []string, opts map[string]string, err error) {
+	opts = map[string]string{}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "--") {
+			pos = append(pos, a)
+			continue
+		}
+		k, v, ok := strings.Cut(strings.TrimPrefix(a, "--"), "=")
+		if k == "project" || k == "all" || k == "insecure" {
+			opts[k] = "true"
+			continue
+		}
+		if !ok {
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--%s needs a value", k)
+			}
+			i++
+			v = args[i]
+		}
+		if k == "header" && opts[k] != "" {
+			v = opts[k] + "\n" + v
+		}
+		opts[k] = v
+	}
+	return pos, opts, nil
+}
+
+// registryPath is the file :llm add/rm edit: the user config, or the
+// project's ./.kiki.yaml with --project.
+func registryPath(opts map[string]string) string {
+	if opts["project"] != "" {
+		return "./" + config.ProjectConfigName
+	}
+	return config.UserConfigPath()
+}
+
+func llmAdd(args []string) error {
+	pos, opts, err := parseRegistryFlags(args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 2 {
+		return fmt.Errorf("usage: :llm add <name> <url> [--provider %s] [--model m] [--api-key REF | --basic-user U --basic-password REF] [--header Name=value|REF]... [--ca FILE] [--cert FILE --key FILE] [--server-name NAME] [--tls-min 1.2|1.3] [--insecure] [--ctx N] [--tags a,b] [--desc text] [--project]   (REF: env:VAR | file:PATH | keyring:NAME)", strings.Join(config.Providers, "|"))
+	}
+	ep := config.Endpoint{URL: pos[1]}
+	for k, v := range opts {
+		switch k {
+		case "provider":
+			ep.Provider = v
+		case "model":
+			ep.Model = v
+		case "api-key", "api_key":
+			ep.APIKey = v
+		case "basic-user", "basic_user":
+			ep.BasicUser = v
+		case "basic-password", "basic_password":
+			ep.BasicPassword = v
+		case "header":
+			ep.Headers = map[string]string{}
+			for _, kv := range strings.Split(v, "\n") {
+				name, val, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("--header %q: use Name=value (value may be env:/file:/keyring:)", kv)
+				}
+				ep.Headers[strings.TrimSpace(name)] = strings.TrimSpace(val)
+			}
+		case "ca", "ca-file":
+			tlsOpts(&ep).CAFile = v
+		case "cert", "cert-file":
+			tlsOpts(&ep).CertFile = v
+		case "key", "key-file":
+			tlsOpts(&ep).KeyFile = v
+		case "server-name", "sni":
+			tlsOpts(&ep).ServerName = v
+		case "tls-min", "min-tls":
+			tlsOpts(&ep).MinVersion = v
+		case "insecure":
+			tlsOpts(&ep).InsecureSkipVerify = true
+		case "ctx", "ctx-size", "ctx_size":
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("--ctx: %q is not a number", v)
+			}
+			ep.CtxSize = n
+		case "tags":
+			ep.Tags = strings.Split(v, ",")
+		case "desc", "description":
+			ep.Description = v
+		case "project":
+		default:
+			return fmt.Errorf("unknown option --%s", k)
+		}
+	}
+	p, err := config.ParseEndpoint(pos[0], ep)
+	if err != nil {
+		return err
+	}
+	_, exists := mustEndpoints()[p.Name]
+	path := registryPath(opts)
+	if err := config.SaveEndpoint(path, p); err != nil {
+		return err
+	}
+	verb := "added"
+	if exists {
+		verb = "updated"
+	}
+	fmt.Printf("%s endpoint %s (%s) in %s\n", verb, p.Name, p.URL, path)
+	warnInsecure(p)
+	if cur := mustEndpoints()[p.Name]; cur != nil && cur.Source != path {
+		fmt.Fprintf(os.Stderr, "note: %s is also defined in %s, which takes precedence\n", p.Name, cur.Source)
+	}
+	return nil
+}
+
+func llmRemove(args []string) error {
+	pos, opts, err := parseRegistryFlags(args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return fmt.Errorf("usage: :llm rm <name> [--project]")
+	}
+	name := strings.ToLower(pos[0])
+	path := registryPath(opts)
+	if err := config.RemoveEndpoint(path, name); err != nil {
+		return err
+	}
+	fmt.Printf("removed endpoint %s from %s\n", name, path)
+	return nil
+}
+
+func sortedKeys(m map[string]*config.Endpoint) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func tlsOpts(ep *config.Endpoint) *config.EndpointTLS {
+	if ep.TLS == nil {
+		ep.TLS = &config.EndpointTLS{}
+	}
+	return ep.TLS
+}
+
+func mustEndpoints() map[string]*config.Endpoint {
+	m, _ := config.Endpoints()
+	return m
+}
+
+// llmTest pings each selected endpoint and times a short completion.
+// Without arguments it tests the active endpoint (or the bare base URL).
+func llmTest(cfg *config.Config, args []string) error {
+	pos, opts, err := parseRegistryFlags(args)
+	if err != nil {
+		return err
+	}
+	m, errs := config.Endpoints()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "llm:", err)
+	}
+	var targets []*config.Endpoint
+	switch {
+	case opts["all"] != "" || opts["tag"] != "":
+		for _, name := range config.EndpointNames() {
+			if opts["tag"] == "" || m[name].HasTag(opts["tag"]) {
+				targets = append(targets, m[name])
+			}
+		}
+	case len(pos) > 0:
+		for _, name := range pos {
+			ep, err := config.LookupEndpoint(name)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, ep)
+		}
+	default:
+		targets = append(targets, currentEndpoint(cfg, m))
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no endpoints match")
+	}
+
+	failed := 0
+	for _, ep := range targets {
+		r := probeEndpoint(cfg, ep)
+		if r.err != nil {
+			failed++
+			fmt.Printf("%-12s FAIL  %v\n", ep.Name, r.err)
+			continue
+		}
+		src := "usage"
+		if r.estimated {
+			src = "estimated"
+		}
+		fmt.Printf("%-12s ok    ping %s (%s)  reply %s  %d tok (%s)  %.1f tok/s  model=%s\n",
+			ep.Name, fmtMillis(r.ping), r.pingStatus, fmtMillis(r.reply), r.tokens, src, r.tokensPerSec(), r.model)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d endpoint(s) failed", failed, len(targets))
+	}
+	return nil
+}
+
+// currentEndpoint returns the active registry entry, else the entry whose
+// URL is the configured base URL (e.g. chosen by a profile), else an
+// unnamed one for the base URL.
+func currentEndpoint(cfg *config.Config, m map[string]*config.Endpoint) *config.Endpoint {
+	if ep := m[cfg.LLMEndpoint]; ep != nil && ep.URL == cfg.BaseURL {
+		return ep
+	}
+	for _, name := range sortedKeys(m) {
+		if m[name].URL == cfg.BaseURL {
+			return m[name]
+		}
+	}
+	url := strings.TrimSpace(cfg.BaseURL)
+	if url == "" {
+		url = fmt.Sprintf("http://%s:%d", cfg.Host, cfg.Port)
+	}
+	ep := &config.Endpoint{Name: "(current)", URL: url, Provider: "openai", Model: cfg.Model}
+	if os.Getenv("LLM_API_KEY") != "" {
+		ep.APIKey = "env:LLM_API_KEY"
+	}
+	return ep
+}
+
+// endpointURL is the chat completions URL of the current server, for
+// history records (never the headers).
+func endpointURL(cfg *config.Config) string {
+	m, _ := config.Endpoints()
+	return chatURL(currentEndpoint(cfg, m).URL)
+}
+
+// llmEndpoint resolves ep's credentials and TLS settings for a request to
+// url (its chat or ping URL).
+func llmEndpoint(ep *config.Endpoint, url string) (llm.Endpoint, error) {
+	h, err := endpointHeader(ep)
+	if err != nil {
+		return llm.Endpoint{}, err
+	}
+	out := llm.Endpoint{URL: url, Header: h}
+	if t := ep.TLS; t != nil {
+		warnInsecure(ep)
+		out.TLS = &llm.TLSOptions{
+			CAFile:             t.CAFile,
+			CertFile:           t.CertFile,
+			KeyFile:            t.KeyFile,
+			ServerName:         t.ServerName,
+			InsecureSkipVerify: t.InsecureSkipVerify,
+			MinVersion:         t.MinVersion,
+		}
+	}
+	return out, nil
+}
+
+var insecureWarned sync.Map
+
+// warnInsecure complains on stderr, once per endpoint and process, when
+// certificate verification is off.
+func warnInsecure(ep *config.Endpoint) {
+	if !ep.Insecure() {
+		return
+	}
+	if _, done := insecureWarned.LoadOrStore(ep.Name, true); done {
+		return
+	}
+	msg := fmt.Sprintf("WARNING: TLS certificate verification is DISABLED for endpoint %s (%s, tls.insecure_skip_verify).\n"+
+		"         Anyone on the network path can read or change prompts, answers and credentials. Prefer tls.ca_file.", ep.Name, ep.URL)
+	if colorEnabled(os.Stderr) {
+		msg = "\x1b[1;31m" + msg + "\x1b[0m"
+	}
+	fmt.Fprintln(os.Stderr, msg)
+}
+
+// endpointHeader resolves the credentials of ep into request headers.
+func endpointHeader(ep *config.Endpoint) (http.Header, error) {
+	h := http.Header{}
+	for k, v := range ep.Headers {
+		if secret.IsRef(v) {
+			var err error
+			if v, err = secret.Resolve(v); err != nil {
+				return nil, fmt.Errorf("endpoint %s: header %s: %w", ep.Name, k, err)
+			}
+		}
+		h.Set(k, v)
+	}
+	switch {
+	case ep.APIKey != "":
+		key, err := secret.Resolve(ep.APIKey)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: api_key: %w", ep.Name, err)
+		}
+		h.Set("Authorization", "Bearer "+key)
+	case ep.BasicUser != "":
+		pw, err := secret.Resolve(ep.BasicPassword)
+		if err != nil {
+			return nil, fmt.Errorf("endpoint %s: basic_password: %w", ep.Name, err)
+		}
+		h.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(ep.BasicUser+":"+pw)))
+	}
+	return h, nil
+}
+
+type probeResult struct {
+	ping, reply time.Duration
+	pingStatus  string
+	tokens      int
+	estimated   bool
+	model       string
+	err         error
+}
+
+func (r probeResult) tokensPerSec() float64 {
+	if r.reply <= 0 {
+		return 0
+	}
+	return float64(r.tokens) / r.reply.Seconds()
+}
+
+func probeEndpoint(cfg *config.Config, ep *config.Endpoint) probeResult {
+	var r probeResult
+	target, err := llmEndpoint(ep, pingURL(ep))
+	if err != nil {
+		r.err = err
+		return r
+	}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	status, err := llm.Ping(ctx, target, 5)
+	cancel()
+	r.ping = time.Since(start)
+	if err != nil {
+		r.err = fmt.Errorf("ping %s: %w", pingURL(ep), err)
+		return r
+	}
+	r.pingStatus = fmt.Sprintf("HTTP %d", status)
+	if status == http.StatusUnauthorized || status == http.StatusForbidden {
+		r.err = fmt.Errorf("ping %s: HTTP %d (check api_key/basic_user/headers)", pingURL(ep), status)
+		return r
+	}
+
+	r.model = ep.Model
+	if r.model == "" {
+		r.model = cfg.Model
+	}
+	req := llm.ChatRequest{
+		Model:       r.model,
+		Temperature: 0,
+		MaxTokens:   64,
+		Messages: []llm.ChatMessage{
+			{Role: "system", Content: "Answer with the numbers only."},
+			{Role: "user", Content: "Count from 1 to 30, separated by spaces."},
+		},
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), time.Duration(cfg.TimeoutSec)*time.Second)
+	defer cancel()
+	start = time.Now()
+	target.URL = chatURL(ep.URL)
+	cr, err := llm.DoChat(ctx, target, cfg.TimeoutSec, req)
+	r.reply = time.Since(start)
+	if err != nil {
+		r.err = fmt.Errorf("chat: %w", err)
+		return r
+	}
+	if cr.Usage != nil && cr.Usage.CompletionTokens > 0 {
+		r.tokens = cr.Usage.CompletionTokens
+	} else {
+		r.tokens, r.estimated = agent.EstimateTokens(cr.Content()), true
+	}
+	return r
+}
+
+// apiBase returns the OpenAI-style API root of a base URL: it already ends
+// in /v1 for hosted APIs (https://api.openai.com/v1) but not for local
+// servers (http://host:8080).
+func apiBase(base string) string {
+	base = strings.TrimRight(base, "/")
+	if strings.HasSuffix(base, "/v1") {
+		return base
+	}
+	return base + "/v1"
+}
+
+func chatURL(base string) string {
+	return apiBase(base) + "/chat/completions"
+}
+
+// pingURL is a cheap GET that tells whether the server is up.
+func pingURL(ep *config.Endpoint) string {
+	root := strings.TrimSuffix(strings.TrimRight(ep.URL, "/"), "/v1")
+	switch ep.Provider {
+	case "llamacpp":
+		return root + "/health"
+	case "ollama":
+		return root + "/api/tags"
+	}
+	return apiBase(ep.URL) + "/models"
+}
+
+func fmtMillis(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
@@ -0,0 +1,315 @@
+package shell
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"kiki-ai-shell/internal/agent"
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/history"
+	"kiki-ai-shell/internal/logs"
+	"kiki-ai-shell/internal/timeutil"
+	"kiki-ai-shell/internal/usage"
+)
+
+// logAISystemPrompt is used for log-ai unless the user overrides it.
+const logAISystemPrompt = "당신은 리눅스/쿠버네티스 로그 분석 전문가입니다. 첨부된 로그 개요와 레코드만을 근거로 답하세요. " +
+	"오류/경고를 시간순으로 묶어 핵심 사건을 요약하고, 근거 로그 라인(시간/유닛)을 인용한 뒤, 가능한 원인/확인 명령/조치를 제시하세요. " +
+	"[LOG TEMPLATES] 는 반복 라인을 템플릿으로 묶은 것으로, <*> 는 가변 값, xN 은 발생 횟수, $N 은 해당 위치의 예시 값입니다. " +
+	"로그로 판단할 수 없는 부분은 불확실하다고 표시하세요."
+
+const logAIDefaultQuestion = "이 로그에서 오류와 이상 징후를 요약하고, 가능한 원인과 조치를 제시하세요."
+
+// LogAIOptions controls RunLogAI.
+type LogAIOptions struct {
+	File     string
+	Format   string
+	Filter   logs.Filter
+	MaxLines int
+	MaxBytes int64
+	Raw      bool // send raw records instead of mined templates
+	MaxTpl   int
+	System   string
+	Question string
+	BaseURL  string // --base-url, for this command only
+}
+
+// ParseLogAIArgs parses log-ai flags; remaining arguments form the question.
+// Used by both `kiki-ai-shell log-ai ...` and the REPL `:log-ai ...`.
+func ParseLogAIArgs(cfg *config.Config, args []string) (LogAIOptions, error) {
+	fs := flag.NewFlagSet("log-ai", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("file", "", "log file to analyze")
+	format := fs.String("format", "auto", "log format: "+strings.Join(logs.Formats, "|"))
+	level := fs.String("level", "warning", "keep records at this severity or worse (err|warning|notice|info|all)")
+	since := fs.String("since", "", "keep records at/after this time (2024-05-01 10:00, 10:00, -2h)")
+	until := fs.String("until", "", "keep records at/before this time")
+	grep := fs.String("grep", "", "keep records whose message/unit matches this regex")
+	unit := fs.String("unit", "", "keep records whose unit contains this (comma separated)")
+	maxLines := fs.Int("max-lines", 400, "send at most the last N kept records (--raw)")
+	maxTpl := fs.Int("max-templates", 200, "send at most N templates")
+	raw := fs.Bool("raw", false, "send raw records instead of mined templates")
+	maxBytes := fs.Int64("max-bytes", 32<<20, "read at most the last N bytes of the file")
+	system := fs.String("system", "", "override system prompt")
+	baseURL := fs.String("base-url", "", "LLM base URL (overrides LLM_BASE_URL)")
+
+	rest, err := parseInterleaved(fs, joinTimeArgs(args, "since", "until"))
+	if err != nil {
+		return LogAIOptions{}, err
+	}
+	opts := LogAIOptions{
+		File:     strings.TrimSpace(*file),
+		Format:   *format,
+		MaxLines: *maxLines,
+		MaxBytes: *maxBytes,
+		Raw:      *raw,
+		MaxTpl:   *maxTpl,
+		System:   *system,
+	}
+	// allow: log-ai /var/log/messages "question"
+	if opts.File == "" && len(rest) > 0 && fileExists(normalizePath(rest[0])) {
+		opts.File, rest = rest[0], rest[1:]
+	}
+	if opts.File == "" {
+		return opts, fmt.Errorf("log-ai: --file is required")
+	}
+	opts.File = normalizePath(opts.File)
+	opts.Question = strings.TrimSpace(strings.Join(rest, " "))
+
+	if opts.Filter, err = buildLogFilter(*level, *since, *until, *grep, *unit); err != nil {
+		return opts, err
+	}
+	if _, err := withBaseURL(cfg, *baseURL); err != nil {
+		return opts, err
+	}
+	opts.BaseURL = *baseURL
+	return opts, nil
+}
+
+// parseInterleaved parses fs allowing flags after positional arguments
+// (log-ai /var/log/messages --level err) and returns the positional ones.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// joinTimeArgs rejoins a date and a clock given as two words after one of
+// the named flags: the REPL splits arguments on spaces, so
+// `:log-ai --since 2024-05-01 10:00` arrives as two tokens (takeTime does
+// the same for :pcp). Other arguments are returned as they are.
+func joinTimeArgs(args []string, names ...string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		out = append(out, a)
+		name, val, hasVal := strings.Cut(strings.TrimLeft(a, "-"), "=")
+		if !strings.HasPrefix(a, "-") || !slices.Contains(names, name) {
+			continue
+		}
+		if !hasVal {
+			if i+1 == len(args) {
+				break
+			}
+			i++
+			val = args[i]
+			out = append(out, val)
+		}
+		if i+1 < len(args) {
+			if _, n, err := takeTime([]string{val, args[i+1]}); err == nil && n == 2 {
+				i++
+				out[len(out)-1] += " " + args[i]
+			}
+		}
+	}
+	return out
+}
+
+// buildLogFilter turns the shared --level/--since/--until/--grep/--unit flag
+// values into a logs.Filter. An empty level keeps every severity.
+func buildLogFilter(level, since, until, grep, unit string) (logs.Filter, error) {
+	f := logs.Filter{MaxSeverity: logs.SevDebug}
+	var err error
+	if strings.TrimSpace(level) != "" {
+		if f.MaxSeverity, err = logs.ParseSeverity(level); err != nil {
+			return f, err
+		}
+	}
+	if since != "" {
+		if f.Since, err = timeutil.ParseTime(since, time.Now()); err != nil {
+			return f, err
+		}
+	}
+	if until != "" {
+		if f.Until, err = timeutil.ParseTime(until, time.Now()); err != nil {
+			return f, err
+		}
+	}
+	if grep != "" {
+		if f.Grep, err = regexp.Compile(grep); err != nil {
+			return f, fmt.Errorf("invalid --grep: %w", err)
+		}
+	}
+	for _, u := range strings.Split(unit, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			f.Units = append(f.Units, u)
+		}
+	}
+	return f, nil
+}
+
+// RunLogAI parses a log file into structured records, keeps only the relevant
+// ones (severity/time/grep) and asks the LLM about that condensed view.
+func RunLogAI(cfg *config.Config, st *State, opts LogAIOptions) error {
+	cfg, err := withBaseURL(cfg, opts.BaseURL)
+	if err != nil {
+		return err
+	}
+	recs, err := logs.ParseFile(opts.File, opts.Format, opts.MaxBytes)
+	if err != nil {
+		return err
+	}
+	kept := logs.Apply(recs, opts.Filter)
+	overview := logs.Overview(recs, kept, opts.Filter)
+	fmt.Fprintf(os.Stderr, "(log-ai: %s)\n", strings.ReplaceAll(overview, "\n", " | "))
+	if len(kept) == 0 {
+		fmt.Println("(no records matched the filter; try --level info or a wider --since)")
+		return nil
+	}
+
+	q := opts.Question
+	if q == "" {
+		q = logAIDefaultQuestion
+	}
+	var b strings.Builder
+	b.WriteString(q)
+	fmt.Fprintf(&b, "\n\n[LOG OVERVIEW] file=%s\n%s\n", opts.File, overview)
+	if opts.Raw {
+		shown := len(kept)
+		if opts.MaxLines > 0 && shown > opts.MaxLines {
+			shown = opts.MaxLines
+		}
+		fmt.Fprintf(&b, "\n[LOG RECORDS] (last %d of %d kept)\n", shown, len(kept))
+		b.WriteString(logs.Render(kept, opts.MaxLines))
+	} else {
+		// Repeated lines differ only by ids/numbers; templates keep every distinct
+		// event while the raw tail preserves exact ordering of the latest ones.
+		m := logs.Mine(kept)
+		fmt.Fprintf(os.Stderr, "(log-ai: %d records -> %d templates)\n", len(kept), len(m.Templates()))
+		b.WriteString("\n[LOG TEMPLATES] ")
+		b.WriteString(m.RenderTemplates(opts.MaxTpl))
+		tail := 20
+		if tail > len(kept) {
+			tail = len(kept)
+		}
+		fmt.Fprintf(&b, "\n\n[LATEST RECORDS] (last %d)\n", tail)
+		b.WriteString(logs.Render(kept, tail))
+	}
+
+	sys := opts.System
+	if strings.TrimSpace(sys) == "" {
+		sys = logAISystemPrompt
+	}
+	sys = systemPromptWithCtx(cfg, st, sys)
+	out, err := askCondensed(cfg, st, sys, q, b.String())
+	if err != nil {
+		return err
+	}
+	fmt.Println(out)
+	saveAnswer(cfg, st, sys, q, []string{opts.File + " (" + opts.Filter.Describe() + ")"}, nil, out)
+	return nil
+}
+
+// askCondensed sends pre-condensed content through agent.AskWithAutoChunk so that
+// oversized inputs are still chunked against the known ctx-size.
+func askCondensed(cfg *config.Config, st *State, sys, question, content string) (string, error) {
+	endpoint, err := buildEndpoint(cfg)
+	if err != nil {
+		return "", err
+	}
+	timeout := cfg.TimeoutSec
+	if timeout <= 0 {
+		timeout = 60
+	}
+	maxCtx := st.CtxSizeObserved
+	if maxCtx <= 0 {
+		maxCtx = st.CtxSizeTarget
+	}
+	// Chunked runs make several requests; give them a proportionally longer deadline.
+	deadline := time.Duration(timeout) * time.Second
+	if maxCtx > 0 {
+		if n := agent.EstimateTokens(content)/maxCtx + 2; n > 1 {
+			deadline *= time.Duration(n)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), deadline)
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	out, err := agent.AskWithAutoChunk(ctx, endpoint, sys, question, content, agent.AskOpts{
+		MaxCtx:    maxCtx,
+		Reserve:   768,
+		Timeout:   timeout,
+		Stream:    false,
+		Model:     cfg.Model,
+		Temp:      cfg.Temp,
+		MaxTokens: cfg.MaxTokens,
+	})
+	if err != nil {
+		if obs := parseCtxSizeFromError(err); obs > 0 {
+			st.CtxSizeObserved = obs
+		}
+		return "", err
+	}
+	if st.NoFence {
+		out = StripMarkdownFences(out)
+	}
+	return out, nil
+}
+
+// saveAnswer records an answer in LastAnswer, usage log, RAG and history,
+// the same way Ask does.
+func saveAnswer(cfg *config.Config, st *State, sys, prompt string, files, hashes []string, out string) {
+	now := time.Now().Format(time.RFC3339)
+	cwd, _ := os.Getwd()
+	st.LastAnswer = out
+	if st.Usage != nil {
+		st.Usage.Append(usage.Record{Time: now, User: st.User, Type: "ask", Cwd: cwd, Prompt: prompt, RespPrev: truncateRunes(out, cfg.HistoryPreview)})
+		_ = st.RAG.AddText("usage:"+now+":ask", "[ask] "+prompt, 8000)
+	}
+	if cfg.HistoryEnabled {
+		appendHistory(cfg, history.Record{
+			Time: now, Endpoint: endpointURL(cfg), Profile: st.Profile, Model: cfg.Model,
+			Temperature: cfg.Temp, MaxTokens: cfg.MaxTokens, Stream: false,
+			SystemPrompt: sys, Ctx: st.Ctx, Prompt: prompt, Files: files,
+			FileHashes: hashes, Cwd: cwd, ResponsePrev: truncateRunes(out, cfg.HistoryPreview),
+		})
+	}
+}
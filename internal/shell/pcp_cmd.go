@@ -0,0 +1,316 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/pcp"
+	"kiki-ai-shell/internal/timeutil"
+)
+
+// pcpDiagSystemPrompt grounds the answer on the attached PCP snapshot.
+const pcpDiagSystemPrompt = "당신은 시니어 SRE/리눅스 성능 엔지니어입니다. 첨부된 [PCP SNAPSHOT] 지표만을 근거로 사용자의 질문에 답하세요. " +
+	"결론을 먼저 말하고, 근거가 되는 지표 값을 인용한 뒤, 가능한 원인/확인 명령/조치 순으로 정리하세요. " +
+	"지표로 판단할 수 없는 부분은 불확실하다고 표시하세요."
+
+// parseWindowFlag consumes a leading "-w <dur>" / "--window <dur>" from args.
+func parseWindowFlag(args []string, def time.Duration) (time.Duration, []string, error) {
+	if len(args) >= 2 && (args[0] == "-w" || args[0] == "--window") {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			return 0, args, fmt.Errorf("invalid window: %s", args[1])
+		}
+		return d, args[2:], nil
+	}
+	return def, args, nil
+}
+
+// handlePCPAsk implements ":pcp ask [-w 10s] <question>".
+// It samples the curated metric set and asks the LLM with the snapshot attached.
+func handlePCPAsk(cfg *config.Config, st *State, args []string) {
+	def := pcp.DefaultDiagWindow
+	if d := st.PCP.RangeDuration(); d > 0 {
+		def = d // archive replay: diagnose the whole selected range
+	}
+	window, rest, err := parseWindowFlag(args, def)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	q := strings.TrimSpace(strings.Join(rest, " "))
+	if q == "" {
+		fmt.Println("usage: :pcp ask [-w 10s] <question>")
+		return
+	}
+	fmt.Printf("(sampling PCP metrics on %s for %s ...)\n", st.PCP.HostLabel(), window)
+	snap, err := st.PCP.Diagnose(window)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pcp error:", err)
+		return
+	}
+	Ask(cfg, st, q+"\n\n"+snap.Context(), pcpDiagSystemPrompt)
+}
+
+// handlePCPWatch implements ":pcp watch [-i 2s] <metric...> <duration>".
+// It samples for the whole duration and prints min/mean/p95/max, trend and anomalies.
+func handlePCPWatch(st *State, args []string) {
+	usage := "usage: :pcp watch [-i 2s] <metric...> <duration>   (e.g. :pcp watch kernel.all.load disk.dev.avactive 1m)"
+	interval := 1 * time.Second
+	if len(args) >= 2 && (args[0] == "-i" || args[0] == "--interval") {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			fmt.Println("invalid interval:", args[1])
+			return
+		}
+		interval = d
+		args = args[2:]
+	}
+	if len(args) < 2 {
+		fmt.Println(usage)
+		return
+	}
+	window, err := time.ParseDuration(args[len(args)-1])
+	if err != nil || window <= 0 {
+		fmt.Println(usage)
+		return
+	}
+	metrics := args[:len(args)-1]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Printf("(sampling %d metric(s) on %s every %s for %s; Ctrl-C aborts ...)\n", len(metrics), st.PCP.HostLabel(), interval, window)
+	w, err := st.PCP.SampleWindow(ctx, metrics, window, interval)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pcp error:", err)
+		return
+	}
+	fmt.Println(pcp.FormatStats(w.Host, window, w.Summarize()))
+}
+
+// handlePCPGroup implements ":pcp group add <name> <spec> | rm <name> | list".
+func handlePCPGroup(st *State, args []string) {
+	usage := "usage: :pcp group add <name> <hosts> | :pcp group rm <name> | :pcp group list"
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return
+	}
+	if st.PCPGroups == nil {
+		st.PCPGroups = map[string]string{}
+	}
+	switch strings.ToLower(args[0]) {
+	case "add", "set":
+		if len(args) < 3 {
+			fmt.Println("usage: :pcp group add <name> <hosts>   (e.g. kube-master[1:3],kube-worker[1:5])")
+			return
+		}
+		spec := strings.Join(args[2:], ",")
+		hosts, err := pcp.ExpandHosts(spec)
+		if err != nil {
+			fmt.Println("invalid hosts:", err)
+			return
+		}
+		st.PCPGroups[args[1]] = spec
+		fmt.Printf("pcp group %s: %d host(s)\n", args[1], len(hosts))
+	case "rm", "del":
+		if len(args) < 2 {
+			fmt.Println("usage: :pcp group rm <name>")
+			return
+		}
+		delete(st.PCPGroups, args[1])
+		fmt.Println("pcp group removed:", args[1])
+	case "list", "show":
+		if len(st.PCPGroups) == 0 {
+			fmt.Println("(no pcp groups)")
+			return
+		}
+		names := make([]string, 0, len(st.PCPGroups))
+		for k := range st.PCPGroups {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			hosts, _ := pcp.ExpandHosts(st.PCPGroups[k])
+			fmt.Printf("%s = %s (%d hosts)\n", k, st.PCPGroups[k], len(hosts))
+		}
+	default:
+		fmt.Println(usage)
+	}
+}
+
+// resolveHosts returns the hosts of a named group, or expands target as a host spec.
+func resolveHosts(st *State, target string) ([]string, error) {
+	if spec, ok := st.PCPGroups[target]; ok {
+		return pcp.ExpandHosts(spec)
+	}
+	return pcp.ExpandHosts(target)
+}
+
+// handlePCPCompare implements ":pcp compare [-t 10s] <group|hosts>".
+func handlePCPCompare(cfg *config.Config, st *State, args []string) {
+	timeout := time.Duration(cfg.PCPTimeoutSec) * time.Second
+	if len(args) >= 2 && (args[0] == "-t" || args[0] == "--timeout") {
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d <= 0 {
+			fmt.Println("invalid timeout:", args[1])
+			return
+		}
+		timeout = d
+		args = args[2:]
+	}
+	if len(args) < 1 {
+		fmt.Println("usage: :pcp compare [-t 10s] <group|hosts>   (e.g. :pcp compare kube-worker[1:5])")
+		return
+	}
+	hosts, err := resolveHosts(st, strings.Join(args, ","))
+	if err != nil {
+		fmt.Println("invalid hosts:", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+	go func() {
+		select {
+		case <-sigCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Printf("(querying %d host(s), timeout %s per host ...)\n", len(hosts), timeout)
+	res := pcp.FanOut(ctx, hosts, timeout, 0)
+	fmt.Println(pcp.FormatComparison(res))
+}
+
+var reClock = regexp.MustCompile(`^\d{1,2}:\d{2}(:\d{2})?$`)
+
+// takeTime parses a time starting at args[0]. A date followed by a clock token
+// ("2024-05-01 10:00") is joined. Returns the time and the number of tokens used.
+func takeTime(args []string) (time.Time, int, error) {
+	if len(args) == 0 {
+		return time.Time{}, 0, fmt.Errorf("missing time")
+	}
+	if len(args) >= 2 && reClock.MatchString(args[1]) && !reClock.MatchString(args[0]) {
+		if t, err := timeutil.ParseTime(args[0]+" "+args[1], time.Now()); err == nil {
+			return t, 2, nil
+		}
+	}
+	t, err := timeutil.ParseTime(args[0], time.Now())
+	return t, 1, err
+}
+
+// parseRangeArgs parses "[--from T] [--to T]" (T as accepted by takeTime).
+func parseRangeArgs(args []string) (time.Time, time.Time, error) {
+	var from, to time.Time
+	for i := 0; i < len(args); {
+		switch args[i] {
+		case "--from", "-S":
+			t, n, err := takeTime(args[i+1:])
+			if err != nil {
+				return from, to, err
+			}
+			from, i = t, i+1+n
+		case "--to", "-T":
+			t, n, err := takeTime(args[i+1:])
+			if err != nil {
+				return from, to, err
+			}
+			to, i = t, i+1+n
+		default:
+			return from, to, fmt.Errorf("unexpected argument: %s", args[i])
+		}
+	}
+	return from, to, nil
+}
+
+// handlePCPArchive implements ":pcp archive <path> [--from T] [--to T] | off".
+func handlePCPArchive(st *State, args []string) {
+	usage := "usage: :pcp archive <path|pmlogger-dir> [--from T] [--to T] | :pcp archive off"
+	if len(args) < 1 {
+		if st.PCP.Archive == "" {
+			fmt.Println("pcp archive: (live mode)")
+		} else {
+			fmt.Println("pcp archive:", st.PCP.HostLabel())
+		}
+		fmt.Println(usage)
+		return
+	}
+	if strings.EqualFold(args[0], "off") || strings.EqualFold(args[0], "live") {
+		_ = st.PCP.SetArchive("")
+		fmt.Println("pcp: live mode @", st.PCP.HostLabel())
+		return
+	}
+	from, to, err := parseRangeArgs(args[1:])
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println(usage)
+		return
+	}
+	if err := st.PCP.SetArchive(normalizePath(args[0])); err != nil {
+		fmt.Println("pcp error:", err)
+		return
+	}
+	if err := st.PCP.SetRange(from, to); err != nil {
+		fmt.Println("pcp error:", err)
+		return
+	}
+	fmt.Println("pcp source:", st.PCP.HostLabel())
+}
+
+// handlePCPRange implements ":pcp range <from> [<to>] | :pcp range clear".
+func handlePCPRange(st *State, args []string) {
+	usage := "usage: :pcp range <from> [<to>] | :pcp range clear   (e.g. :pcp range 2024-05-01 09:50 2024-05-01 10:20, :pcp range -3h -2h)"
+	if st.PCP.Archive == "" {
+		fmt.Println("pcp range applies to archives only (use :pcp archive <path> first)")
+		return
+	}
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return
+	}
+	if strings.EqualFold(args[0], "clear") {
+		_ = st.PCP.SetRange(time.Time{}, time.Time{})
+		fmt.Println("pcp source:", st.PCP.HostLabel())
+		return
+	}
+	from, n, err := takeTime(args)
+	if err != nil {
+		fmt.Println(err)
+		fmt.Println(usage)
+		return
+	}
+	var to time.Time
+	if len(args) > n {
+		if to, _, err = takeTime(args[n:]); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+	if err := st.PCP.SetRange(from, to); err != nil {
+		fmt.Println("pcp error:", err)
+		return
+	}
+	fmt.Println("pcp source:", st.PCP.HostLabel())
+}
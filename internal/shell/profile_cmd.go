@@ -0,0 +1,148 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"kiki-ai-shell/internal/config"
+)
+
+const profileUsage = "usage: :profile <name> | :profile list | :profile show [name]"
+
+// RunProfile handles `:profile ...` and `kiki-ai-shell profile ...`.
+func RunProfile(cfg *config.Config, st *State, args []string) error {
+	if len(args) < 1 {
+		fmt.Println("profile:", st.Profile)
+		fmt.Println(profileUsage)
+		return nil
+	}
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		profileList(st)
+		return nil
+	case "show":
+		name := st.Profile
+		if len(args) > 1 {
+			name = args[1]
+		}
+		return profileShow(name)
+	}
+	return switchProfile(cfg, st, args[0])
+}
+
+// switchProfile applies a profile and syncs the shell state it affects.
+func switchProfile(cfg *config.Config, st *State, name string) error {
+	prev, prevName := *cfg, cfg.Profile
+	cfg.Profile = strings.ToLower(strings.TrimSpace(name))
+	if err := config.ApplyProfile(cfg); err != nil {
+		cfg.Profile = prevName
+		return err
+	}
+	st.Profile = cfg.Profile
+	// Only push fields the profile actually changed, so :rag on / :nofence
+	// set by hand are not reset by an unrelated profile.
+	if cfg.NoFence != prev.NoFence {
+		st.NoFence = cfg.NoFence
+	}
+	if cfg.RAGEnabled != prev.RAGEnabled && st.RAG != nil {
+		st.RAG.Enabled = cfg.RAGEnabled
+	}
+	fmt.Printf("profile: %s (model=%s temp=%.2f max_tokens=%d)\n", st.Profile, cfg.Model, cfg.Temp, cfg.MaxTokens)
+	return nil
+}
+
+func profileList(st *State) {
+	m, errs := config.Profiles()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "profile:", err)
+	}
+	for _, name := range config.ProfileNames() {
+		p := m[name]
+		mark := " "
+		if strings.EqualFold(name, st.Profile) {
+			mark = "*"
+		}
+		desc := p.Description
+		if p.Extends != "" {
+			desc = "(extends " + p.Extends + ") " + desc
+		}
+		fmt.Printf("%s %-16s %-24s %s\n", mark, name, profileSource(p), desc)
+	}
+}
+
+func profileSource(p *config.Profile) string {
+	if p.Source == "builtin" {
+		return "builtin"
+	}
+	return truncateRunes(p.Source, 24)
+}
+
+func profileShow(name string) error {
+	chain, err := config.ProfileChain(name)
+	if err != nil {
+		return err
+	}
+	p := chain[len(chain)-1]
+	fmt.Printf("name:    %s\nsource:  %s\n", p.Name, p.Source)
+	if p.Description != "" {
+		fmt.Println("desc:   ", p.Description)
+	}
+	if len(chain) > 1 {
+		names := make([]string, len(chain))
+		for i, c := range chain {
+			names[i] = c.Name
+		}
+		fmt.Println("chain:  ", strings.Join(names, " -> "))
+	}
+	// Later profiles in the chain win, like ApplyProfile.
+	set := map[string]string{}
+	var keys []string
+	put := func(k, v string) {
+		if _, ok := set[k]; !ok {
+			keys = append(keys, k)
+		}
+		set[k] = v
+	}
+	for _, c := range chain {
+		if c.Model != nil {
+			put("model", *c.Model)
+		}
+		if c.Temp != nil {
+			put("temperature", fmt.Sprint(*c.Temp))
+		}
+		if c.MaxTokens != nil {
+			put("max_tokens", fmt.Sprint(*c.MaxTokens))
+		}
+		if c.RAG != nil {
+			put("rag", fmt.Sprint(*c.RAG))
+		}
+		if c.RAGTopK != nil {
+			put("rag_topk", fmt.Sprint(*c.RAGTopK))
+		}
+		if c.RAGMaxChars != nil {
+			put("rag_max_chars", fmt.Sprint(*c.RAGMaxChars))
+		}
+		if c.Endpoint != nil {
+			put("endpoint", *c.Endpoint)
+		}
+		if c.NoFence != nil {
+			put("nofence", fmt.Sprint(*c.NoFence))
+		}
+		if c.System != nil {
+			put("system_prompt", strings.TrimSpace(*c.System))
+		}
+	}
+	for _, k := range keys {
+		v := set[k]
+		if k == "system_prompt" {
+			fmt.Printf("%-14s |\n", k+":")
+			for _, line := range strings.Split(v, "\n") {
+				fmt.Println("    " + line)
+			}
+			continue
+		}
+		fmt.Printf("%-14s %s\n", k+":", v)
+	}
+	return nil
+}
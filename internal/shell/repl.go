@@ -12,6 +12,7 @@ import (
 
 	"kiki-ai-shell/internal/auth"
 	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/gen"
 	"kiki-ai-shell/internal/ui"
 	"kiki-ai-shell/internal/usage"
 )
@@ -45,13 +46,14 @@ func renderHeader(cfg *config.Config, st *State, uicfg ui.Config) {
 		LLM:         llm,
 		Profile:     st.Profile,
 		Stream:      st.Stream,
-		Files:       st.Files,
+		Files:       attachmentPaths(st.Files),
 		NoFence:     st.NoFence,
 		CtxObserved: st.CtxSizeObserved,
 		CtxTarget:   st.CtxSizeTarget,
 		Cluster:     cluster,
 		Namespace:   ns,
 		PCP:         st.PCP.Display(),
+		Alert:       st.Watch.HeaderSummary(),
 	}
 	ui.RenderHeader(uicfg, h)
 }
@@ -77,8 +79,10 @@ func RunREPL(cfg *config.Config, uicfg ui.Config) {
 			_ = st.RAG.AddText("usage:"+r.Time, text, 4000)
 		}
 	}
+	StartHistorySync(cfg)
 
 	for {
+		handleWatchEvents(cfg, st)
 		renderHeader(cfg, st, uicfg)
 		line, err := ui.ReadLineRaw(promptLine(st), completeLine)
 		if err != nil {
@@ -170,9 +174,9 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		return
 
 	case "llm":
-		// :llm show | :llm set <base_url> | :llm clear
+		// :llm show | :llm set <base_url> | :llm clear | :llm list|use|add|rm|test ...
 		if len(args) < 1 {
-			fmt.Println("usage: :llm show | :llm set <base_url> | :llm clear")
+			fmt.Println(llmUsage)
 			return
 		}
 		sub := strings.ToLower(strings.TrimSpace(args[0]))
@@ -183,9 +187,14 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 			} else {
 				fmt.Printf("LLM_BASE_URL: %s\n", cfg.BaseURL)
 			}
+			if cfg.LLMEndpoint != "" {
+				fmt.Printf("endpoint: %s (from %s)\n", cfg.LLMEndpoint, cfg.Origin("llm_endpoint"))
+			}
 			return
 		case "clear":
 			cfg.BaseURL = ""
+			cfg.SetOrigin("base_url", ":llm clear")
+			clearLLMEndpoint(cfg, ":llm clear")
 			fmt.Println("LLM_BASE_URL cleared (fallback to host:port)")
 			if uicfg.FixedHeader {
 				renderHeader(cfg, st, uicfg)
@@ -202,11 +211,22 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 				url = "http://" + url
 			}
 			cfg.BaseURL = strings.TrimRight(url, "/")
+			cfg.SetOrigin("base_url", ":llm set")
+			clearLLMEndpoint(cfg, ":llm set")
 			fmt.Println("LLM_BASE_URL set:", cfg.BaseURL)
 			if uicfg.FixedHeader {
 				renderHeader(cfg, st, uicfg)
 			}
 			return
+		case "list", "ls", "use", "add", "rm", "remove", "test":
+			if err := RunLLM(cfg, st, args); err != nil {
+				fmt.Fprintln(os.Stderr, "llm error:", err)
+				return
+			}
+			if sub == "use" && uicfg.FixedHeader {
+				renderHeader(cfg, st, uicfg)
+			}
+			return
 		default:
 			// shorthand: :llm http://...
 			url := strings.TrimSpace(args[0])
@@ -215,25 +235,37 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 					url = "http://" + url
 				}
 				cfg.BaseURL = strings.TrimRight(url, "/")
+				cfg.SetOrigin("base_url", ":llm set")
+				clearLLMEndpoint(cfg, ":llm set")
 				fmt.Println("LLM_BASE_URL set:", cfg.BaseURL)
 				if uicfg.FixedHeader {
 					renderHeader(cfg, st, uicfg)
 				}
 				return
 			}
-			fmt.Println("usage: :llm show | :llm set <base_url> | :llm clear")
+			fmt.Println(llmUsage)
 			return
 		}
 
 	case "gen":
-		// :gen <path> <prompt...>
+		// :gen [target] <path> <prompt...>   (target: ansible, ...)
+		target := ""
+		if len(args) > 0 && gen.Lookup(args[0]) != nil {
+			target, args = strings.ToLower(args[0]), args[1:]
+		}
 		if len(args) < 2 {
-			fmt.Println("usage: :gen <path> <prompt...>")
+			fmt.Println("usage: :gen [" + strings.Join(gen.Names(), "|") + "] <path> <prompt...>")
 			return
 		}
 		out := args[0]
 		p := strings.TrimSpace(strings.Join(args[1:], " "))
-		if err := Gen(cfg, st, out, p); err != nil {
+		var err error
+		if target != "" {
+			err = GenTarget(cfg, st, out, p, replGenOptions(cfg, st, target))
+		} else {
+			err = Gen(cfg, st, out, p)
+		}
+		if err != nil {
 			fmt.Fprintln(os.Stderr, "gen error:", err)
 		}
 		if uicfg.FixedHeader {
@@ -241,10 +273,38 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		}
 		return
 
+	case "scaffold":
+		// :scaffold <role|k8s|files> <dir> <prompt...>
+		if len(args) < 3 || gen.LookupScaffold(args[0]) == nil {
+			fmt.Println("usage: :scaffold <" + strings.Join(gen.ScaffoldNames(), "|") + "> <dir> <prompt...>")
+			return
+		}
+		p := strings.TrimSpace(strings.Join(args[2:], " "))
+		if err := RunScaffold(cfg, st, args[1], p, replScaffoldOptions(cfg, st, strings.ToLower(args[0]))); err != nil {
+			fmt.Fprintln(os.Stderr, "scaffold error:", err)
+		}
+		if uicfg.FixedHeader {
+			renderHeader(cfg, st, uicfg)
+		}
+		return
+
+	case "tpl":
+		// :tpl list | show <name> | run <name> [key=value ...] [extra...] | new <name> | path
+		// Re-split the raw line so quoted values (msg="a b") stay one token.
+		if err := RunTpl(cfg, st, splitQuoted(cmdline)[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if uicfg.FixedHeader {
+			renderHeader(cfg, st, uicfg)
+		}
+		return
+
 	case "pcp":
-		// :pcp show | :pcp host <host|local> | :pcp cpu | :pcp mem | :pcp load | :pcp raw <metric...>
+		// :pcp show | :pcp host <host|local> | :pcp cpu | :pcp mem | :pcp load | :pcp raw <metric...> | :pcp ask <question> | :pcp watch <metric...> <duration>
+		// :pcp group add|rm|list ... | :pcp compare <group|hosts>
+		// :pcp archive <path> [--from T] [--to T] | off | :pcp range <from> [to] | clear
 		if len(args) < 1 {
-			fmt.Println("usage: :pcp show | :pcp host <host|local> | :pcp cpu|mem|load | :pcp raw <metric...>")
+			fmt.Println("usage: :pcp show | :pcp host <host|local> | :pcp cpu|mem|load | :pcp raw <metric...> | :pcp ask <question> | :pcp watch <metric...> <duration> | :pcp group ... | :pcp compare <group|hosts> | :pcp archive <path> | :pcp range <from> [to]")
 			return
 		}
 		sub := strings.ToLower(args[0])
@@ -287,6 +347,27 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 			}
 			fmt.Println(out)
 			return
+		case "ask":
+			handlePCPAsk(cfg, st, args[1:])
+			return
+		case "watch":
+			handlePCPWatch(st, args[1:])
+			return
+		case "group":
+			handlePCPGroup(st, args[1:])
+			return
+		case "compare":
+			handlePCPCompare(cfg, st, args[1:])
+			return
+		case "archive":
+			handlePCPArchive(st, args[1:])
+			if uicfg.FixedHeader {
+				renderHeader(cfg, st, uicfg)
+			}
+			return
+		case "range":
+			handlePCPRange(st, args[1:])
+			return
 		case "raw":
 			if len(args) < 2 {
 				fmt.Println("usage: :pcp raw <metric...>")
@@ -309,29 +390,90 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 				}
 				return
 			}
-			fmt.Println("usage: :pcp show | :pcp host <host|local> | :pcp cpu|mem|load | :pcp raw <metric...>")
+			fmt.Println("usage: :pcp show | :pcp host <host|local> | :pcp cpu|mem|load | :pcp raw <metric...> | :pcp ask <question> | :pcp watch <metric...> <duration> | :pcp group ... | :pcp compare <group|hosts> | :pcp archive <path> | :pcp range <from> [to]")
 			return
 		}
 
+	case "log-ai", "logai":
+		opts, err := ParseLogAIArgs(cfg, args)
+		if err != nil {
+			fmt.Println(err)
+			fmt.Println("usage: :log-ai <file> [--level err] [--since T] [--until T] [--grep RE] [--unit U] [question...]")
+			return
+		}
+		if err := RunLogAI(cfg, st, opts); err != nil {
+			fmt.Fprintln(os.Stderr, "log-ai error:", err)
+		}
+		return
+
+	case "watch":
+		handleWatch(cfg, st, args, cmdline)
+		if uicfg.FixedHeader {
+			renderHeader(cfg, st, uicfg)
+		}
+		return
+
 	case "bash":
-		fmt.Println("\n[Entering interactive bash] (type 'exit' to return)\n")
+		fmt.Print("\n[Entering interactive bash] (type 'exit' to return)\n\n")
 		if err := runInteractiveBash(); err != nil {
 			fmt.Fprintln(os.Stderr, "pty bash error:", err)
 		}
-		fmt.Println("\n[Back to KIKI]\n")
+		fmt.Print("\n[Back to KIKI]\n\n")
+		if uicfg.FixedHeader {
+			renderHeader(cfg, st, uicfg)
+		}
+		return
+
+	case "config":
+		// :config show [filter] | :config paths | :config check | :config save [key...]
+		if err := RunConfig(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, "config:", err)
+		}
+		return
+
+	case "set":
+		// :set <key> <value...>
+		if err := handleSet(cfg, st, args, cmdline); err != nil {
+			fmt.Fprintln(os.Stderr, "set:", err)
+		}
 		if uicfg.FixedHeader {
 			renderHeader(cfg, st, uicfg)
 		}
 		return
 
+	case "agentd":
+		// :agentd status | login [user] | register [user] | logout
+		sub := "status"
+		if len(args) > 0 {
+			sub, args = strings.ToLower(args[0]), args[1:]
+		}
+		if err := RunAgentd(cfg, sub, args); err != nil {
+			fmt.Fprintln(os.Stderr, "agentd:", err)
+		}
+		return
+
+	case "secret":
+		// :secret list | :secret set <name> | :secret rm <name>
+		if err := RunSecret(args); err != nil {
+			fmt.Fprintln(os.Stderr, "secret:", err)
+		}
+		return
+
+	case "get":
+		// :get [key...]
+		if err := handleGet(cfg, args); err != nil {
+			fmt.Fprintln(os.Stderr, "get:", err)
+		}
+		return
+
 	case "profile":
-		if len(args) < 1 {
-			fmt.Println("usage: :profile fast|deep|none")
-			return
+		// :profile <name> | :profile list | :profile show [name]
+		if err := RunProfile(cfg, st, args); err != nil {
+			fmt.Fprintln(os.Stderr, "profile error:", err)
+		}
+		if uicfg.FixedHeader {
+			renderHeader(cfg, st, uicfg)
 		}
-		st.Profile = args[0]
-		cfg.Profile = st.Profile
-		config.ApplyProfile(cfg)
 		return
 
 	case "stream":
@@ -342,6 +484,7 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		v := strings.ToLower(args[0])
 		st.Stream = (v == "on" || v == "1" || v == "true")
 		cfg.Stream = st.Stream
+		cfg.SetOrigin("stream", ":stream")
 		return
 
 	case "nofence":
@@ -358,6 +501,7 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		on := (v == "on" || v == "1" || v == "true")
 		st.NoFence = on
 		cfg.NoFence = on
+		cfg.SetOrigin("nofence", ":nofence")
 		return
 
 	case "ui":
@@ -439,6 +583,7 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		}
 		st.CtxSizeTarget = n
 		cfg.CtxSizeTarget = n
+		cfg.SetOrigin("ctx_target", ":ctx-size")
 		fmt.Println("ctx-size target set:", n)
 		fmt.Println("note: actual ctx-size requires llama.cpp server restart with --ctx-size", n)
 		return
@@ -475,23 +620,28 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 		switch sub {
 		case "add":
 			if len(args) < 2 {
-				fmt.Println("usage: :file add /path")
+				fmt.Println("usage: :file add /path [--since T] [--until T] [--tail N] [--grep RE] [--level err] [--unit U]")
+				return
+			}
+			p, sl, err := parseFileAddArgs(args[1:])
+			if err != nil {
+				fmt.Println("file add error:", err)
 				return
 			}
-			p := normalizePath(strings.Join(args[1:], " "))
 			if !fileExists(p) {
 				fmt.Println("file not found:", p)
 				return
 			}
-			st.Files = append(st.Files, p)
-			fmt.Println("file added:", p)
+			a := Attachment{Path: p, Slice: sl}
+			st.Files = append(st.Files, a)
+			fmt.Println("file added:", a.Label())
 		case "list":
 			if len(st.Files) == 0 {
 				fmt.Println("(no attached files)")
 				return
 			}
 			for i, f := range st.Files {
-				fmt.Printf("%d) %s\n", i+1, f)
+				fmt.Printf("%d) %s\n", i+1, f.Label())
 			}
 		case "rm":
 			if len(args) < 2 {
@@ -506,7 +656,7 @@ func handleInternalCommand(cfg *config.Config, st *State, uicfg ui.Config, line
 			idx := n - 1
 			removed := st.Files[idx]
 			st.Files = append(st.Files[:idx], st.Files[idx+1:]...)
-			fmt.Println("file removed:", removed)
+			fmt.Println("file removed:", removed.Label())
 		case "clear":
 			st.Files = nil
 			fmt.Println("files cleared")
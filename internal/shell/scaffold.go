@@ -0,0 +1,366 @@
+package shell
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/gen"
+	"kiki-ai-shell/internal/health"
+	"kiki-ai-shell/internal/textdiff"
+)
+
+// ScaffoldOptions selects a multi-file layout and its inputs.
+type ScaffoldOptions struct {
+	Kind    string // gen.ScaffoldNames()
+	Gen     gen.Options
+	Confirm bool   // write without asking when validation passes
+	Repair  int    // attempts to fix validation errors with the model (0 = none)
+	Extra   string // extra system-prompt rules (templates)
+	BaseURL string // --base-url, for this command only
+}
+
+// RunScaffold asks the model for a set of files in the envelope format,
+// validates each one, previews them as a tree and writes them into dir in
+// one step.
+func RunScaffold(cfg *config.Config, st *State, dir, prompt string, o ScaffoldOptions) error {
+	cfg, err := withBaseURL(cfg, o.BaseURL)
+	if err != nil {
+		return err
+	}
+	dir = strings.TrimSpace(dir)
+	prompt = strings.TrimSpace(prompt)
+	if dir == "" {
+		return fmt.Errorf("scaffold: target directory is empty")
+	}
+	if prompt == "" {
+		return fmt.Errorf("scaffold: prompt is empty")
+	}
+	sc := gen.LookupScaffold(o.Kind)
+	if sc == nil {
+		return fmt.Errorf("scaffold: unknown kind %q (%s)", o.Kind, strings.Join(gen.ScaffoldNames(), "|"))
+	}
+	sys := appendSystem(gen.ScaffoldSystem(sc, o.Gen), o.Extra)
+	answer, err := genOnce(cfg, st, sys, prompt)
+	if err != nil {
+		return err
+	}
+	label := "scaffold:" + sc.Name
+	files, results, res := checkScaffold(sc, answer, o.Gen)
+	printValidation(label, res)
+	for attempt := 1; !res.OK() && attempt <= o.Repair; attempt++ {
+		fmt.Fprintf(os.Stderr, "[repair %d/%d] sending %d error(s) back to the model\n", attempt, o.Repair, len(res.Errors()))
+		fixed, err := genRepair(cfg, st, sys, prompt, res)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "[repair] failed:", err)
+			break
+		}
+		if strings.TrimSpace(fixed) == strings.TrimSpace(res.Content) {
+			fmt.Fprintln(os.Stderr, "[repair] the model returned the same output; giving up")
+			break
+		}
+		nfiles, nresults, nres := checkScaffold(sc, fixed, o.Gen)
+		fmt.Fprint(os.Stderr, diffFiles(files, nfiles, attempt, colorEnabled(os.Stderr)))
+		printValidation(label, nres)
+		files, results, res = nfiles, nresults, nres
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("scaffold: the model returned no files")
+	}
+
+	existing := existingFiles(dir, files)
+	fmt.Print(renderTree(dir, files, results, existing))
+
+	question := fmt.Sprintf("write %d file(s) to %s?", len(files), dir)
+	if len(existing) > 0 {
+		question = fmt.Sprintf("write %d file(s) to %s (%d overwritten, backups kept)?", len(files), dir, len(existing))
+	}
+	switch {
+	case !res.OK():
+		if !stdinInteractive() || !confirm("validation failed. "+question) {
+			fmt.Println("(not saved)")
+			return nil
+		}
+	case o.Confirm:
+	case !stdinInteractive():
+		fmt.Fprintln(os.Stderr, "scaffold: non-interactive stdin. not writing without --confirm")
+		return nil
+	case !confirm(question):
+		fmt.Println("(cancelled)")
+		return nil
+	}
+
+	backups, err := writeScaffold(normalizePath(dir), files)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("saved: %d file(s) in %s\n", len(files), dir)
+	for _, b := range backups {
+		fmt.Println("backup:", b)
+	}
+	if st != nil && st.RAG != nil {
+		for _, f := range files {
+			_ = st.RAG.AddText("gen:"+filepath.Join(dir, f.Path), f.Content, cfg.RAGMaxChars)
+		}
+	}
+	return nil
+}
+
+// checkScaffold parses and validates one answer. The returned Result carries
+// the raw answer as Content so it can be sent back for repair.
+func checkScaffold(sc *gen.Scaffold, answer string, o gen.Options) ([]gen.File, map[string]gen.Result, gen.Result) {
+	res := gen.Result{Content: answer}
+	files, err := gen.ParseFiles(answer)
+	if err != nil {
+		res.Issues = append(res.Issues, gen.Issue{Severity: "error", Msg: "envelope: " + err.Error()})
+		return nil, nil, res
+	}
+	results, issues := gen.CheckFiles(sc, files, o)
+	res.Issues = issues
+	return files, results, res
+}
+
+// diffFiles renders per-file diffs between two repair attempts.
+func diffFiles(prev, next []gen.File, attempt int, color bool) string {
+	byPath := func(files []gen.File) map[string]string {
+		m := map[string]string{}
+		for _, f := range files {
+			m[f.Path] = f.Content
+		}
+		return m
+	}
+	a, b := byPath(prev), byPath(next)
+	paths := map[string]bool{}
+	for p := range a {
+		paths[p] = true
+	}
+	for p := range b {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	var sb strings.Builder
+	for _, p := range sorted {
+		sb.WriteString(textdiff.Unified(fmt.Sprintf("attempt %d/%s", attempt, p), fmt.Sprintf("attempt %d/%s", attempt+1, p), a[p], b[p], 3, color))
+	}
+	return sb.String()
+}
+
+// existingFiles returns the files that would overwrite different content in dir.
+func existingFiles(dir string, files []gen.File) map[string]bool {
+	out := map[string]bool{}
+	for _, f := range files {
+		cur, err := os.ReadFile(filepath.Join(normalizePath(dir), filepath.FromSlash(f.Path)))
+		if err == nil && string(cur) != f.Content {
+			out[f.Path] = true
+		}
+	}
+	return out
+}
+
+// renderTree previews files under root with line counts and validation status.
+func renderTree(root string, files []gen.File, results map[string]gen.Result, existing map[string]bool) string {
+	type node struct {
+		name     string
+		children map[string]*node
+		file     *gen.File
+	}
+	top := &node{children: map[string]*node{}}
+	for i := range files {
+		cur := top
+		parts := strings.Split(files[i].Path, "/")
+		for j, part := range parts {
+			n, ok := cur.children[part]
+			if !ok {
+				n = &node{name: part, children: map[string]*node{}}
+				cur.children[part] = n
+			}
+			if j == len(parts)-1 {
+				n.file = &files[i]
+			}
+			cur = n
+		}
+	}
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSuffix(root, "/") + "/\n")
+	var walk func(n *node, prefix string)
+	walk = func(n *node, prefix string) {
+		names := make([]string, 0, len(n.children))
+		for k := range n.children {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for i, k := range names {
+			c := n.children[k]
+			branch, next := "├── ", "│   "
+			if i == len(names)-1 {
+				branch, next = "└── ", "    "
+			}
+			if c.file == nil {
+				sb.WriteString(prefix + branch + c.name + "/\n")
+				walk(c, prefix+next)
+				continue
+			}
+			status := "OK"
+			if r, ok := results[c.file.Path]; ok {
+				nerr := len(r.Errors())
+				switch nwarn := len(r.Issues) - nerr; {
+				case nerr > 0:
+					status = fmt.Sprintf("FAILED (%d error(s))", nerr)
+				case nwarn > 0:
+					status = fmt.Sprintf("OK (%d warning(s))", nwarn)
+				}
+			}
+			if existing[c.file.Path] {
+				status += " [overwrite]"
+			}
+			fmt.Fprintf(&sb, "%s%s%s  (%d lines) %s\n", prefix, branch, c.name, len(textdiff.SplitLines(c.file.Content)), status)
+		}
+	}
+	walk(top, "")
+	return sb.String()
+}
+
+// writeScaffold writes files under dir all at once: they are staged in a
+// temporary directory next to dir and moved into place. A new dir is a single
+// rename; into an existing dir, overwritten files are backed up first and
+// every move is rolled back if one fails.
+func writeScaffold(dir string, files []gen.File) ([]string, error) {
+	parent := filepath.Dir(filepath.Clean(dir))
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, err
+	}
+	stage, err := os.MkdirTemp(parent, "."+filepath.Base(dir)+".kiki-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stage)
+	for _, f := range files {
+		p := filepath.Join(stage, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return nil, err
+		}
+		mode := os.FileMode(0o644)
+		if strings.HasSuffix(f.Path, ".sh") {
+			mode = 0o755
+		}
+		if err := os.WriteFile(p, []byte(f.Content), mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.Chmod(stage, 0o755); err != nil {
+			return nil, err
+		}
+		return nil, os.Rename(stage, dir)
+	}
+
+	type move struct{ dst, bak string }
+	var done []move
+	rollback := func() {
+		for i := len(done) - 1; i >= 0; i-- {
+			m := done[i]
+			if m.bak != "" {
+				_ = os.Rename(m.bak, m.dst)
+			} else {
+				_ = os.Remove(m.dst)
+			}
+		}
+	}
+	var backups []string
+	for _, f := range files {
+		src := filepath.Join(stage, filepath.FromSlash(f.Path))
+		dst := filepath.Join(dir, filepath.FromSlash(f.Path))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			rollback()
+			return nil, err
+		}
+		if cur, err := os.ReadFile(dst); err == nil && string(cur) == f.Content {
+			continue // unchanged: no backup, no write
+		}
+		m := move{dst: dst}
+		if _, err := os.Lstat(dst); err == nil {
+			bak, err := backupFile(dst)
+			if err != nil {
+				rollback()
+				return nil, err
+			}
+			m.bak = bak
+		}
+		if err := os.Rename(src, dst); err != nil {
+			if m.bak != "" {
+				_ = os.Remove(m.bak)
+			}
+			rollback()
+			return nil, err
+		}
+		done = append(done, m)
+		if m.bak != "" {
+			backups = append(backups, m.bak)
+		}
+	}
+	return backups, nil
+}
+
+// ParseScaffoldArgs parses `kiki-ai-shell scaffold "<prompt>" --kind role|k8s|files
+// --out <dir> [--namespace ns] [--inventory hosts] [--repair N] [--confirm]`.
+func ParseScaffoldArgs(cfg *config.Config, args []string) (string, string, ScaffoldOptions, error) {
+	fs := flag.NewFlagSet("scaffold", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	kind := fs.String("kind", "role", "layout: "+strings.Join(gen.ScaffoldNames(), "|"))
+	out := fs.String("out", "", "target directory (required)")
+	namespace := fs.String("namespace", "", "default namespace for k8s objects (injected when missing)")
+	fs.StringVar(namespace, "n", "", "alias of --namespace")
+	inventory := fs.String("inventory", "", "inventory file or host spec (node1,node2 | kube-worker[1:5])")
+	verify := fs.String("verify", "syntax", "external check: syntax (shellcheck when installed) | none")
+	repair := fs.Int("repair", cfg.GenRepairMax, "max attempts to fix validation errors with the model (0 = off)")
+	confirm := fs.Bool("confirm", false, "write without asking when validation passes")
+	baseURL := fs.String("base-url", "", "LLM base URL (overrides LLM_BASE_URL)")
+
+	rest, err := parseInterleaved(fs, args)
+	if err != nil {
+		return "", "", ScaffoldOptions{}, err
+	}
+	prompt := strings.TrimSpace(strings.Join(rest, " "))
+	if prompt == "" {
+		return "", "", ScaffoldOptions{}, fmt.Errorf("scaffold: prompt is empty")
+	}
+	if strings.TrimSpace(*out) == "" {
+		return "", "", ScaffoldOptions{}, fmt.Errorf("scaffold: --out <dir> is required")
+	}
+	o := ScaffoldOptions{Kind: *kind, Confirm: *confirm, Repair: *repair}
+	o.Gen.Namespace = strings.TrimSpace(*namespace)
+	switch strings.ToLower(*verify) {
+	case "syntax":
+		o.Gen.SyntaxCheck = true
+	case "none", "":
+	default:
+		return "", "", o, fmt.Errorf("invalid --verify: %s (syntax|none)", *verify)
+	}
+	if strings.TrimSpace(*inventory) != "" {
+		if o.Gen.Inventory, err = health.LoadInventory(*inventory); err != nil {
+			return "", "", o, err
+		}
+	}
+	if _, err := withBaseURL(cfg, *baseURL); err != nil {
+		return "", "", o, err
+	}
+	o.BaseURL = *baseURL
+	return normalizePath(*out), prompt, o, nil
+}
+
+// replScaffoldOptions builds scaffold options from the shell context, like
+// replGenOptions.
+func replScaffoldOptions(cfg *config.Config, st *State, kind string) ScaffoldOptions {
+	g := replGenOptions(cfg, st, "")
+	return ScaffoldOptions{Kind: kind, Gen: g.Gen, Repair: g.Repair}
+}
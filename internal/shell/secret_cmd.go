@@ -0,0 +1,76 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"kiki-ai-shell/internal/secret"
+)
+
+const secretUsage = "usage: :secret list | :secret set <name> | :secret rm <name>   (use as keyring:<name> in endpoints:)"
+
+// RunSecret handles `:secret ...` and `kiki-ai-shell secret ...`. Values are
+// never taken from the command line, so they do not end up in shell
+// history: set prompts without echo, or reads stdin when it is not a
+// terminal (kiki-ai-shell secret set gw < token.txt).
+func RunSecret(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", secretUsage)
+	}
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		names, err := secret.Names()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			fmt.Println("no secrets stored")
+			return nil
+		}
+		for _, name := range names {
+			fmt.Println("keyring:" + name)
+		}
+		return nil
+	case "set":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: :secret set <name>   (the value is prompted for, not passed as an argument)")
+		}
+		value, err := readSecretValue("value for keyring:" + args[1] + " (not echoed):")
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			return fmt.Errorf("empty value; nothing stored")
+		}
+		if err := secret.Set(args[1], value); err != nil {
+			return err
+		}
+		fmt.Printf("stored keyring:%s in %s\n", args[1], secret.Dir())
+		return nil
+	case "rm", "remove":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: :secret rm <name>")
+		}
+		if err := secret.Delete(args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("removed keyring:%s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("%s", secretUsage)
+}
+
+func readSecretValue(question string) (string, error) {
+	if !stdinInteractive() {
+		b, err := io.ReadAll(io.LimitReader(os.Stdin, 64*1024))
+		return strings.TrimSpace(string(b)), err
+	}
+	fmt.Printf("%s ", question)
+	b, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	return strings.TrimSpace(string(b)), err
+}
@@ -0,0 +1,120 @@
+package shell
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/pcp"
+)
+
+// handleSet implements `:set <key> <value...>`. The value is the rest of the
+// raw line so prompts with spaces work without quoting.
+func handleSet(cfg *config.Config, st *State, args []string, cmdline string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: :set <key> <value>   (keys: :config show, TAB)")
+	}
+	f, ok := config.LookupField(args[0])
+	if !ok {
+		_, err := cfg.Get(args[0])
+		return err
+	}
+	// Raw text after "set <key>", so spacing inside the value survives.
+	value := afterFields(cmdline, 2)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	if f.Key == "profile" {
+		if err := switchProfile(cfg, st, value); err != nil {
+			return err
+		}
+		cfg.SetOrigin("profile", ":set")
+		return nil
+	}
+	if f.Key == "llm_endpoint" && value != "" {
+		if err := llmUse(cfg, st, value); err != nil {
+			return err
+		}
+		cfg.SetOrigin("llm_endpoint", ":set")
+		return nil
+	}
+	if err := cfg.Set(f.Key, value, ":set"); err != nil {
+		return err
+	}
+	syncState(cfg, st, f.Key)
+	v, _ := cfg.Get(f.Key)
+	fmt.Printf("%s = %s\n", f.Key, truncateRunes(strings.ReplaceAll(v, "\n", `\n`), 120))
+	return nil
+}
+
+// handleGet implements `:get [key]`.
+func handleGet(cfg *config.Config, args []string) error {
+	if len(args) < 1 {
+		configShow(cfg, "")
+		return nil
+	}
+	for _, key := range args {
+		f, ok := config.LookupField(key)
+		if !ok {
+			_, err := cfg.Get(key)
+			return err
+		}
+		v, _ := cfg.Get(f.Key)
+		env := ""
+		if f.Env != "" {
+			env = ", env " + f.Env
+		}
+		fmt.Printf("%s = %s   (%s, from %s%s)\n", f.Key, v, f.Type, cfg.Origin(f.Key), env)
+	}
+	return nil
+}
+
+// syncState pushes a changed config value into the parts of the shell state
+// that were initialized from it.
+func syncState(cfg *config.Config, st *State, key string) {
+	switch key {
+	case "stream":
+		st.Stream = cfg.Stream
+	case "nofence":
+		st.NoFence = cfg.NoFence
+	case "rag":
+		if st.RAG != nil {
+			st.RAG.Enabled = cfg.RAGEnabled
+		}
+	case "ctx_target":
+		st.CtxSizeTarget = cfg.CtxSizeTarget
+	case "ctx_observed":
+		st.CtxSizeObserved = cfg.CtxSizeObserved
+	case "pcp_host":
+		if st.PCP != nil {
+			st.PCP.SetHost(cfg.PCPHost)
+		}
+	case "pcp_groups":
+		st.PCPGroups = pcp.ParseGroups(cfg.PCPGroups)
+	case "watch_explain":
+		st.WatchExplain = cfg.WatchExplain
+	case "watch_interval":
+		if st.Watch != nil {
+			st.Watch.SetInterval(time.Duration(cfg.WatchIntervalSec) * time.Second)
+		}
+	case "usage":
+		st.EnsureUsage(cfg)
+	}
+}
+
+// setCompletions completes `:set <key> [value]` / `:get <key>`.
+func setCompletions(s, cmd string, parts []string) []string {
+	if len(parts) >= 2 {
+		if f, ok := config.LookupField(parts[1]); ok && f.Type == "bool" && len(parts) > 2 {
+			return completeSecondToken(s, cmd+" "+parts[1], []string{"true", "false"})
+		}
+		if f, ok := config.LookupField(parts[1]); ok && f.Key == "profile" && len(parts) > 2 {
+			return completeSecondToken(s, cmd+" "+parts[1], config.ProfileNames())
+		}
+		if f, ok := config.LookupField(parts[1]); ok && f.Key == "llm_endpoint" && len(parts) > 2 {
+			return completeSecondToken(s, cmd+" "+parts[1], config.EndpointNames())
+		}
+	}
+	return completeSecondToken(s, cmd, config.FieldKeys())
+}
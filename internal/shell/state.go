@@ -13,7 +13,9 @@ import (
 
 type State struct {
 	User       string
-	Files      []string
+	// Files are the attachments in :file list order; a path may be attached
+	// more than once with different slices.
+	Files      []Attachment
 	Profile    string
 	Stream     bool
 	LastAnswer string
@@ -27,6 +29,13 @@ type State struct {
 	Usage *usage.Logger
 	PCP   *pcp.Client
 
+	// PCPGroups maps a group name to an unexpanded host spec (see pcp.ExpandHosts).
+	PCPGroups map[string]string
+
+	// Watch runs background threshold rules (:watch); nil until the first rule is added.
+	Watch        *pcp.Watcher
+	WatchExplain bool
+
 	NoFence bool
 }
 
@@ -58,7 +67,7 @@ func NewState(cfg *config.Config, uicfg ui.Config) *State {
 	}
 	st := &State{
 		User:            user,
-		Files:           []string{},
+		Files:           []Attachment{},
 		Profile:         cfg.Profile,
 		Stream:          cfg.Stream,
 		Ctx:             map[string]string{},
@@ -67,6 +76,8 @@ func NewState(cfg *config.Config, uicfg ui.Config) *State {
 		UI:              uicfg,
 		RAG:             rag.New(cfg.RAGEnabled),
 		PCP:             pcp.New(cfg.PCPHost),
+		PCPGroups:       pcp.ParseGroups(cfg.PCPGroups),
+		WatchExplain:    cfg.WatchExplain,
 		NoFence:         cfg.NoFence,
 	}
 	st.EnsureUsage(cfg)
@@ -0,0 +1,366 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/gen"
+	"kiki-ai-shell/internal/tpl"
+)
+
+const tplUsage = "usage: :tpl list | :tpl show <name> | :tpl run <name> [key=value ...] [--key value] [--out path] [--confirm] [--dry-run] [extra text...] | :tpl new <name> [--project] | :tpl path"
+
+// RunTpl handles `:tpl ...` and `kiki-ai-shell tpl ...`.
+func RunTpl(cfg *config.Config, st *State, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%s", tplUsage)
+	}
+	switch strings.ToLower(args[0]) {
+	case "list", "ls":
+		return tplList()
+	case "show":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :tpl show <name>")
+		}
+		t, err := tpl.Find(args[1])
+		if err != nil {
+			return err
+		}
+		tplShow(st, t)
+		return nil
+	case "run":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :tpl run <name> [key=value ...] [extra text...]")
+		}
+		return tplRun(cfg, st, args[1], args[2:])
+	case "new":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: :tpl new <name> [--project]")
+		}
+		return tplNew(args[1], len(args) > 2 && args[2] == "--project")
+	case "path":
+		if p := tpl.ProjectDir(); p != "" {
+			fmt.Println("project:", p)
+		} else {
+			fmt.Println("project: (none: create .kiki/templates in the project)")
+		}
+		fmt.Println("user:   ", tpl.UserDir())
+		return nil
+	}
+	return fmt.Errorf("%s", tplUsage)
+}
+
+func tplList() error {
+	list, errs := tpl.List()
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, "tpl:", err)
+	}
+	if len(list) == 0 {
+		fmt.Println("(no templates in", strings.Join(tplDirs(), ", ")+")")
+		return nil
+	}
+	for _, t := range list {
+		mode := t.Mode
+		if t.Target != "" {
+			mode += ":" + t.Target
+		}
+		fmt.Printf("%-24s %-7s %-14s %s\n", t.Name, t.Source, mode, t.Description)
+	}
+	return nil
+}
+
+func tplDirs() []string {
+	var out []string
+	if p := tpl.ProjectDir(); p != "" {
+		out = append(out, p)
+	}
+	return append(out, tpl.UserDir())
+}
+
+func tplShow(st *State, t *tpl.Template) {
+	fmt.Printf("name:   %s (%s)\npath:   %s\nmode:   %s\n", t.Name, t.Source, t.Path, t.Mode)
+	if t.Description != "" {
+		fmt.Println("desc:  ", t.Description)
+	}
+	if t.Target != "" {
+		fmt.Println("target:", t.Target)
+	}
+	if t.Out != "" {
+		fmt.Println("out:   ", t.Out)
+	}
+	if t.System != "" {
+		fmt.Println("system:", truncateRunes(t.System, 200))
+	}
+	if len(t.Vars) > 0 {
+		fmt.Println("vars:")
+		for _, v := range t.Vars {
+			var notes []string
+			if v.Required && v.Default == "" {
+				notes = append(notes, "required")
+			}
+			if v.Default != "" {
+				notes = append(notes, "default="+v.Default)
+			}
+			if c := ctxGet(st, v.Name); c != "" {
+				notes = append(notes, "ctx="+c)
+			}
+			line := fmt.Sprintf("  %-16s %s", v.Name, v.Description)
+			if len(notes) > 0 {
+				line = strings.TrimRight(line, " ") + " [" + strings.Join(notes, ", ") + "]"
+			}
+			fmt.Println(line)
+		}
+	}
+	fmt.Println("---")
+	fmt.Println(t.Body)
+}
+
+// tplRunArgs is what `:tpl run` takes besides the template name.
+type tplRunArgs struct {
+	vals    map[string]string
+	extra   []string
+	out     string
+	confirm bool
+	dryRun  bool
+	repair  int // -1 = config default
+}
+
+var tplKeyValue = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.-]*)=(.*)$`)
+
+// parseTplRunArgs splits key=value, --key value / --key=value and free text.
+// --out, --confirm, --dry-run and --repair are options, not variables.
+func parseTplRunArgs(args []string) (tplRunArgs, error) {
+	ra := tplRunArgs{vals: map[string]string{}, repair: -1}
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			ra.extra = append(ra.extra, args[i+1:]...)
+			break
+		}
+		if strings.HasPrefix(a, "--") && len(a) > 2 {
+			key, val, hasVal := strings.Cut(a[2:], "=")
+			switch key {
+			case "confirm":
+				ra.confirm = true
+				continue
+			case "dry-run":
+				ra.dryRun = true
+				continue
+			}
+			if !hasVal {
+				if i+1 >= len(args) {
+					return ra, fmt.Errorf("tpl: --%s needs a value", key)
+				}
+				i++
+				val = args[i]
+			}
+			switch key {
+			case "out":
+				ra.out = val
+			case "repair":
+				n, err := strconv.Atoi(val)
+				if err != nil || n < 0 {
+					return ra, fmt.Errorf("tpl: invalid --repair %q", val)
+				}
+				ra.repair = n
+			default:
+				ra.vals[key] = val
+			}
+			continue
+		}
+		if m := tplKeyValue.FindStringSubmatch(a); m != nil {
+			ra.vals[m[1]] = m[2]
+			continue
+		}
+		ra.extra = append(ra.extra, a)
+	}
+	return ra, nil
+}
+
+func tplRun(cfg *config.Config, st *State, name string, args []string) error {
+	t, err := tpl.Find(name)
+	if err != nil {
+		return err
+	}
+	ra, err := parseTplRunArgs(args)
+	if err != nil {
+		return err
+	}
+	vals, err := tplResolve(st, t, ra.vals)
+	if err != nil {
+		return err
+	}
+	r, _ := t.Render(vals)
+	prompt := r.Prompt
+	if len(ra.extra) > 0 {
+		prompt += "\n\n[추가 요청]\n" + strings.Join(ra.extra, " ")
+	}
+	out := r.Out
+	if ra.out != "" {
+		out = ra.out
+	}
+	if ra.dryRun {
+		fmt.Printf("[tpl %s] mode=%s", t.Name, t.Mode)
+		if t.Target != "" {
+			fmt.Printf(" target=%s", t.Target)
+		}
+		if out != "" {
+			fmt.Printf(" out=%s", out)
+		}
+		fmt.Println()
+		if r.System != "" {
+			fmt.Printf("[system]\n%s\n", r.System)
+		}
+		fmt.Printf("[prompt]\n%s\n", prompt)
+		return nil
+	}
+
+	switch t.Mode {
+	case "gen":
+		o := replGenOptions(cfg, st, t.Target)
+		o.Confirm, o.Extra = ra.confirm, r.System
+		if ra.repair >= 0 {
+			o.Repair = ra.repair
+		}
+		return GenTarget(cfg, st, normalizePath(out), prompt, o)
+	case "scaffold":
+		if gen.LookupScaffold(t.Target) == nil {
+			return fmt.Errorf("tpl %s: unknown scaffold kind %q (%s)", t.Name, t.Target, strings.Join(gen.ScaffoldNames(), "|"))
+		}
+		o := replScaffoldOptions(cfg, st, t.Target)
+		o.Confirm, o.Extra = ra.confirm, r.System
+		if ra.repair >= 0 {
+			o.Repair = ra.repair
+		}
+		return RunScaffold(cfg, st, normalizePath(out), prompt, o)
+	}
+	sys := ""
+	if r.System != "" {
+		sys = appendSystem(cfg.SystemPrompt, r.System)
+	}
+	Ask(cfg, st, prompt, sys)
+	return nil
+}
+
+// tplResolve fills the template variables: explicit values first, then
+// :ctx, then defaults; required ones still missing are asked for on a
+// terminal and reported as an error otherwise.
+func tplResolve(st *State, t *tpl.Template, given map[string]string) (map[string]string, error) {
+	vals := map[string]string{}
+	known := map[string]bool{}
+	for _, v := range t.Vars {
+		known[v.Name] = true
+		if val, ok := given[v.Name]; ok {
+			vals[v.Name] = val
+		} else if c := ctxGet(st, v.Name); c != "" {
+			vals[v.Name] = c
+		}
+	}
+	var unknown []string
+	for k := range given {
+		if !known[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		fmt.Fprintf(os.Stderr, "tpl %s: ignoring unknown variable(s): %s\n", t.Name, strings.Join(unknown, ", "))
+	}
+
+	_, missing := t.Render(vals)
+	if len(missing) == 0 {
+		return vals, nil
+	}
+	if !stdinInteractive() {
+		return nil, fmt.Errorf("tpl %s: missing variable(s): %s (pass key=value or :ctx set key=value)", t.Name, strings.Join(missing, ", "))
+	}
+	desc := map[string]string{}
+	for _, v := range t.Vars {
+		desc[v.Name] = v.Description
+	}
+	for _, name := range missing {
+		q := name
+		if desc[name] != "" {
+			q += " (" + desc[name] + ")"
+		}
+		ans := readLine(q + ":")
+		if ans == "" {
+			return nil, fmt.Errorf("tpl %s: %s is required", t.Name, name)
+		}
+		vals[name] = ans
+	}
+	return vals, nil
+}
+
+// tplNew writes a skeleton template into the user (or project) dir.
+func tplNew(name string, project bool) error {
+	name = strings.Trim(filepath.ToSlash(strings.TrimSpace(name)), "/")
+	if name == "" || strings.Contains(name, "..") {
+		return fmt.Errorf("tpl: invalid name %q", name)
+	}
+	dir := tpl.UserDir()
+	if project {
+		if dir = tpl.ProjectDir(); dir == "" {
+			dir = filepath.Join(".kiki", "templates")
+		}
+	}
+	path := filepath.Join(dir, filepath.FromSlash(name)+".tpl")
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("tpl: %s already exists", path)
+	}
+	if err := writeFile(path, tpl.Skeleton); err != nil {
+		return err
+	}
+	fmt.Println("created:", path)
+	return nil
+}
+
+// tplNames lists template names for completion.
+func tplNames() []string {
+	list, _ := tpl.List()
+	out := make([]string, 0, len(list))
+	for _, t := range list {
+		out = append(out, t.Name)
+	}
+	return out
+}
+
+// splitQuoted splits a command line on spaces, keeping "double" and
+// 'single' quoted parts together (quotes removed).
+func splitQuoted(s string) []string {
+	var out []string
+	var cur strings.Builder
+	var quote rune
+	inToken := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inToken = r, true
+		case r == ' ' || r == '\t':
+			if inToken {
+				out = append(out, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	if inToken {
+		out = append(out, cur.String())
+	}
+	return out
+}
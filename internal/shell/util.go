@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 func truncateRunes(s string, n int) string {
@@ -32,3 +33,18 @@ func parseCtxSizeFromError(err error) int {
 	}
 	return 0
 }
+
+// afterFields returns s with its first n whitespace-separated fields removed,
+// keeping the rest verbatim (quotes, inner spacing). It works on the raw
+// line, so it does not depend on how the command token was spelled.
+func afterFields(s string, n int) string {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	for i := 0; i < n && s != ""; i++ {
+		end := strings.IndexFunc(s, unicode.IsSpace)
+		if end < 0 {
+			return ""
+		}
+		s = strings.TrimLeftFunc(s[end:], unicode.IsSpace)
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kiki-ai-shell/internal/config"
+	"kiki-ai-shell/internal/pcp"
+)
+
+// handleWatch implements the :watch command family.
+//
+//	:watch add "kernel.all.load > 8 for 2m"
+//	:watch list | :watch rm N | :watch clear
+//	:watch interval 30s | :watch explain on|off
+func handleWatch(cfg *config.Config, st *State, args []string, cmdline string) {
+	usage := `usage: :watch add "<metric>[inst] <op> <value> [for <dur>]" | :watch list | :watch rm N | :watch clear | :watch interval <dur> | :watch explain on|off`
+	if len(args) < 1 {
+		fmt.Println(usage)
+		return
+	}
+	switch strings.ToLower(args[0]) {
+	case "add":
+		// Take the raw text after "watch add" so quotes/spaces in instances survive.
+		expr := afterFields(cmdline, 2)
+		if expr == "" {
+			fmt.Println(usage)
+			return
+		}
+		r, err := pcp.ParseRule(expr)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if st.Watch == nil {
+			st.Watch = pcp.NewWatcher(time.Duration(cfg.WatchIntervalSec) * time.Second)
+		}
+		r, err = st.Watch.Add(r, st.PCP)
+		if err != nil {
+			fmt.Println("watch error:", err)
+			return
+		}
+		fmt.Printf("watch #%d added: %s @ %s (every %s)\n", r.ID, r.Expr, r.Host(), st.Watch.Interval())
+	case "list", "show":
+		if st.Watch == nil || len(st.Watch.Rules()) == 0 {
+			fmt.Println("(no watchers)")
+			return
+		}
+		explain := "off"
+		if st.WatchExplain {
+			explain = "on"
+		}
+		fmt.Printf("interval=%s explain=%s\n", st.Watch.Interval(), explain)
+		for _, r := range st.Watch.Rules() {
+			fmt.Printf("#%d %s @ %s : %s\n", r.ID, r.Expr, r.Host(), r.Status())
+		}
+	case "rm", "del":
+		if len(args) < 2 {
+			fmt.Println("usage: :watch rm N")
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil || st.Watch == nil || !st.Watch.Remove(n) {
+			fmt.Println("invalid watch id:", args[1])
+			return
+		}
+		fmt.Println("watch removed:", n)
+	case "clear":
+		if st.Watch != nil {
+			st.Watch.Clear()
+		}
+		fmt.Println("watchers cleared")
+	case "interval":
+		if len(args) < 2 {
+			fmt.Println("usage: :watch interval <dur>   (e.g. 30s)")
+			return
+		}
+		d, err := time.ParseDuration(args[1])
+		if err != nil || d < time.Second {
+			fmt.Println("invalid interval:", args[1])
+			return
+		}
+		cfg.WatchIntervalSec = int(d / time.Second)
+		if st.Watch != nil {
+			st.Watch.SetInterval(d)
+		}
+		fmt.Println("watch interval:", d)
+	case "explain":
+		if len(args) < 2 {
+			fmt.Println("usage: :watch explain on|off")
+			return
+		}
+		v := strings.ToLower(args[1])
+		st.WatchExplain = (v == "on" || v == "1" || v == "true")
+		cfg.WatchExplain = st.WatchExplain
+		fmt.Println("watch explain:", v)
+	default:
+		fmt.Println(usage)
+	}
+}
+
+// handleWatchEvents prints queued watcher transitions before the next prompt
+// and, when enabled, asks the LLM to explain newly firing alerts.
+func handleWatchEvents(cfg *config.Config, st *State) {
+	if st.Watch == nil {
+		return
+	}
+	for _, ev := range st.Watch.Drain() {
+		fmt.Fprintln(os.Stderr, "[watch]", ev.String())
+		if ev.State != "firing" || !st.WatchExplain {
+			continue
+		}
+		fmt.Printf("(explaining alert #%d: sampling PCP metrics on %s ...)\n", ev.RuleID, ev.Host)
+		snap, err := pcp.New(ev.Host).Diagnose(0)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "pcp error:", err)
+			continue
+		}
+		q := fmt.Sprintf("경보가 발생했습니다: %s (값=%s, 호스트=%s). 가능한 원인과 확인 명령, 조치를 설명하세요.", ev.Expr, ev.Value, ev.Host)
+		Ask(cfg, st, q+"\n\n"+snap.Context(), pcpDiagSystemPrompt)
+	}
+}
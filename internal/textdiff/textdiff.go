@@ -0,0 +1,276 @@
+// Package textdiff computes line diffs and renders them as unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is one line of an edit script.
+type Op struct {
+	Kind byte // ' ' keep, '-' delete (from a), '+' insert (from b)
+	Text string
+}
+
+// Hunk is a run of changes with surrounding context. Starts are 0-based line
+// indexes into a and b.
+type Hunk struct {
+	AStart, ALen int
+	BStart, BLen int
+	Ops          []Op
+}
+
+// maxCells bounds the LCS table; larger inputs degrade to a single
+// delete-all/insert-all hunk instead of using quadratic memory.
+const maxCells = 16 << 20
+
+// SplitLines splits s into lines without their terminators. A trailing
+// newline does not produce an empty last line.
+func SplitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// Lines returns the edit script turning a into b.
+func Lines(a, b []string) []Op {
+	// Common prefix/suffix are cheap and keep the table small.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []Op
+	for _, l := range a[:pre] {
+		ops = append(ops, Op{' ', l})
+	}
+	ops = append(ops, lcs(a[pre:len(a)-suf], b[pre:len(b)-suf])...)
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, Op{' ', l})
+	}
+	return ops
+}
+
+func lcs(a, b []string) []Op {
+	n, m := len(a), len(b)
+	var ops []Op
+	if n == 0 || m == 0 || (n+1)*(m+1) > maxCells {
+		for _, l := range a {
+			ops = append(ops, Op{'-', l})
+		}
+		for _, l := range b {
+			ops = append(ops, Op{'+', l})
+		}
+		return ops
+	}
+	// t[i][j] = LCS length of a[i:] and b[j:].
+	t := make([][]int32, n+1)
+	for i := range t {
+		t[i] = make([]int32, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				t[i][j] = t[i+1][j+1] + 1
+			} else if t[i+1][j] >= t[i][j+1] {
+				t[i][j] = t[i+1][j]
+			} else {
+				t[i][j] = t[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, Op{' ', a[i]})
+			i++
+			j++
+		case t[i+1][j] >= t[i][j+1]:
+			ops = append(ops, Op{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, Op{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, Op{'-', a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, Op{'+', b[j]})
+	}
+	return ops
+}
+
+// Hunks groups an edit script into hunks with up to context unchanged lines
+// around each change. Changes separated by at most 2*context unchanged lines
+// share a hunk, as in diff -u.
+func Hunks(ops []Op, context int) []Hunk {
+	var out []Hunk
+	var cur *Hunk
+	lastChange := -1 // index in ops of the last change in cur
+	closeHunk := func() {
+		for n, c := range ops[lastChange+1:] {
+			if c.Kind != ' ' || n >= context {
+				break
+			}
+			cur.Ops = append(cur.Ops, c)
+			cur.ALen++
+			cur.BLen++
+		}
+		out = append(out, *cur)
+	}
+	ai, bi := 0, 0
+	for k, op := range ops {
+		if op.Kind != ' ' {
+			if cur != nil && k-lastChange-1 > 2*context {
+				closeHunk()
+				cur = nil
+			}
+			if cur == nil {
+				// Start with up to context lines before this change.
+				from := k - context
+				if from < lastChange+1 {
+					from = lastChange + 1
+				}
+				if from < 0 {
+					from = 0
+				}
+				lead := k - from
+				cur = &Hunk{AStart: ai - lead, BStart: bi - lead, ALen: lead, BLen: lead}
+				cur.Ops = append(cur.Ops, ops[from:k]...)
+			} else {
+				// Bridge the unchanged gap since the last change.
+				gap := ops[lastChange+1 : k]
+				cur.Ops = append(cur.Ops, gap...)
+				cur.ALen += len(gap)
+				cur.BLen += len(gap)
+			}
+			cur.Ops = append(cur.Ops, op)
+			if op.Kind == '-' {
+				cur.ALen++
+			} else {
+				cur.BLen++
+			}
+			lastChange = k
+		}
+		switch op.Kind {
+		case ' ':
+			ai++
+			bi++
+		case '-':
+			ai++
+		case '+':
+			bi++
+		}
+	}
+	if cur != nil {
+		closeHunk()
+	}
+	return out
+}
+
+// Apply rebuilds the new text from a, taking accepted hunks from b and
+// keeping a's lines for rejected ones. hunks must come from Hunks(Lines(a, b)).
+func Apply(a []string, hunks []Hunk, accept []bool) []string {
+	out := make([]string, 0, len(a))
+	pos := 0
+	for i, h := range hunks {
+		out = append(out, a[pos:h.AStart]...)
+		if i < len(accept) && accept[i] {
+			for _, op := range h.Ops {
+				if op.Kind != '-' {
+					out = append(out, op.Text)
+				}
+			}
+		} else {
+			out = append(out, a[h.AStart:h.AStart+h.ALen]...)
+		}
+		pos = h.AStart + h.ALen
+	}
+	return append(out, a[pos:]...)
+}
+
+// Stats counts inserted and deleted lines.
+func Stats(ops []Op) (added, deleted int) {
+	for _, op := range ops {
+		switch op.Kind {
+		case '+':
+			added++
+		case '-':
+			deleted++
+		}
+	}
+	return added, deleted
+}
+
+const (
+	colorDel  = "\033[31m"
+	colorAdd  = "\033[32m"
+	colorHunk = "\033[36m"
+	colorHead = "\033[1m"
+	colorOff  = "\033[0m"
+)
+
+// Header renders the @@ line of a hunk.
+func (h Hunk) Header(color bool) string {
+	s := fmt.Sprintf("@@ -%s +%s @@", span(h.AStart, h.ALen), span(h.BStart, h.BLen))
+	if color {
+		return colorHunk + s + colorOff
+	}
+	return s
+}
+
+// Render renders the hunk body (header included), one line per op.
+func (h Hunk) Render(color bool) string {
+	var b strings.Builder
+	b.WriteString(h.Header(color) + "\n")
+	for _, op := range h.Ops {
+		line := string(op.Kind) + op.Text
+		if color {
+			switch op.Kind {
+			case '-':
+				line = colorDel + line + colorOff
+			case '+':
+				line = colorAdd + line + colorOff
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// Unified renders a unified diff of a and b; "" when they are equal.
+func Unified(aName, bName, a, b string, context int, color bool) string {
+	hunks := Hunks(Lines(SplitLines(a), SplitLines(b)), context)
+	if len(hunks) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	head := "--- " + aName + "\n+++ " + bName + "\n"
+	if color {
+		head = colorHead + strings.TrimSuffix(head, "\n") + colorOff + "\n"
+	}
+	sb.WriteString(head)
+	for _, h := range hunks {
+		sb.WriteString(h.Render(color))
+	}
+	return sb.String()
+}
+
+// span formats a hunk range the way diff -u does (1-based; empty ranges
+// point at the line before).
+func span(start, n int) string {
+	switch n {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
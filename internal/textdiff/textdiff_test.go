@@ -0,0 +1,100 @@
+package textdiff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func lines(s string) []string { return SplitLines(strings.ReplaceAll(s, " ", "\n")) }
+
+// TestApply applies every accept/reject combination of the hunks between a
+// and b. Each result must take the accepted hunks from b and the rejected
+// ones from a, and diffing it against a or b again must lead back to them.
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		hunks   int
+	}{
+		{"equal", "a b c", "a b c", 3, 0},
+		{"from empty", "", "a b c", 3, 1},
+		{"to empty", "a b c", "", 3, 1},
+		{"change in the middle", "1 2 3 4 5 6 7", "1 2 3 x 5 6 7", 3, 1},
+		{"insert at start, delete at end", "1 2 3 4 5 6 7 8 9 10 11", "0 1 2 3 4 5 6 7 8 9 10", 3, 2},
+		{"close changes share a hunk", "1 2 3 4 5 6 7 8", "1 x 3 4 5 6 y 8", 3, 1},
+		{"far changes", "1 2 3 4 5 6 7 8 9 10 11 12", "1 x 3 4 5 6 7 8 9 10 y 12", 3, 2},
+		{"no context", "1 2 3 4 5", "x 2 y 4 z", 0, 3},
+		{"repeated lines", "a a b a a b a a", "a b a a b b a", 1, 2},
+		{"replace all", "1 2 3", "4 5 6 7", 3, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := lines(tt.a), lines(tt.b)
+			hunks := Hunks(Lines(a, b), tt.context)
+			if len(hunks) != tt.hunks {
+				t.Fatalf("got %d hunks, want %d", len(hunks), tt.hunks)
+			}
+			for mask := 0; mask < 1<<len(hunks); mask++ {
+				accept := make([]bool, len(hunks))
+				for i := range accept {
+					accept[i] = mask&(1<<i) != 0
+				}
+				t.Run(fmt.Sprint(accept), func(t *testing.T) {
+					got := Apply(a, hunks, accept)
+					if want := pick(a, b, hunks, accept); !slices.Equal(got, want) {
+						t.Fatalf("Apply = %q, want %q", got, want)
+					}
+					if back := applyAll(got, a); !slices.Equal(back, a) {
+						t.Errorf("back to a = %q, want %q", back, a)
+					}
+					if fwd := applyAll(got, b); !slices.Equal(fwd, b) {
+						t.Errorf("on to b = %q, want %q", fwd, b)
+					}
+				})
+			}
+			if got := Apply(a, hunks, nil); !slices.Equal(got, a) {
+				t.Errorf("nothing accepted = %q, want a %q", got, a)
+			}
+		})
+	}
+}
+
+// pick builds the expected result from the hunk spans alone: b's lines for
+// accepted hunks, a's everywhere else.
+func pick(a, b []string, hunks []Hunk, accept []bool) []string {
+	var out []string
+	pos := 0
+	for i, h := range hunks {
+		out = append(out, a[pos:h.AStart]...)
+		if accept[i] {
+			out = append(out, b[h.BStart:h.BStart+h.BLen]...)
+		} else {
+			out = append(out, a[h.AStart:h.AStart+h.ALen]...)
+		}
+		pos = h.AStart + h.ALen
+	}
+	return append(out, a[pos:]...)
+}
+
+func applyAll(from, to []string) []string {
+	hunks := Hunks(Lines(from, to), 3)
+	accept := make([]bool, len(hunks))
+	for i := range accept {
+		accept[i] = true
+	}
+	return Apply(from, hunks, accept)
+}
+
+func TestUnified(t *testing.T) {
+	got := Unified("a", "b", "1\n2\n3\n", "1\nx\n3\n", 1, false)
+	want := "--- a\n+++ b\n@@ -1,3 +1,3 @@\n 1\n-2\n+x\n 3\n"
+	if got != want {
+		t.Fatalf("Unified:\n%s\nwant:\n%s", got, want)
+	}
+	if got := Unified("a", "b", "same\n", "same\n", 3, false); got != "" {
+		t.Fatalf("Unified of equal texts = %q, want empty", got)
+	}
+}
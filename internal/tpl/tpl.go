@@ -0,0 +1,380 @@
+// Package tpl loads shared prompt templates and renders them with variables.
+//
+// Templates live in ~/.kiki/templates (user) and the nearest .kiki/templates
+// above the working directory (project); a project template shadows a user
+// template of the same name. A template is a text file (.tpl, .md or .txt)
+// with an optional YAML front matter:
+//
+//	---
+//	description: nginx 롤 생성
+//	mode: scaffold          # ask (default) | gen | scaffold
+//	target: role            # gen target or scaffold kind
+//	out: roles/{{name}}     # output path for gen/scaffold
+//	system: 사내 표준 ...    # extra system-prompt rules
+//	vars:
+//	  host: 대상 호스트       # description only: required
+//	  name: {default: nginx, description: 롤 이름}
+//	---
+//	{{host}} 에 {{name}} 을 설치하는 롤을 만들어 주세요.
+//
+// Placeholders are {{var}}; variables used in the body but not declared are
+// required.
+package tpl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions are the file extensions recognized as templates.
+var Extensions = []string{".tpl", ".md", ".txt"}
+
+// Modes are the ways a rendered template is executed.
+var Modes = []string{"ask", "gen", "scaffold"}
+
+// Var is one template variable.
+type Var struct {
+	Name        string
+	Description string
+	Default     string
+	Required    bool
+}
+
+// Template is a parsed template file.
+type Template struct {
+	Name        string // path below the templates dir without extension, e.g. "ansible/nginx-role"
+	Path        string
+	Source      string // "project" | "user"
+	Description string
+	Mode        string
+	Target      string
+	Out         string
+	System      string
+	Vars        []Var
+	Body        string
+}
+
+// UserDir returns ~/.kiki/templates.
+func UserDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".kiki", "templates")
+}
+
+// ProjectDir returns the nearest .kiki/templates in the working directory or
+// one of its parents, or "" when there is none.
+func ProjectDir() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		// ~/.kiki/templates is the user dir, not a project.
+		if dir != home {
+			p := filepath.Join(dir, ".kiki", "templates")
+			if fi, err := os.Stat(p); err == nil && fi.IsDir() {
+				return p
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// dirs lists template roots in precedence order.
+func dirs() [][2]string {
+	var out [][2]string
+	if p := ProjectDir(); p != "" {
+		out = append(out, [2]string{"project", p})
+	}
+	return append(out, [2]string{"user", UserDir()})
+}
+
+// List returns all templates, project first, sorted by name. Shadowed user
+// templates are omitted.
+func List() ([]*Template, []error) {
+	seen := map[string]bool{}
+	var out []*Template
+	var errs []error
+	for _, d := range dirs() {
+		_ = filepath.WalkDir(d[1], func(p string, e os.DirEntry, err error) error {
+			if err != nil || e.IsDir() || !isTemplateFile(p) {
+				return nil
+			}
+			name := nameOf(d[1], p)
+			if seen[name] {
+				return nil
+			}
+			seen[name] = true
+			t, err := Load(name, p, d[0])
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			out = append(out, t)
+			return nil
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, errs
+}
+
+// Find resolves a template by name in the project dir, then the user dir.
+func Find(name string) (*Template, error) {
+	name = strings.Trim(filepath.ToSlash(strings.TrimSpace(name)), "/")
+	if name == "" || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+	for _, d := range dirs() {
+		for _, ext := range Extensions {
+			p := filepath.Join(d[1], filepath.FromSlash(name)+ext)
+			if _, err := os.Stat(p); err == nil {
+				return Load(name, p, d[0])
+			}
+		}
+	}
+	return nil, fmt.Errorf("template %q not found (searched %s)", name, strings.Join(searched(), ", "))
+}
+
+func searched() []string {
+	var out []string
+	for _, d := range dirs() {
+		out = append(out, d[1])
+	}
+	return out
+}
+
+func isTemplateFile(p string) bool {
+	ext := strings.ToLower(filepath.Ext(p))
+	for _, e := range Extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+func nameOf(root, p string) string {
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		rel = filepath.Base(p)
+	}
+	return strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+}
+
+// Load reads and parses a template file.
+func Load(name, path, source string) (*Template, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	t, err := Parse(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.Name, t.Path, t.Source = name, path, source
+	return t, nil
+}
+
+type frontMatter struct {
+	Description string    `yaml:"description"`
+	Mode        string    `yaml:"mode"`
+	Target      string    `yaml:"target"`
+	Out         string    `yaml:"out"`
+	System      string    `yaml:"system"`
+	Vars        yaml.Node `yaml:"vars"`
+}
+
+// Parse parses template text: optional front matter, then the body.
+func Parse(text string) (*Template, error) {
+	t := &Template{Mode: "ask"}
+	body := text
+	if rest, ok := strings.CutPrefix(strings.TrimPrefix(strings.ReplaceAll(text, "\r\n", "\n"), "\ufeff"), "---\n"); ok {
+		head, after, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			if h, ok := strings.CutSuffix(rest, "\n---"); ok {
+				head, after, found = h, "", true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("front matter is not closed with ---")
+		}
+		var fm frontMatter
+		if err := yaml.Unmarshal([]byte(head), &fm); err != nil {
+			return nil, fmt.Errorf("front matter: %w", err)
+		}
+		t.Description = strings.TrimSpace(fm.Description)
+		t.Target = strings.TrimSpace(fm.Target)
+		t.Out = strings.TrimSpace(fm.Out)
+		t.System = strings.TrimSpace(fm.System)
+		if m := strings.ToLower(strings.TrimSpace(fm.Mode)); m != "" {
+			t.Mode = m
+		}
+		vars, err := parseVars(&fm.Vars)
+		if err != nil {
+			return nil, err
+		}
+		t.Vars = vars
+		body = after
+	}
+	t.Body = strings.TrimSpace(body)
+	if t.Body == "" {
+		return nil, fmt.Errorf("empty template body")
+	}
+	if !contains(Modes, t.Mode) {
+		return nil, fmt.Errorf("invalid mode %q (%s)", t.Mode, strings.Join(Modes, "|"))
+	}
+	if t.Mode != "ask" && t.Out == "" {
+		return nil, fmt.Errorf("mode %s needs out:", t.Mode)
+	}
+	// Variables referenced but not declared are required.
+	declared := map[string]bool{}
+	for _, v := range t.Vars {
+		declared[v.Name] = true
+	}
+	for _, s := range []string{t.Body, t.Out, t.System} {
+		for _, name := range References(s) {
+			if !declared[name] {
+				declared[name] = true
+				t.Vars = append(t.Vars, Var{Name: name, Required: true})
+			}
+		}
+	}
+	return t, nil
+}
+
+// parseVars accepts `name: description` or `name: {description, default, required}`.
+func parseVars(n *yaml.Node) ([]Var, error) {
+	if n.Kind == 0 {
+		return nil, nil
+	}
+	if n.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("vars must be a mapping")
+	}
+	var out []Var
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		k, v := n.Content[i], n.Content[i+1]
+		vr := Var{Name: k.Value}
+		switch v.Kind {
+		case yaml.ScalarNode:
+			vr.Description = v.Value
+			vr.Required = true
+		case yaml.MappingNode:
+			var spec struct {
+				Description string  `yaml:"description"`
+				Default     *string `yaml:"default"`
+				Required    *bool   `yaml:"required"`
+			}
+			if err := v.Decode(&spec); err != nil {
+				return nil, fmt.Errorf("vars.%s: %w", k.Value, err)
+			}
+			vr.Description = spec.Description
+			if spec.Default != nil {
+				vr.Default = *spec.Default
+			}
+			// Without a default a variable is required unless stated otherwise.
+			vr.Required = spec.Default == nil
+			if spec.Required != nil {
+				vr.Required = *spec.Required
+			}
+		default:
+			return nil, fmt.Errorf("vars.%s: expected a description or a mapping", k.Value)
+		}
+		if !varName.MatchString(vr.Name) {
+			return nil, fmt.Errorf("invalid variable name %q", vr.Name)
+		}
+		out = append(out, vr)
+	}
+	return out, nil
+}
+
+var (
+	varName     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+	placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+)
+
+// References lists the variables used in s, in order of first use.
+func References(s string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, m := range placeholder.FindAllStringSubmatch(s, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			out = append(out, m[1])
+		}
+	}
+	return out
+}
+
+// Expand replaces {{var}} placeholders from vals; unknown ones are kept.
+func Expand(s string, vals map[string]string) string {
+	return placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		name := placeholder.FindStringSubmatch(m)[1]
+		if v, ok := vals[name]; ok {
+			return v
+		}
+		return m
+	})
+}
+
+// Rendered is a template with its variables filled in.
+type Rendered struct {
+	Prompt string
+	Out    string
+	System string
+}
+
+// Render fills t with vals. Required variables without a value are returned
+// in missing; optional ones fall back to their default (possibly empty).
+func (t *Template) Render(vals map[string]string) (Rendered, []string) {
+	full := map[string]string{}
+	var missing []string
+	for _, v := range t.Vars {
+		if val, ok := vals[v.Name]; ok {
+			full[v.Name] = val
+			continue
+		}
+		if v.Required && v.Default == "" {
+			missing = append(missing, v.Name)
+			continue
+		}
+		full[v.Name] = v.Default
+	}
+	return Rendered{
+		Prompt: Expand(t.Body, full),
+		Out:    Expand(t.Out, full),
+		System: Expand(t.System, full),
+	}, missing
+}
+
+// Skeleton is the content written by `:tpl new`.
+const Skeleton = `---
+description: 한 줄 설명
+mode: ask            # ask | gen | scaffold
+# target: ansible    # gen: ansible|k8s|bash, scaffold: role|k8s|files
+# out: playbooks/{{name}}.yml
+# system: 추가 시스템 지침
+vars:
+  host: 대상 호스트
+  name: {default: demo, description: 이름}
+---
+{{host}} 에서 {{name}} 관련 작업을 설명해 주세요.
+`
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
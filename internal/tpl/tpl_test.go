@@ -0,0 +1,120 @@
+package tpl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    *Template
+		wantErr string
+	}{
+		{
+			name: "body only",
+			text: "{{host}} 상태를 알려 주세요\n",
+			want: &Template{Mode: "ask", Body: "{{host}} 상태를 알려 주세요", Vars: []Var{{Name: "host", Required: true}}},
+		},
+		{
+			name: "front matter",
+			text: "---\ndescription: 롤 생성\nmode: Scaffold\ntarget: role\nout: roles/{{name}}\nsystem: '{{std}} 준수'\n" +
+				"vars:\n  host: 대상 호스트\n  name: {default: nginx, description: 롤 이름}\n  port: {description: 포트, required: false}\n---\n{{host}} 에 {{name}} 설치\n",
+			want: &Template{
+				Description: "롤 생성", Mode: "scaffold", Target: "role", Out: "roles/{{name}}", System: "{{std}} 준수",
+				Body: "{{host}} 에 {{name}} 설치",
+				Vars: []Var{
+					{Name: "host", Description: "대상 호스트", Required: true},
+					{Name: "name", Description: "롤 이름", Default: "nginx"},
+					{Name: "port", Description: "포트"},
+					{Name: "std", Required: true},
+				},
+			},
+		},
+		{
+			name: "crlf and bom",
+			text: "\ufeff---\r\nmode: ask\r\n---\r\n본문\r\n",
+			want: &Template{Mode: "ask", Body: "본문"},
+		},
+		{name: "unclosed front matter", text: "---\nmode: ask\n본문\n", wantErr: "not closed"},
+		{name: "empty body", text: "---\nmode: ask\n---\n\n", wantErr: "empty template body"},
+		{name: "unknown mode", text: "---\nmode: run\n---\nx\n", wantErr: "invalid mode"},
+		{name: "gen without out", text: "---\nmode: gen\n---\nx\n", wantErr: "needs out"},
+		{name: "vars not a mapping", text: "---\nvars: [a, b]\n---\nx\n", wantErr: "vars must be a mapping"},
+		{name: "bad variable name", text: "---\nvars:\n  1x: y\n---\nx\n", wantErr: "invalid variable name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	tp, err := Parse("---\nmode: gen\nout: roles/{{name}}/main.yml\nsystem: '{{ std }} 준수'\n" +
+		"vars:\n  name: {default: nginx}\n  port: {required: false}\n  std: 표준\n---\n{{host}}:{{port}} 에 {{name}} {{name}}\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		vals    map[string]string
+		want    Rendered
+		missing []string
+	}{
+		{
+			name:    "required missing",
+			vals:    map[string]string{},
+			want:    Rendered{Prompt: "{{host}}: 에 nginx nginx", Out: "roles/nginx/main.yml", System: "{{ std }} 준수"},
+			missing: []string{"std", "host"},
+		},
+		{
+			name: "all given",
+			vals: map[string]string{"std": "사내", "host": "web1", "port": "8080", "name": "redis"},
+			want: Rendered{Prompt: "web1:8080 에 redis redis", Out: "roles/redis/main.yml", System: "사내 준수"},
+		},
+		{
+			name: "empty value is a value",
+			vals: map[string]string{"std": "", "host": "web1", "name": ""},
+			want: Rendered{Prompt: "web1: 에  ", Out: "roles//main.yml", System: " 준수"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := tp.Render(tt.vals)
+			if got != tt.want {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(missing, tt.missing) {
+				t.Errorf("missing = %q, want %q", missing, tt.missing)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	got := References("{{a}} {{ b }} {{a}} {{ not a var }} {{c.d}}")
+	if want := []string{"a", "b", "c.d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("References = %q, want %q", got, want)
+	}
+}
+
+func TestSkeletonParses(t *testing.T) {
+	if _, err := Parse(Skeleton); err != nil {
+		t.Fatalf("Skeleton: %v", err)
+	}
+}
@@ -61,6 +61,7 @@ type HeaderData struct {
 	Files       []string
 	NoFence     bool
 	PCP         string
+	Alert       string // firing watcher summary ("" when quiet)
 }
 
 func elideMiddle(s string, max int) string {
@@ -189,6 +190,9 @@ func RenderHeader(cfg Config, hd HeaderData) {
 	line3 := fmt.Sprintf(" Files: %s ", files)
 
 	line1 = padRight(ElideMiddle(line1, inner), inner)
+	if strings.TrimSpace(hd.Alert) != "" {
+		k8s += fmt.Sprintf("| ALERT: %s ", hd.Alert)
+	}
 	line2 := padRight(ElideMiddle(k8s, inner), inner)
 	line3 = padRight(ElideMiddle(line3, inner), inner)
 
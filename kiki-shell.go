@@ -1104,7 +1104,7 @@ func runInteractiveBash() error {
 // ---------------- Help ----------------
 
 func printHelpAll() {
-	fmt.Println(`
+	fmt.Print(`
 KIKI AI SHELL
 
 === 실행 방식 ===
@@ -1160,7 +1160,7 @@ func printHelpTopic(topic string) {
 	t := strings.ToLower(strings.TrimSpace(topic))
 	switch t {
 	case "shell":
-		fmt.Println(`
+		fmt.Print(`
 [help:shell]
   - 인터랙티브 모드: kiki
   - 프롬프트:
@@ -1176,7 +1176,7 @@ func printHelpTopic(topic string) {
   - 일반 명령 입력은 /bin/bash -lc 로 실행됩니다.
 `)
 	case "llm":
-		fmt.Println(`
+		fmt.Print(`
 [help:llm]
   - LLM 서버(OpenAI 호환 /v1/chat/completions):
       LLM_HOST (default 10.0.2.253)
@@ -1194,7 +1194,7 @@ func printHelpTopic(topic string) {
       LLM_PROFILE=deep LLM_STREAM=1 ./kiki ask "원인 분석해줘"
 `)
 	case "file":
-		fmt.Println(`
+		fmt.Print(`
 [help:file]
   - 원샷 첨부:
       ./kiki -f /var/log/messages ask "이 로그 분석"
@@ -1210,7 +1210,7 @@ func printHelpTopic(topic string) {
       RAG on 상태에서 파일을 add 하면 자동으로 RAG 인덱싱합니다.
 `)
 	case "ctx":
-		fmt.Println(`
+		fmt.Print(`
 [help:ctx]
   - 컨텍스트는 시스템 프롬프트 뒤에 [Context] 섹션으로 붙습니다.
       :ctx set cluster=prod
@@ -1219,7 +1219,7 @@ func printHelpTopic(topic string) {
       :ctx clear
 `)
 	case "ctx-size":
-		fmt.Println(`
+		fmt.Print(`
 [help:ctx-size]
   - ctx-size는 LLM의 컨텍스트 윈도우(토큰 수)입니다.
   - KIKI는 목표값을 저장/표시할 수 있지만, 실제 적용은 llama.cpp 서버를 --ctx-size 로 재시작해야 합니다.
@@ -1232,7 +1232,7 @@ func printHelpTopic(topic string) {
     LLM_CTX_OBSERVED=8192   (선택: 관측값 강제)
 `)
 	case "rag":
-		fmt.Println(`
+		fmt.Print(`
 [help:rag]
   - 로컬 RAG: 파일을 청킹해서 ~/.kiki/rag.json에 저장한 뒤, 질문 시 관련 청크를 자동으로 붙입니다.
   - 명령:
@@ -1250,7 +1250,7 @@ func printHelpTopic(topic string) {
       LLM_RAG_MAXCHARS=4000
 `)
 	case "ui":
-		fmt.Println(`
+		fmt.Print(`
 [help:ui]
   - 헤더 고정(권장):
       KIKI_UI_FIXED=1
@@ -1260,7 +1260,7 @@ func printHelpTopic(topic string) {
       :ui clear on|off   (레거시: 전체 clear)
 `)
 	case "env":
-		fmt.Println(`
+		fmt.Print(`
 [help:env]
   UI:
     KIKI_UI_HEADER=1|0
@@ -1435,11 +1435,11 @@ func handleInternalCommand(cfg *Config, st *ShellState, line string) bool {
 		return true
 
 	case "bash":
-		fmt.Println("\n[Entering interactive bash] (type 'exit' to return)\n")
+		fmt.Print("\n[Entering interactive bash] (type 'exit' to return)\n\n")
 		if err := runInteractiveBash(); err != nil {
 			fmt.Fprintln(os.Stderr, "pty bash error:", err)
 		}
-		fmt.Println("\n[Back to KIKI]\n")
+		fmt.Print("\n[Back to KIKI]\n\n")
 		if st.UI.FixedHeader {
 			renderHeader(cfg, st)
 		}